@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyPrefix API key 明文的固定前綴，方便掃描程式辨識外洩的金鑰
+const APIKeyPrefix = "mk_"
+
+// API key 的 scope：ScopeRead 允許 GET/HEAD/OPTIONS，ScopeWrite 允許其他方法，
+// ScopeAdmin 允許使用需要特定角色的端點；權限（Permission）本身也可作為 scope
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// ErrInvalidAPIKey API key 不存在、已撤銷或已過期
+var ErrInvalidAPIKey = errors.New("無效的 API key")
+
+// IsValidScope 檢查 scope 名稱是否有效
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return true
+	}
+	for _, perms := range rolePermissions {
+		if slices.Contains(perms, Permission(scope)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey 產生新的 API key 明文，回傳明文與可公開顯示的識別前綴
+func GenerateAPIKey() (key, prefix string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
+
+// APIKeyPrincipal 通過驗證的 API key 所代表的會員與授權範圍
+type APIKeyPrincipal struct {
+	KeyID         uint
+	MemberID      int64
+	Email         string
+	Roles         []string
+	EmailVerified bool
+	Scopes        []string
+}
+
+// APIKeyStore 驗證 API key 並記錄使用時間與來源 IP
+type APIKeyStore interface {
+	AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*APIKeyPrincipal, error)
+}
+
+var (
+	apiKeyMu    sync.RWMutex
+	apiKeyStore APIKeyStore
+)
+
+// SetAPIKeyStore 設定 AuthMiddleware 驗證 API key 使用的儲存，設為 nil 時不接受 API key
+func SetAPIKeyStore(store APIKeyStore) {
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	apiKeyStore = store
+}
+
+// CurrentAPIKeyStore 回傳目前的 API key 儲存，未設定時為 nil
+func CurrentAPIKeyStore() APIKeyStore {
+	apiKeyMu.RLock()
+	defer apiKeyMu.RUnlock()
+	return apiKeyStore
+}
+
+// methodScope 回傳 HTTP 方法需要的 scope
+func methodScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
+
+// apiKeyScopes 取得 AuthMiddleware 存入 context 的 API key scope；以 JWT 認證時 ok 為 false
+func apiKeyScopes(c *gin.Context) (scopes []string, ok bool) {
+	value, exists := c.Get("api_key_scopes")
+	if !exists {
+		return nil, false
+	}
+	scopes, ok = value.([]string)
+	return scopes, ok
+}
+
+// DenyAPIKey 拒絕以 API key 認證的請求，用於管理憑證或登入狀態的端點，須在 AuthMiddleware 之後使用
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := apiKeyScopes(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "此操作不接受 API key，請使用登入後取得的 token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIKeyStore 以明文對應 principal 的測試用 API key 儲存
+type fakeAPIKeyStore struct {
+	keys    map[string]*APIKeyPrincipal
+	err     error
+	lastIP  string
+	lastKey string
+}
+
+func (s *fakeAPIKeyStore) AuthenticateAPIKey(_ context.Context, key, clientIP string) (*APIKeyPrincipal, error) {
+	s.lastKey, s.lastIP = key, clientIP
+	if s.err != nil {
+		return nil, s.err
+	}
+	principal, ok := s.keys[key]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return principal, nil
+}
+
+// useAPIKeyStore 設定測試用的 API key 儲存，測試結束後恢復
+func useAPIKeyStore(t *testing.T, store APIKeyStore) {
+	t.Helper()
+	previous := CurrentAPIKeyStore()
+	SetAPIKeyStore(store)
+	t.Cleanup(func() { SetAPIKeyStore(previous) })
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(prefix, APIKeyPrefix))
+	assert.Len(t, prefix, len(APIKeyPrefix)+8)
+	assert.True(t, strings.HasPrefix(key, prefix+"_"))
+	assert.Len(t, key, len(prefix)+1+43)
+
+	other, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestIsValidScope(t *testing.T) {
+	assert.True(t, IsValidScope(ScopeRead))
+	assert.True(t, IsValidScope(ScopeWrite))
+	assert.True(t, IsValidScope(ScopeAdmin))
+	assert.True(t, IsValidScope(string(PermMemberDelete)))
+	assert.False(t, IsValidScope("member:write"))
+	assert.False(t, IsValidScope(""))
+}
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakeAPIKeyStore{keys: map[string]*APIKeyPrincipal{
+		"mk_00000001_reader": {KeyID: 1, MemberID: 7, Email: "bot@example.com", Roles: []string{RoleStaff, RoleMember}, EmailVerified: true, Scopes: []string{ScopeRead}},
+		"mk_00000002_writer": {KeyID: 2, MemberID: 7, Email: "bot@example.com", Roles: []string{RoleStaff, RoleMember}, Scopes: []string{ScopeRead, ScopeWrite, string(PermProductDelete)}},
+		"mk_00000003_admin":  {KeyID: 3, MemberID: 8, Email: "admin@example.com", Roles: []string{RoleAdmin, RoleMember}, Scopes: []string{ScopeRead, ScopeWrite, ScopeAdmin}},
+		"mk_00000004_plain":  {KeyID: 4, MemberID: 8, Email: "admin@example.com", Roles: []string{RoleAdmin, RoleMember}, Scopes: []string{ScopeRead, ScopeWrite}},
+	}}
+
+	tests := []struct {
+		name       string
+		store      APIKeyStore
+		method     string
+		header     string
+		middleware []gin.HandlerFunc
+		wantCode   int
+	}{
+		{name: "有效的 read key 讀取", store: store, method: http.MethodGet, header: "ApiKey mk_00000001_reader", wantCode: http.StatusOK},
+		{name: "read key 不可寫入", store: store, method: http.MethodPost, header: "ApiKey mk_00000001_reader", wantCode: http.StatusForbidden},
+		{name: "write key 可寫入", store: store, method: http.MethodPost, header: "ApiKey mk_00000002_writer", wantCode: http.StatusOK},
+		{name: "未知的 key", store: store, method: http.MethodGet, header: "ApiKey mk_00000009_unknown", wantCode: http.StatusUnauthorized},
+		{name: "缺少前綴", store: store, method: http.MethodGet, header: "ApiKey reader", wantCode: http.StatusUnauthorized},
+		{name: "未設定 API key 儲存", store: nil, method: http.MethodGet, header: "ApiKey mk_00000001_reader", wantCode: http.StatusUnauthorized},
+		{name: "儲存發生錯誤", store: &fakeAPIKeyStore{err: errors.New("db down")}, method: http.MethodGet, header: "ApiKey mk_00000001_reader", wantCode: http.StatusInternalServerError},
+		{
+			name: "權限為角色與 scope 的交集：缺少 scope", store: store, method: http.MethodDelete, header: "ApiKey mk_00000001_reader",
+			middleware: []gin.HandlerFunc{RequirePermission(PermProductDelete)}, wantCode: http.StatusForbidden,
+		},
+		{
+			name: "權限為角色與 scope 的交集：兩者皆有", store: store, method: http.MethodDelete, header: "ApiKey mk_00000002_writer",
+			middleware: []gin.HandlerFunc{RequirePermission(PermProductDelete)}, wantCode: http.StatusOK,
+		},
+		{
+			name: "權限為角色與 scope 的交集：角色不足", store: store, method: http.MethodGet, header: "ApiKey mk_00000002_writer",
+			middleware: []gin.HandlerFunc{RequirePermission(PermMemberDelete)}, wantCode: http.StatusForbidden,
+		},
+		{
+			name: "管理端點需要 admin scope", store: store, method: http.MethodGet, header: "ApiKey mk_00000004_plain",
+			middleware: []gin.HandlerFunc{RequireRole(RoleAdmin)}, wantCode: http.StatusForbidden,
+		},
+		{
+			name: "具備 admin scope 的管理員 key", store: store, method: http.MethodGet, header: "ApiKey mk_00000003_admin",
+			middleware: []gin.HandlerFunc{RequireRole(RoleAdmin)}, wantCode: http.StatusOK,
+		},
+		{
+			name: "DenyAPIKey 拒絕 API key", store: store, method: http.MethodGet, header: "ApiKey mk_00000003_admin",
+			middleware: []gin.HandlerFunc{DenyAPIKey()}, wantCode: http.StatusForbidden,
+		},
+		{
+			name: "API key 不代表通過兩步驟驗證", store: store, method: http.MethodGet, header: "ApiKey mk_00000003_admin",
+			middleware: []gin.HandlerFunc{RequireMFA()}, wantCode: http.StatusForbidden,
+		},
+		{
+			name: "email 已驗證的會員", store: store, method: http.MethodGet, header: "ApiKey mk_00000001_reader",
+			middleware: []gin.HandlerFunc{RequireVerifiedEmail()}, wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useAPIKeyStore(t, tt.store)
+
+			router := gin.New()
+			handlers := append([]gin.HandlerFunc{AuthMiddleware()}, tt.middleware...)
+			handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.Handle(tt.method, "/", handlers...)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestAuthMiddlewareAPIKeyContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeAPIKeyStore{keys: map[string]*APIKeyPrincipal{
+		"mk_00000001_reader": {KeyID: 1, MemberID: 7, Email: "bot@example.com", Roles: []string{RoleMember}, Scopes: []string{ScopeRead}},
+	}}
+	useAPIKeyStore(t, store)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "ApiKey mk_00000001_reader")
+	c.Request.RemoteAddr = "203.0.113.9:4321"
+
+	AuthMiddleware()(c)
+
+	assert.False(t, c.IsAborted())
+	assert.Equal(t, "mk_00000001_reader", store.lastKey)
+	assert.Equal(t, "203.0.113.9", store.lastIP)
+	assert.Equal(t, int64(7), c.GetInt64("user_id"))
+	assert.Equal(t, "bot@example.com", c.GetString("user_email"))
+	assert.Equal(t, uint(1), c.GetUint("api_key_id"))
+
+	value, _ := c.Get("token_claims")
+	claims, ok := value.(*Claims)
+	require.True(t, ok)
+	assert.Equal(t, int64(7), claims.UserID)
+	assert.Empty(t, claims.ID)
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrMissingAuthorization 請求沒有 Authorization header
+	ErrMissingAuthorization = errors.New("缺少 Authorization header")
+	// ErrMalformedAuthorization Authorization header 不是 Bearer 或 ApiKey 格式
+	ErrMalformedAuthorization = errors.New("Authorization header 格式錯誤，應為 'Bearer {token}' 或 'ApiKey {key}'")
+	// ErrInvalidToken access token 無效或已過期
+	ErrInvalidToken = errors.New("無效的 token")
+	// ErrTokenRevoked access token 已被撤銷
+	ErrTokenRevoked = errors.New("token 已被撤銷")
+
+	// errRevocationCheck 與 errAPIKeyCheck 表示儲存層發生錯誤，無法判斷憑證是否有效
+	errRevocationCheck = errors.New("無法驗證 token 狀態")
+	errAPIKeyCheck     = errors.New("無法驗證 API key")
+)
+
+// Identity 通過認證的呼叫者。以 API key 認證時 APIKeyID 不為 0，權限受 Scopes 限制
+type Identity struct {
+	Claims   *Claims
+	APIKeyID uint
+	Scopes   []string
+}
+
+// IsAPIKey 是否以 API key 認證
+func (i *Identity) IsAPIKey() bool {
+	return i.APIKeyID != 0
+}
+
+// HasScope 檢查 API key 是否具備 scope；以 JWT 認證時一律為 true
+func (i *Identity) HasScope(scope string) bool {
+	return !i.IsAPIKey() || slices.Contains(i.Scopes, scope)
+}
+
+// HasRole 檢查呼叫者是否具備任一指定角色；API key 還必須具備 admin scope（與 RequireRole 相同）
+func (i *Identity) HasRole(roles ...string) bool {
+	return HasRole(i.Claims.Roles, roles...) && i.HasScope(ScopeAdmin)
+}
+
+// HasPermission 檢查呼叫者是否具備權限；API key 的權限為角色權限與 scope 的交集
+func (i *Identity) HasPermission(perm Permission) bool {
+	return HasPermission(i.Claims.Roles, perm) && i.HasScope(string(perm))
+}
+
+// identityKey context 中保存 Identity 的 key
+type identityKey struct{}
+
+// WithIdentity 將通過認證的身分存入 context
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext 取得 WithIdentity 存入的身分，未認證時 ok 為 false
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// Authenticate 驗證 Authorization header（Bearer access token 或 ApiKey），REST 與 GraphQL 共用。
+// clientIP 與 userAgent 用於記錄 API key 與工作階段的使用紀錄
+func Authenticate(ctx context.Context, header, clientIP, userAgent string) (*Identity, error) {
+	if header == "" {
+		return nil, ErrMissingAuthorization
+	}
+
+	scheme, credential, found := strings.Cut(header, " ")
+	if !found || (scheme != "Bearer" && scheme != "ApiKey") {
+		return nil, ErrMalformedAuthorization
+	}
+
+	if scheme == "ApiKey" {
+		return authenticateAPIKey(ctx, credential, clientIP)
+	}
+
+	claims, err := ValidateToken(credential)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// 檢查 token 是否已被撤銷（登出、工作階段撤銷或管理員撤銷）
+	if store := CurrentRevocationStore(); store != nil {
+		revoked, err := store.IsRevoked(ctx, claims)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errRevocationCheck, err)
+		}
+		if !revoked && claims.IsImpersonated() {
+			// 管理員本身的 token 全部被撤銷時，其模擬登入也一併失效
+			revoked, err = store.IsRevoked(ctx, &Claims{
+				UserID:           claims.Actor.UserID,
+				RegisteredClaims: jwt.RegisteredClaims{IssuedAt: claims.IssuedAt},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errRevocationCheck, err)
+			}
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	if tracker := CurrentSessionTracker(); tracker != nil && claims.SessionID != 0 {
+		tracker.TouchSession(ctx, claims, clientIP, userAgent)
+	}
+
+	return &Identity{Claims: claims}, nil
+}
+
+// authenticateAPIKey 驗證 API key，並以 Claims 表示其身分，讓依 claims 判斷的檢查維持一致；
+// API key 沒有 jti 也不代表通過兩步驟驗證
+func authenticateAPIKey(ctx context.Context, key, clientIP string) (*Identity, error) {
+	store := CurrentAPIKeyStore()
+	if store == nil || !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	principal, err := store.AuthenticateAPIKey(ctx, key, clientIP)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAPIKey) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errAPIKeyCheck, err)
+	}
+
+	return &Identity{
+		Claims: &Claims{
+			UserID:        principal.MemberID,
+			Email:         principal.Email,
+			Roles:         principal.Roles,
+			EmailVerified: principal.EmailVerified,
+		},
+		APIKeyID: principal.KeyID,
+		Scopes:   principal.Scopes,
+	}, nil
+}
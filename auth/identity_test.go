@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticate(t *testing.T) {
+	setupTest(t)
+
+	store := NewMemoryRevocationStore()
+	SetRevocationStore(store)
+	t.Cleanup(func() { SetRevocationStore(nil) })
+	useAPIKeyStore(t, &fakeAPIKeyStore{keys: map[string]*APIKeyPrincipal{
+		"mk_00000001_reader": {KeyID: 1, MemberID: 7, Email: "bot@example.com", Roles: []string{RoleMember}, Scopes: []string{ScopeRead}},
+	}})
+
+	valid, err := GenerateToken(3, "user@example.com", WithRoles(RoleStaff))
+	require.NoError(t, err)
+	revoked, err := GenerateToken(4, "revoked@example.com")
+	require.NoError(t, err)
+	revokedClaims, err := ValidateToken(revoked)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeToken(context.Background(), revokedClaims.ID, 4, revokedClaims.ExpiresAt.Time))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantErr    error
+		wantUserID int64
+		wantAPIKey bool
+	}{
+		{name: "缺少 header", header: "", wantErr: ErrMissingAuthorization},
+		{name: "未知的 scheme", header: "Basic dXNlcjpwYXNz", wantErr: ErrMalformedAuthorization},
+		{name: "缺少憑證", header: "Bearer", wantErr: ErrMalformedAuthorization},
+		{name: "無效的 token", header: "Bearer invalid", wantErr: ErrInvalidToken},
+		{name: "已撤銷的 token", header: "Bearer " + revoked, wantErr: ErrTokenRevoked},
+		{name: "無效的 API key", header: "ApiKey mk_00000009_unknown", wantErr: ErrInvalidAPIKey},
+		{name: "有效的 token", header: "Bearer " + valid, wantUserID: 3},
+		{name: "有效的 API key", header: "ApiKey mk_00000001_reader", wantUserID: 7, wantAPIKey: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := Authenticate(context.Background(), tt.header, "203.0.113.9", "test")
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.Nil(t, identity)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUserID, identity.Claims.UserID)
+			assert.Equal(t, tt.wantAPIKey, identity.IsAPIKey())
+		})
+	}
+}
+
+func TestIdentityPermissions(t *testing.T) {
+	staff := &Identity{Claims: &Claims{Roles: []string{RoleStaff}}}
+	assert.True(t, staff.HasScope(ScopeWrite), "以 JWT 認證時不受 scope 限制")
+	assert.True(t, staff.HasRole(RoleStaff))
+	assert.False(t, staff.HasRole(RoleAdmin))
+	assert.True(t, staff.HasPermission(PermProductDelete))
+	assert.False(t, staff.HasPermission(PermMemberDelete))
+
+	adminKey := &Identity{Claims: &Claims{Roles: []string{RoleAdmin}}, APIKeyID: 1, Scopes: []string{ScopeRead, string(PermMemberDelete)}}
+	assert.True(t, adminKey.HasScope(ScopeRead))
+	assert.False(t, adminKey.HasScope(ScopeWrite))
+	assert.False(t, adminKey.HasRole(RoleAdmin), "API key 需要 admin scope")
+	assert.True(t, adminKey.HasPermission(PermMemberDelete))
+	assert.False(t, adminKey.HasPermission(PermProductDelete), "權限為角色與 scope 的交集")
+}
+
+func TestIdentityContext(t *testing.T) {
+	_, ok := IdentityFromContext(context.Background())
+	assert.False(t, ok)
+
+	identity := &Identity{Claims: &Claims{UserID: 9}}
+	got, ok := IdentityFromContext(WithIdentity(context.Background(), identity))
+	assert.True(t, ok)
+	assert.Same(t, identity, got)
+
+	_, ok = IdentityFromContext(WithIdentity(context.Background(), nil))
+	assert.False(t, ok)
+}
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTest(t)
+	useAPIKeyStore(t, &fakeAPIKeyStore{keys: map[string]*APIKeyPrincipal{
+		"mk_00000001_reader": {KeyID: 1, MemberID: 7, Email: "bot@example.com", Roles: []string{RoleMember}, Scopes: []string{ScopeRead}},
+	}})
+
+	valid, err := GenerateToken(3, "user@example.com", WithEmailVerified(true))
+	require.NoError(t, err)
+	expired := createTestClaims(3, "user@example.com", time.Now().Add(-time.Hour), time.Now().Add(-2*time.Hour))
+	expiredToken, err := signClaims(expired)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantCode   int
+		wantUserID int64
+	}{
+		{name: "未提供憑證以匿名繼續", header: "", wantCode: http.StatusOK},
+		{name: "有效的 token", header: "Bearer " + valid, wantCode: http.StatusOK, wantUserID: 3},
+		{name: "過期的 token", header: "Bearer " + expiredToken, wantCode: http.StatusUnauthorized},
+		{name: "格式錯誤", header: "Token abc", wantCode: http.StatusUnauthorized},
+		{name: "read key 的 POST 交由後續檢查", header: "ApiKey mk_00000001_reader", wantCode: http.StatusOK, wantUserID: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID int64
+			router := gin.New()
+			router.POST("/", OptionalAuthMiddleware(), func(c *gin.Context) {
+				if identity, ok := IdentityFromContext(c.Request.Context()); ok {
+					userID = identity.Claims.UserID
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			assert.Equal(t, tt.wantUserID, userID)
+		})
+	}
+}
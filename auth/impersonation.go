@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ImpersonationTTL 模擬登入 token 的有效期限
+const ImpersonationTTL = 15 * time.Minute
+
+// Actor 實際持有 token 的管理員（RFC 8693 的 act claim），表示 token 是代表會員操作的模擬登入
+type Actor struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+}
+
+// WithImpersonation 將 token 標記為管理員代表會員操作，有效期限縮短為 ImpersonationTTL
+func WithImpersonation(actorID int64, actorEmail string) TokenOption {
+	return func(c *Claims) {
+		c.Actor = &Actor{UserID: actorID, Email: actorEmail}
+		c.ExpiresAt = jwt.NewNumericDate(c.IssuedAt.Add(ImpersonationTTL))
+	}
+}
+
+// IsImpersonated 是否為管理員模擬會員的 token
+func (c *Claims) IsImpersonated() bool {
+	return c.Actor != nil
+}
+
+// isReadOnlyMethod 判斷 HTTP 方法是否不會修改資料
+func isReadOnlyMethod(method string) bool {
+	return methodScope(method) == ScopeRead
+}
+
+// DenyImpersonatedWrites 拒絕模擬登入 token 的寫入請求（GET、HEAD、OPTIONS 以外），須在 AuthMiddleware 之後使用
+func DenyImpersonatedWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := claimsFromContext(c); claims != nil && claims.IsImpersonated() && !isReadOnlyMethod(c.Request.Method) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "模擬登入期間不可執行寫入操作"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyImpersonation 拒絕模擬登入 token，用於管理憑證或登入狀態的端點，須在 AuthMiddleware 之後使用
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := claimsFromContext(c); claims != nil && claims.IsImpersonated() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "模擬登入期間不可執行此操作"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// claimsFromContext 取得 AuthMiddleware 存入 context 的 claims
+func claimsFromContext(c *gin.Context) *Claims {
+	value, _ := c.Get("token_claims")
+	claims, _ := value.(*Claims)
+	return claims
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationToken(t *testing.T) {
+	setupTest(t)
+
+	token, err := GenerateToken(2, "member@example.com", WithRoles(RoleMember), WithImpersonation(1, "admin@example.com"))
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(token)
+	require.NoError(t, err)
+	assert.True(t, claims.IsImpersonated())
+	assert.Equal(t, int64(2), claims.UserID)
+	require.NotNil(t, claims.Actor)
+	assert.Equal(t, int64(1), claims.Actor.UserID)
+	assert.Equal(t, "admin@example.com", claims.Actor.Email)
+	assert.WithinDuration(t, claims.IssuedAt.Add(ImpersonationTTL), claims.ExpiresAt.Time, time.Second)
+
+	regular, err := GenerateToken(2, "member@example.com")
+	require.NoError(t, err)
+	claims, err = ValidateToken(regular)
+	require.NoError(t, err)
+	assert.False(t, claims.IsImpersonated())
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	impersonated := &Claims{UserID: 2, Actor: &Actor{UserID: 1}}
+	regular := &Claims{UserID: 2}
+
+	tests := []struct {
+		name       string
+		claims     *Claims
+		method     string
+		middleware gin.HandlerFunc
+		wantCode   int
+	}{
+		{name: "模擬登入可讀取", claims: impersonated, method: http.MethodGet, middleware: DenyImpersonatedWrites(), wantCode: http.StatusOK},
+		{name: "模擬登入不可寫入", claims: impersonated, method: http.MethodPost, middleware: DenyImpersonatedWrites(), wantCode: http.StatusForbidden},
+		{name: "模擬登入不可刪除", claims: impersonated, method: http.MethodDelete, middleware: DenyImpersonatedWrites(), wantCode: http.StatusForbidden},
+		{name: "一般 token 可寫入", claims: regular, method: http.MethodPost, middleware: DenyImpersonatedWrites(), wantCode: http.StatusOK},
+		{name: "憑證管理拒絕模擬登入的讀取", claims: impersonated, method: http.MethodGet, middleware: DenyImpersonation(), wantCode: http.StatusForbidden},
+		{name: "憑證管理接受一般 token", claims: regular, method: http.MethodGet, middleware: DenyImpersonation(), wantCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Handle(tt.method, "/", func(c *gin.Context) {
+				c.Set("token_claims", tt.claims)
+				c.Next()
+			}, tt.middleware, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, "/", nil))
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
+
+func TestAuthenticateImpersonationRevokedWithActor(t *testing.T) {
+	setupTest(t)
+
+	store := NewMemoryRevocationStore()
+	SetRevocationStore(store)
+	t.Cleanup(func() { SetRevocationStore(nil) })
+
+	token, err := GenerateToken(2, "member@example.com", WithImpersonation(1, "admin@example.com"))
+	require.NoError(t, err)
+
+	_, err = Authenticate(context.Background(), "Bearer "+token, "", "")
+	require.NoError(t, err)
+
+	// 撤銷管理員本身的所有 token 時，其模擬登入也一併失效
+	require.NoError(t, store.RevokeAllForMember(context.Background(), 1, time.Now()))
+	_, err = Authenticate(context.Background(), "Bearer "+token, "", "")
+	assert.True(t, errors.Is(err, ErrTokenRevoked), "got %v", err)
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"time"
 
@@ -10,44 +12,179 @@ import (
 var jwtSecret []byte
 
 func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
-	}
+	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+}
+
+// ErrNoSigningKey 未設定金鑰環也未設定 JWT_SECRET
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// SetSecret 設定未使用金鑰環時 HS256 使用的密鑰
+func SetSecret(secret string) {
 	jwtSecret = []byte(secret)
 }
 
 // Claims 定義 JWT 的 claims
 type Claims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+	UserID        int64    `json:"user_id"`
+	Email         string   `json:"email"`
+	Roles         []string `json:"roles,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+	// MFA 表示會員已啟用兩步驟驗證，且此 token 是通過第二因素後簽發的
+	MFA bool `json:"mfa,omitempty"`
+	// SessionID 簽發此 token 的登入工作階段，撤銷工作階段時一併失效
+	SessionID uint `json:"sid,omitempty"`
+	// Purpose 非空時表示這是用於特定流程（例如 email 驗證）的 token，不能作為 access token
+	Purpose string `json:"pur,omitempty"`
+	// Actor 非空時表示這是管理員模擬此會員的 token
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// ErrTokenPurpose token 的用途與預期不符
+var ErrTokenPurpose = errors.New("token purpose mismatch")
+
+// TokenOption 用於調整 GenerateToken 產生的 claims
+type TokenOption func(*Claims)
+
+// WithRoles 將會員角色寫入 token
+func WithRoles(roles ...string) TokenOption {
+	return func(c *Claims) {
+		c.Roles = roles
+	}
+}
+
+// AccessTokenTTL access token 的有效期限
+const AccessTokenTTL = 24 * time.Hour
+
+// WithEmailVerified 標記會員的 email 是否已驗證
+func WithEmailVerified(verified bool) TokenOption {
+	return func(c *Claims) {
+		c.EmailVerified = verified
+	}
+}
+
+// WithMFA 標記 token 是否經過兩步驟驗證簽發
+func WithMFA(mfa bool) TokenOption {
+	return func(c *Claims) {
+		c.MFA = mfa
+	}
+}
+
+// WithSession 將 token 綁定到登入工作階段
+func WithSession(sessionID uint) TokenOption {
+	return func(c *Claims) {
+		c.SessionID = sessionID
+	}
+}
+
+// withPurpose 設定 token 用途與有效期限
+func withPurpose(purpose string, ttl time.Duration) TokenOption {
+	return func(c *Claims) {
+		c.Purpose = purpose
+		c.ExpiresAt = jwt.NewNumericDate(c.IssuedAt.Add(ttl))
+	}
+}
+
+// GeneratePurposeToken 生成用於特定流程的短期 token（例如 email 驗證連結），無法作為 access token 使用
+func GeneratePurposeToken(purpose string, userID int64, email string, ttl time.Duration) (string, error) {
+	return GenerateToken(userID, email, withPurpose(purpose, ttl))
+}
+
+// ValidatePurposeToken 驗證特定用途的 token
+func ValidatePurposeToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if purpose == "" || claims.Purpose != purpose {
+		return nil, ErrTokenPurpose
+	}
+	return claims, nil
+}
+
 // GenerateToken 生成 JWT token
-func GenerateToken(userID int64, email string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // 24小時過期
+func GenerateToken(userID int64, email string, opts ...TokenOption) (string, error) {
+	expirationTime := time.Now().Add(AccessTokenTTL) // 24小時過期
+
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
 
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "member-api",
 		},
 	}
+	for _, opt := range opts {
+		opt(claims)
+	}
 
+	return signClaims(claims)
+}
+
+// signClaims 使用金鑰環中目前的金鑰簽章；未設定金鑰環時使用 HS256
+func signClaims(claims jwt.Claims) (string, error) {
+	if ring := CurrentKeyRing(); ring != nil {
+		key := ring.Current()
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.Private)
+	}
+
+	if len(jwtSecret) == 0 {
+		return "", ErrNoSigningKey
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
 
-// ValidateToken 驗證 JWT token
+// verificationKeyFunc 依 token header 的 alg 與 kid 選擇驗證金鑰
+func verificationKeyFunc(token *jwt.Token) (interface{}, error) {
+	ring := CurrentKeyRing()
+	if ring == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		if len(jwtSecret) == 0 {
+			return nil, ErrNoSigningKey
+		}
+		return jwtSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ring.lookup(kid)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// 防止演算法混淆攻擊：alg 必須與金鑰類型一致
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// ValidateToken 驗證 JWT access token
 func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrTokenPurpose
+	}
+	return claims, nil
+}
+
+// parseClaims 驗證簽章與有效期限並解析 claims
+func parseClaims(tokenString string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKeyFunc)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey token 的 kid 不在金鑰環中或已超過輪替重疊期
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// ErrKeyIDReused 輪替的新金鑰沿用了目前的 kid，但金鑰內容不同
+var ErrKeyIDReused = errors.New("signing key id reused with different key material")
+
+// SigningKey 以 kid 識別的非對稱簽章金鑰（RS256 或 EdDSA）
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+}
+
+// verificationKey 用於驗證簽章的公鑰，retireAt 為零值時表示不會過期
+type verificationKey struct {
+	id       string
+	method   jwt.SigningMethod
+	public   crypto.PublicKey
+	retireAt time.Time
+}
+
+// KeyRing 保存目前的簽章金鑰，以及輪替後在重疊期內仍可驗證的舊金鑰
+type KeyRing struct {
+	mu       sync.RWMutex
+	current  *SigningKey
+	previous []verificationKey
+	overlap  time.Duration
+	now      func() time.Time
+}
+
+// NewKeyRing 建立金鑰環，overlap 為舊金鑰在輪替後仍被接受的時間
+func NewKeyRing(current *SigningKey, overlap time.Duration) *KeyRing {
+	return &KeyRing{current: current, overlap: overlap, now: time.Now}
+}
+
+// AddPrevious 加入一把輪替前的金鑰，在目前時間加上重疊期之前仍可用於驗證
+func (r *KeyRing) AddPrevious(id string, public crypto.PublicKey) error {
+	method, err := signingMethodFor(public)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.previous = append(r.previous, verificationKey{
+		id:       id,
+		method:   method,
+		public:   public,
+		retireAt: r.now().Add(r.overlap),
+	})
+	return nil
+}
+
+// Rotate 以新金鑰取代目前的簽章金鑰，舊金鑰在重疊期內仍可驗證；
+// 新金鑰不可沿用目前的 kid（除非是同一把金鑰），否則舊 token 會無法驗證
+func (r *KeyRing) Rotate(next *SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != nil && r.current.ID == next.ID {
+		if !samePublicKey(r.current.Private.Public(), next.Private.Public()) {
+			return ErrKeyIDReused
+		}
+		r.current = next
+		return nil
+	}
+	if r.current != nil {
+		r.previous = append(r.previous, verificationKey{
+			id:       r.current.ID,
+			method:   r.current.Method,
+			public:   r.current.Private.Public(),
+			retireAt: r.now().Add(r.overlap),
+		})
+	}
+	r.current = next
+	return nil
+}
+
+// Reload 從 PEM 檔案重新載入簽章金鑰並輪替；kid 一律使用 JWK thumbprint，
+// 避免固定的 JWT_SIGNING_KEY_ID 讓新金鑰取代舊金鑰的 kid
+func (r *KeyRing) Reload(path string) (*SigningKey, error) {
+	key, err := LoadSigningKey("", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Rotate(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// samePublicKey 比較兩把公鑰是否相同
+func samePublicKey(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// Current 回傳目前的簽章金鑰
+func (r *KeyRing) Current() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current
+}
+
+// verificationKeys 回傳目前有效的驗證金鑰（已超過重疊期的舊金鑰會被排除）
+func (r *KeyRing) verificationKeys() []verificationKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	keys := make([]verificationKey, 0, len(r.previous)+1)
+	if r.current != nil {
+		keys = append(keys, verificationKey{
+			id:     r.current.ID,
+			method: r.current.Method,
+			public: r.current.Private.Public(),
+		})
+	}
+	for _, key := range r.previous {
+		if now.Before(key.retireAt) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// lookup 依 kid 取得驗證金鑰
+func (r *KeyRing) lookup(kid string) (verificationKey, bool) {
+	for _, key := range r.verificationKeys() {
+		if key.id == kid {
+			return key, true
+		}
+	}
+	return verificationKey{}, false
+}
+
+// JWK 表示 JSON Web Key（RFC 7517）中的公鑰
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet 表示 JWKS 文件
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 回傳目前所有有效驗證金鑰的公開 JWKS
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range r.verificationKeys() {
+		jwk, err := publicJWK(key.public)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.id
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// publicJWK 將公鑰轉換為 JWK（不含 kid/use/alg）
+func publicJWK(public crypto.PublicKey) (JWK, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", public)
+	}
+}
+
+// Thumbprint 計算公鑰的 JWK thumbprint（RFC 7638），未指定 kid 時作為預設值
+func Thumbprint(public crypto.PublicKey) (string, error) {
+	jwk, err := publicJWK(public)
+	if err != nil {
+		return "", err
+	}
+
+	// RFC 7638 要求只包含必要成員並依字典序排列
+	var members map[string]string
+	switch jwk.Kty {
+	case "RSA":
+		members = map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N}
+	default:
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// signingMethodFor 依公鑰類型決定簽章演算法
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (only RSA and Ed25519 are supported)", public)
+	}
+}
+
+// readPEM 讀取 PEM 檔案的第一個區塊
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	return block, nil
+}
+
+// parsePrivateKey 解析 PKCS#8 或 PKCS#1 私鑰
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// LoadSigningKey 從 PEM 檔案載入私鑰，id 為空時以 JWK thumbprint 作為 kid
+func LoadSigningKey(id, path string) (*SigningKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	method, err := signingMethodFor(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if id == "" {
+		if id, err = Thumbprint(private.Public()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SigningKey{ID: id, Method: method, Private: private}, nil
+}
+
+// LoadPublicKey 從 PEM 檔案載入公鑰；若檔案為私鑰則取其公鑰
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if public, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return public, nil
+	}
+	if public, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return public, nil
+	}
+
+	private, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return private.Public(), nil
+}
+
+// LoadKeyRing 載入目前的簽章金鑰與輪替前的舊金鑰。
+// previous 中的項目可為 "kid=path" 或僅有 "path"（以 thumbprint 作為 kid）。
+func LoadKeyRing(signingKeyID, signingKeyFile string, previous []string, overlap time.Duration) (*KeyRing, error) {
+	current, err := LoadSigningKey(signingKeyID, signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ring := NewKeyRing(current, overlap)
+	for _, entry := range previous {
+		id, path, found := strings.Cut(entry, "=")
+		if !found {
+			id, path = "", entry
+		}
+
+		public, err := LoadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			if id, err = Thumbprint(public); err != nil {
+				return nil, err
+			}
+		}
+		if err := ring.AddPrevious(id, public); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return ring, nil
+}
+
+var (
+	keyRingMu sync.RWMutex
+	keyRing   *KeyRing
+)
+
+// SetKeyRing 設定全域金鑰環；設為 nil 時退回使用 JWT_SECRET 的 HS256
+func SetKeyRing(ring *KeyRing) {
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	keyRing = ring
+}
+
+// CurrentKeyRing 回傳全域金鑰環，未設定時為 nil
+func CurrentKeyRing() *KeyRing {
+	keyRingMu.RLock()
+	defer keyRingMu.RUnlock()
+	return keyRing
+}
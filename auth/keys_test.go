@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePEM 將 DER 資料以 PEM 格式寫入暫存目錄
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// newRSAKeyFile 產生 RSA 私鑰並寫成 PKCS#8 PEM 檔案
+func newRSAKeyFile(t *testing.T) (string, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "rsa.pem", "PRIVATE KEY", der), key
+}
+
+// newEd25519KeyFile 產生 Ed25519 私鑰並寫成 PKCS#8 PEM 檔案
+func newEd25519KeyFile(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "ed25519.pem", "PRIVATE KEY", der), key
+}
+
+// useKeyRing 設定測試用金鑰環，測試結束後恢復為 HS256
+func useKeyRing(t *testing.T, ring *KeyRing) {
+	t.Helper()
+	SetKeyRing(ring)
+	t.Cleanup(func() { SetKeyRing(nil) })
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	rsaPath, _ := newRSAKeyFile(t)
+	edPath, _ := newEd25519KeyFile(t)
+
+	pkcs1Key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pkcs1Path := writePEM(t, "rsa-pkcs1.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(pkcs1Key))
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a pem"), 0o600))
+
+	tests := []struct {
+		name       string
+		id         string
+		path       string
+		wantErr    bool
+		wantAlg    string
+		wantCustom bool
+	}{
+		{name: "RSA PKCS#8", path: rsaPath, wantAlg: "RS256"},
+		{name: "RSA PKCS#1", path: pkcs1Path, wantAlg: "RS256"},
+		{name: "Ed25519", path: edPath, wantAlg: "EdDSA"},
+		{name: "指定 kid", id: "key-2024", path: edPath, wantAlg: "EdDSA", wantCustom: true},
+		{name: "檔案不存在", path: filepath.Join(t.TempDir(), "missing.pem"), wantErr: true},
+		{name: "非 PEM 檔案", path: invalidPath, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadSigningKey(tt.id, tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, key.Method.Alg())
+			if tt.wantCustom {
+				assert.Equal(t, tt.id, key.ID)
+			} else {
+				thumbprint, err := Thumbprint(key.Private.Public())
+				require.NoError(t, err)
+				assert.Equal(t, thumbprint, key.ID, "未指定 kid 時應使用 thumbprint")
+			}
+		})
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	_, key := newEd25519KeyFile(t)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	publicPath := writePEM(t, "public.pem", "PUBLIC KEY", der)
+
+	public, err := LoadPublicKey(publicPath)
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), public)
+
+	// 私鑰檔案也可以作為驗證金鑰載入
+	rsaPath, rsaKey := newRSAKeyFile(t)
+	public, err = LoadPublicKey(rsaPath)
+	require.NoError(t, err)
+	assert.Equal(t, rsaKey.Public(), public)
+}
+
+func TestAsymmetricTokenRoundTrip(t *testing.T) {
+	setupTest(t)
+	rsaPath, _ := newRSAKeyFile(t)
+	edPath, _ := newEd25519KeyFile(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantAlg string
+	}{
+		{name: "RS256", path: rsaPath, wantAlg: "RS256"},
+		{name: "EdDSA", path: edPath, wantAlg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadKeyRing("", tt.path, nil, time.Hour)
+			require.NoError(t, err)
+			useKeyRing(t, ring)
+
+			token, err := GenerateToken(42, "rsa@example.com", WithRoles(RoleStaff))
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, parsed.Header["alg"])
+			assert.Equal(t, ring.Current().ID, parsed.Header["kid"])
+
+			claims, err := ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, int64(42), claims.UserID)
+			assert.Equal(t, []string{RoleStaff}, claims.Roles)
+		})
+	}
+}
+
+func TestValidateTokenRejectsWrongKeys(t *testing.T) {
+	setupTest(t)
+
+	// 在 HS256 模式下簽發的 token
+	hsToken, err := GenerateToken(1, "hs@example.com")
+	require.NoError(t, err)
+
+	edPath, _ := newEd25519KeyFile(t)
+	ring, err := LoadKeyRing("current", edPath, nil, time.Hour)
+	require.NoError(t, err)
+	useKeyRing(t, ring)
+
+	_, otherKey := newEd25519KeyFile(t)
+	claims := createTestClaims(1, "test@example.com", time.Now().Add(time.Hour), time.Now())
+
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	unknownKid.Header["kid"] = "unknown"
+	unknownKidToken, err := unknownKid.SignedString(otherKey)
+	require.NoError(t, err)
+
+	forgedKid := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	forgedKid.Header["kid"] = "current"
+	forgedKidToken, err := forgedKid.SignedString(otherKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "設定金鑰環後拒絕 HS256 token", token: hsToken},
+		{name: "未知的 kid", token: unknownKidToken},
+		{name: "kid 正確但簽章金鑰錯誤", token: forgedKidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateToken(tt.token)
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	setupTest(t)
+
+	oldPath, _ := newEd25519KeyFile(t)
+	newPath, _ := newRSAKeyFile(t)
+
+	ring, err := LoadKeyRing("old", oldPath, nil, time.Hour)
+	require.NoError(t, err)
+	now := time.Now()
+	ring.now = func() time.Time { return now }
+	useKeyRing(t, ring)
+
+	oldToken, err := GenerateToken(1, "old@example.com")
+	require.NoError(t, err)
+
+	next, err := LoadSigningKey("new", newPath)
+	require.NoError(t, err)
+	require.NoError(t, ring.Rotate(next))
+
+	newToken, err := GenerateToken(2, "new@example.com")
+	require.NoError(t, err)
+
+	// 重疊期內新舊 token 都有效，且 JWKS 同時公開兩把金鑰
+	_, err = ValidateToken(oldToken)
+	assert.NoError(t, err, "重疊期內應接受舊金鑰簽發的 token")
+	_, err = ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Len(t, ring.JWKS().Keys, 2)
+
+	// 超過重疊期後舊金鑰失效
+	now = now.Add(time.Hour + time.Second)
+	_, err = ValidateToken(oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = ValidateToken(newToken)
+	assert.NoError(t, err)
+	assert.Len(t, ring.JWKS().Keys, 1)
+}
+
+func TestKeyRingReloadWithConfiguredKeyID(t *testing.T) {
+	setupTest(t)
+
+	oldPath, _ := newEd25519KeyFile(t)
+	newPath, _ := newEd25519KeyFile(t)
+
+	// 啟動時使用 JWT_SIGNING_KEY_ID 指定的 kid
+	ring, err := LoadKeyRing("configured", oldPath, nil, time.Hour)
+	require.NoError(t, err)
+	useKeyRing(t, ring)
+
+	oldToken, err := GenerateToken(1, "old@example.com")
+	require.NoError(t, err)
+
+	// 以相同 kid 輪替不同金鑰會讓舊 token 失效，必須拒絕
+	same, err := LoadSigningKey("configured", newPath)
+	require.NoError(t, err)
+	assert.ErrorIs(t, ring.Rotate(same), ErrKeyIDReused)
+
+	// 重新載入時以 thumbprint 作為 kid，舊金鑰在重疊期內仍可驗證
+	next, err := ring.Reload(newPath)
+	require.NoError(t, err)
+	assert.NotEqual(t, "configured", next.ID)
+
+	_, err = ValidateToken(oldToken)
+	assert.NoError(t, err, "重新載入後重疊期內應接受舊金鑰簽發的 token")
+	newToken, err := GenerateToken(2, "new@example.com")
+	require.NoError(t, err)
+	_, err = ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestLoadKeyRingWithPreviousKeys(t *testing.T) {
+	setupTest(t)
+
+	previousPath, previousKey := newEd25519KeyFile(t)
+	currentPath, _ := newEd25519KeyFile(t)
+
+	// 以舊金鑰簽發 token
+	oldRing := NewKeyRing(&SigningKey{ID: "2023", Method: jwt.SigningMethodEdDSA, Private: previousKey}, time.Hour)
+	useKeyRing(t, oldRing)
+	oldToken, err := GenerateToken(1, "old@example.com")
+	require.NoError(t, err)
+
+	ring, err := LoadKeyRing("2024", currentPath, []string{"2023=" + previousPath}, time.Hour)
+	require.NoError(t, err)
+	SetKeyRing(ring)
+
+	claims, err := ValidateToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "old@example.com", claims.Email)
+
+	_, err = LoadKeyRing("2024", currentPath, []string{"2023=" + filepath.Join(t.TempDir(), "missing.pem")}, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	rsaPath, _ := newRSAKeyFile(t)
+	edPath, _ := newEd25519KeyFile(t)
+
+	ring, err := LoadKeyRing("rsa-key", rsaPath, []string{"ed-key=" + edPath}, time.Hour)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(ring.JWKS())
+	require.NoError(t, err)
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(data, &set))
+	require.Len(t, set.Keys, 2)
+
+	rsaJWK := set.Keys[0]
+	assert.Equal(t, "RSA", rsaJWK["kty"])
+	assert.Equal(t, "rsa-key", rsaJWK["kid"])
+	assert.Equal(t, "RS256", rsaJWK["alg"])
+	assert.Equal(t, "sig", rsaJWK["use"])
+	assert.Equal(t, "AQAB", rsaJWK["e"])
+	assert.NotEmpty(t, rsaJWK["n"])
+	assert.NotContains(t, rsaJWK, "d", "JWKS 不得包含私鑰成員")
+
+	edJWK := set.Keys[1]
+	assert.Equal(t, "OKP", edJWK["kty"])
+	assert.Equal(t, "Ed25519", edJWK["crv"])
+	assert.Equal(t, "EdDSA", edJWK["alg"])
+	assert.Equal(t, "ed-key", edJWK["kid"])
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 7638 第 3.1 節範例
+	n := "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	require.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537}
+
+	thumbprint, err := Thumbprint(public)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
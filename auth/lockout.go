@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LockoutPolicy 登入失敗的鎖定規則：連續失敗達 MaxAttempts 次後鎖定 Duration，
+// 之後每多失敗一次鎖定時間加倍，最長為 MaxDuration。MaxAttempts 為 0 時停用
+type LockoutPolicy struct {
+	MaxAttempts int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// Enabled 是否啟用鎖定
+func (p LockoutPolicy) Enabled() bool {
+	return p.MaxAttempts > 0 && p.Duration > 0
+}
+
+// LockDuration 依連續失敗次數計算需鎖定的時間，未達門檻時回傳 0
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if !p.Enabled() || failures < p.MaxAttempts {
+		return 0
+	}
+
+	duration := p.Duration
+	for i := p.MaxAttempts; i < failures; i++ {
+		duration *= 2
+		if p.MaxDuration > 0 && duration >= p.MaxDuration {
+			return p.MaxDuration
+		}
+	}
+	if p.MaxDuration > 0 && duration > p.MaxDuration {
+		return p.MaxDuration
+	}
+	return duration
+}
+
+// throttleEntry 單一來源的登入失敗紀錄
+type throttleEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// LoginThrottle 以記憶體依來源（例如用戶端 IP）記錄登入失敗次數並逐步延遲。
+// 紀錄只存在於單一實例，超過 MaxDuration 沒有新的失敗即會被遺忘
+type LoginThrottle struct {
+	policy    LockoutPolicy
+	mu        sync.Mutex
+	entries   map[string]*throttleEntry
+	lastPurge time.Time
+	now       func() time.Time
+}
+
+// NewLoginThrottle 建立登入節流器
+func NewLoginThrottle(policy LockoutPolicy) *LoginThrottle {
+	return &LoginThrottle{
+		policy:  policy,
+		entries: make(map[string]*throttleEntry),
+		now:     time.Now,
+	}
+}
+
+// RetryAfter 回傳 key 需要再等待多久才能嘗試登入，0 表示可以嘗試
+func (t *LoginThrottle) RetryAfter(key string) time.Duration {
+	if t == nil || !t.policy.Enabled() {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok {
+		return 0
+	}
+	if wait := entry.blockedUntil.Sub(t.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Failure 記錄一次登入失敗，並回傳之後需要等待的時間
+func (t *LoginThrottle) Failure(key string) time.Duration {
+	if t == nil || !t.policy.Enabled() {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.purgeLocked(now)
+
+	entry, ok := t.entries[key]
+	if !ok || t.expired(entry, now) {
+		entry = &throttleEntry{}
+		t.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+
+	wait := t.policy.LockDuration(entry.failures)
+	entry.blockedUntil = now.Add(wait)
+	return wait
+}
+
+// Reset 清除 key 的失敗紀錄（例如登入成功後）
+func (t *LoginThrottle) Reset(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, key)
+}
+
+// expired 判斷紀錄是否已超過保留時間
+func (t *LoginThrottle) expired(entry *throttleEntry, now time.Time) bool {
+	retention := t.policy.MaxDuration
+	if retention < t.policy.Duration {
+		retention = t.policy.Duration
+	}
+	return now.After(entry.blockedUntil) && now.Sub(entry.lastFailure) > retention
+}
+
+// purgeLocked 定期清除過期的紀錄，避免記憶體無限成長；呼叫端需持有鎖
+func (t *LoginThrottle) purgeLocked(now time.Time) {
+	if now.Sub(t.lastPurge) < time.Minute {
+		return
+	}
+	t.lastPurge = now
+	for key, entry := range t.entries {
+		if t.expired(entry, now) {
+			delete(t.entries, key)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicyLockDuration(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 3, Duration: time.Minute, MaxDuration: 5 * time.Minute}
+
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		failures int
+		expected time.Duration
+	}{
+		{name: "未達門檻", policy: policy, failures: 2, expected: 0},
+		{name: "剛達門檻", policy: policy, failures: 3, expected: time.Minute},
+		{name: "每多失敗一次加倍", policy: policy, failures: 4, expected: 2 * time.Minute},
+		{name: "再加倍", policy: policy, failures: 5, expected: 4 * time.Minute},
+		{name: "不超過上限", policy: policy, failures: 6, expected: 5 * time.Minute},
+		{name: "大量失敗仍為上限", policy: policy, failures: 1000, expected: 5 * time.Minute},
+		{name: "未設定上限", policy: LockoutPolicy{MaxAttempts: 1, Duration: time.Second}, failures: 3, expected: 4 * time.Second},
+		{name: "停用", policy: LockoutPolicy{}, failures: 100, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.LockDuration(tt.failures))
+		})
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	throttle := NewLoginThrottle(LockoutPolicy{MaxAttempts: 2, Duration: time.Second, MaxDuration: time.Minute})
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	const ip = "203.0.113.7"
+
+	// 門檻內的失敗不需等待
+	assert.Zero(t, throttle.Failure(ip))
+	assert.Zero(t, throttle.RetryAfter(ip))
+
+	// 達到門檻後逐步延遲
+	assert.Equal(t, time.Second, throttle.Failure(ip))
+	assert.Equal(t, time.Second, throttle.RetryAfter(ip))
+	assert.Zero(t, throttle.RetryAfter("198.51.100.1"), "其他來源不受影響")
+
+	now = now.Add(time.Second)
+	assert.Zero(t, throttle.RetryAfter(ip))
+	assert.Equal(t, 2*time.Second, throttle.Failure(ip))
+
+	// 成功登入後重置
+	throttle.Reset(ip)
+	assert.Zero(t, throttle.RetryAfter(ip))
+	assert.Zero(t, throttle.Failure(ip))
+
+	// 超過保留時間沒有失敗即重新計算
+	assert.Equal(t, time.Second, throttle.Failure(ip))
+	now = now.Add(2 * time.Minute)
+	assert.Zero(t, throttle.Failure(ip))
+}
+
+func TestLoginThrottlePurge(t *testing.T) {
+	throttle := NewLoginThrottle(LockoutPolicy{MaxAttempts: 1, Duration: time.Second, MaxDuration: time.Minute})
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		throttle.Failure(fmt.Sprintf("10.0.0.%d", i))
+	}
+	assert.Len(t, throttle.entries, 10)
+
+	now = now.Add(2 * time.Minute)
+	throttle.Failure("10.0.1.1")
+	assert.Len(t, throttle.entries, 1, "過期的紀錄應被清除")
+}
+
+func TestLoginThrottleDisabled(t *testing.T) {
+	var nilThrottle *LoginThrottle
+	assert.Zero(t, nilThrottle.Failure("x"))
+	assert.Zero(t, nilThrottle.RetryAfter("x"))
+	nilThrottle.Reset("x")
+
+	throttle := NewLoginThrottle(LockoutPolicy{})
+	for i := 0; i < 100; i++ {
+		assert.Zero(t, throttle.Failure("x"))
+	}
+	assert.Zero(t, throttle.RetryAfter("x"))
+}
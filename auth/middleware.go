@@ -1,43 +1,105 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT 認證中間件
+// AuthMiddleware JWT 認證中間件，也接受 "ApiKey {key}" 形式的 API key；
+// 以 API key 認證時，請求方法必須在 key 的 scope 內
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
+		identity, err := Authenticate(c.Request.Context(), c.GetHeader("Authorization"), c.ClientIP(), c.Request.UserAgent())
+		if err != nil {
+			abortAuthError(c, err)
+			return
+		}
 
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少 Authorization header"})
+		if !identity.HasScope(methodScope(c.Request.Method)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key 權限不足"})
 			c.Abort()
 			return
 		}
 
-		// 檢查 Bearer 前綴
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header 格式錯誤，應為 'Bearer {token}'"})
-			c.Abort()
+		setIdentity(c, identity)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware 有 Authorization header 時驗證身分，沒有時以匿名身分繼續；
+// 憑證無效仍回傳 401。不檢查 API key 的方法 scope，由後續處理（例如 GraphQL directive）判斷
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
 			return
 		}
 
-		tokenString := parts[1]
-		claims, err := ValidateToken(tokenString)
+		identity, err := Authenticate(c.Request.Context(), c.GetHeader("Authorization"), c.ClientIP(), c.Request.UserAgent())
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "無效的 token"})
-			c.Abort()
+			abortAuthError(c, err)
 			return
 		}
 
-		// 將用戶信息存儲到 context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
+		setIdentity(c, identity)
+		c.Next()
+	}
+}
+
+// setIdentity 將用戶信息存儲到 gin context，並以 WithIdentity 存入 request context
+func setIdentity(c *gin.Context, identity *Identity) {
+	claims := identity.Claims
+	c.Set("token_claims", claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_roles", claims.Roles)
+	if identity.IsAPIKey() {
+		c.Set("api_key_id", identity.APIKeyID)
+		c.Set("api_key_scopes", identity.Scopes)
+	}
+	c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), identity))
+}
 
+// abortAuthError 依 Authenticate 的錯誤回應 401，儲存層錯誤回應 500
+func abortAuthError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errRevocationCheck):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errRevocationCheck.Error()})
+	case errors.Is(err, errAPIKeyCheck):
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errAPIKeyCheck.Error()})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	}
+	c.Abort()
+}
+
+// RequireVerifiedEmail 要求 access token 標記 email 已驗證，須在 AuthMiddleware 之後使用。
+// 完成驗證後需以 refresh token 換發新的 access token 才會生效。
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("token_claims")
+		claims, ok := value.(*Claims)
+		if !ok || !claims.EmailVerified {
+			c.JSON(http.StatusForbidden, gin.H{"error": "請先完成 email 驗證"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireMFA 要求 access token 是通過兩步驟驗證後簽發的，須在 AuthMiddleware 之後使用
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("token_claims")
+		claims, ok := value.(*Claims)
+		if !ok || !claims.MFA {
+			c.JSON(http.StatusForbidden, gin.H{"error": "請先啟用兩步驟驗證"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
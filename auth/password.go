@@ -1,15 +1,194 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher 密碼雜湊演算法。雜湊字串自帶演算法與參數（PHC 格式或 bcrypt 的 $2a$ 格式），
+// 因此可以同時驗證不同版本產生的雜湊，並判斷是否需要以目前的設定重新雜湊
+type PasswordHasher interface {
+	// Hash 以目前的參數雜湊密碼
+	Hash(password string) (string, error)
+	// Recognizes 判斷雜湊字串是否由此演算法產生
+	Recognizes(hash string) bool
+	// Verify 驗證密碼是否符合雜湊
+	Verify(password, hash string) bool
+	// NeedsRehash 判斷同演算法的雜湊是否使用了與目前不同的參數
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher 使用 argon2id（RFC 9106），雜湊格式為
+// $argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idHasher 回傳預設參數（64 MiB、3 次迭代、2 個執行緒）的 argon2id
+func DefaultArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+}
+
+var errInvalidArgon2Hash = errors.New("invalid argon2id hash")
+
+// argon2Params 從雜湊字串解析出的參數
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// Hash 以 argon2id 雜湊密碼
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Recognizes 判斷是否為 argon2id 雜湊
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// Verify 以雜湊中記錄的參數重新計算並比對
+func (h *Argon2idHasher) Verify(password, hash string) bool {
+	params, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1
+}
+
+// NeedsRehash 參數與目前設定不同時需要重新雜湊
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := parseArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params.memory != h.Memory ||
+		params.iterations != h.Iterations ||
+		params.parallelism != h.Parallelism ||
+		uint32(len(params.salt)) != h.SaltLength ||
+		uint32(len(params.key)) != h.KeyLength
+}
+
+// parseArgon2Hash 解析 PHC 格式的 argon2id 雜湊
+func parseArgon2Hash(hash string) (*argon2Params, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errInvalidArgon2Hash
+	}
+
+	params := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, errInvalidArgon2Hash
+	}
+	if params.iterations == 0 || params.parallelism == 0 {
+		return nil, errInvalidArgon2Hash
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errInvalidArgon2Hash
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return nil, errInvalidArgon2Hash
+	}
+	return params, nil
+}
+
+// BcryptHasher 使用 bcrypt，為既有會員密碼的格式
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash 以 bcrypt 雜湊密碼
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(bytes), err
+}
+
+// Recognizes 判斷是否為 bcrypt 雜湊
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Verify 驗證 bcrypt 雜湊
+func (h *BcryptHasher) Verify(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash cost 與目前設定不同時需要重新雜湊
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+var (
+	passwordHasherMu sync.RWMutex
+	// passwordHasher 用於產生新雜湊的演算法
+	passwordHasher PasswordHasher = DefaultArgon2idHasher()
+	// knownHashers 可驗證的所有演算法（使用預設參數，驗證時以雜湊中記錄的參數為準）
+	knownHashers = []PasswordHasher{DefaultArgon2idHasher(), &BcryptHasher{Cost: bcrypt.DefaultCost}}
+)
+
+// SetPasswordHasher 設定產生新密碼雜湊使用的演算法與參數
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasherMu.Lock()
+	defer passwordHasherMu.Unlock()
+	passwordHasher = h
+}
+
+// currentPasswordHasher 回傳目前的密碼雜湊演算法
+func currentPasswordHasher() PasswordHasher {
+	passwordHasherMu.RLock()
+	defer passwordHasherMu.RUnlock()
+	return passwordHasher
+}
 
 // HashPassword 加密密碼
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return currentPasswordHasher().Hash(password)
 }
 
-// CheckPassword 驗證密碼
+// CheckPassword 驗證密碼，依雜湊格式自動選擇演算法
 func CheckPassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	for _, h := range knownHashers {
+		if h.Recognizes(hash) {
+			return h.Verify(password, hash)
+		}
+	}
+	return false
+}
+
+// NeedsRehash 判斷雜湊是否使用過時的演算法或參數，應在密碼驗證成功後以 HashPassword 重新雜湊
+func NeedsRehash(hash string) bool {
+	current := currentPasswordHasher()
+	return !current.Recognizes(hash) || current.NeedsRehash(hash)
 }
@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashPassword(t *testing.T) {
@@ -119,3 +122,134 @@ func TestHashPasswordConsistency(t *testing.T) {
 		t.Error("CheckPassword() failed to verify second hash")
 	}
 }
+
+// usePasswordHasher 設定測試用的密碼雜湊演算法，測試結束後恢復
+func usePasswordHasher(t *testing.T, h PasswordHasher) {
+	t.Helper()
+	previous := currentPasswordHasher()
+	SetPasswordHasher(h)
+	t.Cleanup(func() { SetPasswordHasher(previous) })
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want PHC argon2id format", hash)
+	}
+	if !hasher.Recognizes(hash) {
+		t.Error("Recognizes() should accept its own hash")
+	}
+	if !hasher.Verify("correct horse", hash) {
+		t.Error("Verify() failed for correct password")
+	}
+	if hasher.Verify("wrong horse", hash) {
+		t.Error("Verify() succeeded for wrong password")
+	}
+
+	// 以不同參數產生的雜湊仍可驗證，但需要重新雜湊
+	stronger := &Argon2idHasher{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	if !stronger.Verify("correct horse", hash) {
+		t.Error("Verify() should use the parameters stored in the hash")
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Error("NeedsRehash() should be false for matching parameters")
+	}
+	if !stronger.NeedsRehash(hash) {
+		t.Error("NeedsRehash() should be true for outdated parameters")
+	}
+
+	malformed := []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$aGFzaA",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$aGFzaA",
+	}
+	for _, h := range malformed {
+		if hasher.Verify("correct horse", h) {
+			t.Errorf("Verify() accepted malformed hash %q", h)
+		}
+	}
+}
+
+func TestCheckPasswordRecognisesFormats(t *testing.T) {
+	bcryptHash, err := (&BcryptHasher{Cost: bcrypt.MinCost}).Hash("legacy-password")
+	if err != nil {
+		t.Fatalf("bcrypt Hash() error = %v", err)
+	}
+	argonHash, err := (&Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("modern-password")
+	if err != nil {
+		t.Fatalf("argon2id Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{name: "bcrypt 正確密碼", password: "legacy-password", hash: bcryptHash, want: true},
+		{name: "bcrypt 錯誤密碼", password: "modern-password", hash: bcryptHash, want: false},
+		{name: "argon2id 正確密碼", password: "modern-password", hash: argonHash, want: true},
+		{name: "argon2id 錯誤密碼", password: "legacy-password", hash: argonHash, want: false},
+		{name: "未知格式", password: "legacy-password", hash: "$md5$abc", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPassword(tt.password, tt.hash); got != tt.want {
+				t.Errorf("CheckPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current := &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	usePasswordHasher(t, current)
+
+	upToDate, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	outdatedArgon, err := (&Argon2idHasher{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	bcryptHash, err := (&BcryptHasher{Cost: bcrypt.MinCost}).Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{name: "目前參數", hash: upToDate, want: false},
+		{name: "argon2id 參數過時", hash: outdatedArgon, want: true},
+		{name: "bcrypt 需升級為 argon2id", hash: bcryptHash, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 使用 bcrypt 作為目前演算法時，只有 cost 不同才需要重新雜湊
+	usePasswordHasher(t, &BcryptHasher{Cost: bcrypt.MinCost})
+	if NeedsRehash(bcryptHash) {
+		t.Error("NeedsRehash() should be false for bcrypt hash with current cost")
+	}
+	if !NeedsRehash(upToDate) {
+		t.Error("NeedsRehash() should be true for argon2id hash when bcrypt is configured")
+	}
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密碼規則的違規代碼
+const (
+	ViolationTooShort      = "too_short"
+	ViolationTooLong       = "too_long"
+	ViolationMissingUpper  = "missing_uppercase"
+	ViolationMissingLower  = "missing_lowercase"
+	ViolationMissingDigit  = "missing_digit"
+	ViolationMissingSymbol = "missing_symbol"
+	ViolationPersonalInfo  = "contains_personal_info"
+	ViolationBreached      = "breached"
+)
+
+// minPersonalInfoFragment 個人資訊片段至少幾個字元才檢查，避免過短的片段誤判
+const minPersonalInfoFragment = 3
+
+// PolicyViolation 違反的單一密碼規則
+type PolicyViolation struct {
+	Code    string `json:"code" example:"too_short"`
+	Message string `json:"message" example:"密碼長度至少需要 8 個字元"`
+}
+
+// PasswordPolicyError 密碼不符合規則時回傳，包含所有違反的規則
+type PasswordPolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return strings.Join(messages, "；")
+}
+
+// Has 判斷是否違反指定規則
+func (e *PasswordPolicyError) Has(code string) bool {
+	for _, v := range e.Violations {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// BreachChecker 檢查密碼是否出現在外洩密碼清單中
+type BreachChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+// PasswordPolicy 密碼規則。REST、GraphQL 與所有變更密碼的流程都透過 ValidatePassword 使用同一份規則
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// DisallowPersonalInfo 禁止密碼包含會員名稱或 email 的片段
+	DisallowPersonalInfo bool
+	// Breached 為 nil 時不檢查外洩密碼
+	Breached BreachChecker
+}
+
+// DefaultPasswordPolicy 預設規則：8 到 128 個字元且不得包含個人資訊
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{MinLength: 8, MaxLength: 128, DisallowPersonalInfo: true}
+}
+
+// Validate 檢查密碼是否符合規則，personal 為會員的名稱、email 等不應出現在密碼中的資訊。
+// 不符合時回傳 *PasswordPolicyError；外洩清單讀取失敗時回傳一般錯誤
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	var violations []PolicyViolation
+	add := func(code, message string) {
+		violations = append(violations, PolicyViolation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		add(ViolationTooShort, fmt.Sprintf("密碼長度至少需要 %d 個字元", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		add(ViolationTooLong, fmt.Sprintf("密碼長度不可超過 %d 個字元", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add(ViolationMissingUpper, "密碼需包含大寫英文字母")
+	}
+	if p.RequireLower && !hasLower {
+		add(ViolationMissingLower, "密碼需包含小寫英文字母")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "密碼需包含數字")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "密碼需包含符號")
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personal) {
+		add(ViolationPersonalInfo, "密碼不可包含您的名稱或電子郵件")
+	}
+
+	if p.Breached != nil && password != "" {
+		breached, err := p.Breached.IsBreached(password)
+		if err != nil {
+			return fmt.Errorf("check breached passwords: %w", err)
+		}
+		if breached {
+			add(ViolationBreached, "此密碼曾出現在外洩資料中，請改用其他密碼")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo 判斷密碼是否包含個人資訊的片段（不分大小寫）。
+// email 會拆成帳號與其中以 . _ - + 分隔的片段，名稱以空白分隔；少於 3 個字元的片段會被忽略
+func containsPersonalInfo(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+	for _, info := range personal {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if info == "" {
+			continue
+		}
+
+		fragments := []string{info}
+		if local, _, found := strings.Cut(info, "@"); found {
+			fragments = append(fragments, local)
+			info = local
+		}
+		fragments = append(fragments, strings.FieldsFunc(info, func(r rune) bool {
+			return unicode.IsSpace(r) || strings.ContainsRune("._-+", r)
+		})...)
+
+		for _, fragment := range fragments {
+			if utf8.RuneCountInString(fragment) >= minPersonalInfoFragment && strings.Contains(lower, fragment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// passwordSHA1 回傳密碼 SHA-1 雜湊的大寫十六進位字串（外洩密碼清單使用的格式）
+func passwordSHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachedHashSet 整份載入記憶體的外洩密碼清單
+type breachedHashSet map[string]struct{}
+
+// IsBreached 判斷密碼雜湊是否在清單中
+func (s breachedHashSet) IsBreached(password string) (bool, error) {
+	_, ok := s[passwordSHA1(password)]
+	return ok, nil
+}
+
+// breachedRangeDir 依雜湊前 5 碼分檔的外洩密碼清單（與 k-anonymity range API 相同格式），
+// 每次查詢只讀取對應前綴的檔案
+type breachedRangeDir string
+
+// IsBreached 讀取前綴檔案並比對後 35 碼
+func (d breachedRangeDir) IsBreached(password string) (bool, error) {
+	hash := passwordSHA1(password)
+	prefix, suffix := hash[:5], hash[5:]
+
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		file, err := os.Open(filepath.Join(string(d), name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		defer file.Close()
+		return scanBreachedHashes(file, func(line string) bool { return line == suffix })
+	}
+	return false, nil
+}
+
+// scanBreachedHashes 逐行讀取 "HASH" 或 "HASH:count" 格式的清單，match 回傳 true 時停止
+func scanBreachedHashes(file *os.File, match func(hash string) bool) (bool, error) {
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		if match(strings.ToUpper(hash)) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// LoadBreachedPasswords 載入外洩密碼清單。path 為目錄時，目錄中每個檔案以 SHA-1 前 5 碼命名
+// （可加 .txt 副檔名），內容為後 35 碼；path 為檔案時，內容為完整 SHA-1 雜湊並整份載入記憶體。
+// 兩種格式每行皆為 "HASH" 或 "HASH:count"
+func LoadBreachedPasswords(path string) (BreachChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return breachedRangeDir(path), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	set := make(breachedHashSet)
+	var invalid error
+	if _, err := scanBreachedHashes(file, func(hash string) bool {
+		if len(hash) != sha1.Size*2 {
+			invalid = fmt.Errorf("%s: invalid SHA-1 hash %q", path, hash)
+			return true
+		}
+		set[hash] = struct{}{}
+		return false
+	}); err != nil {
+		return nil, err
+	}
+	if invalid != nil {
+		return nil, invalid
+	}
+	return set, nil
+}
+
+var (
+	passwordPolicyMu sync.RWMutex
+	passwordPolicy   = DefaultPasswordPolicy()
+)
+
+// SetPasswordPolicy 設定全域密碼規則
+func SetPasswordPolicy(p *PasswordPolicy) {
+	passwordPolicyMu.Lock()
+	defer passwordPolicyMu.Unlock()
+	passwordPolicy = p
+}
+
+// ValidatePassword 以全域密碼規則檢查密碼
+func ValidatePassword(password string, personal ...string) error {
+	passwordPolicyMu.RLock()
+	policy := passwordPolicy
+	passwordPolicyMu.RUnlock()
+	return policy.Validate(password, personal...)
+}
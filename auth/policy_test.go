@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	strict := &PasswordPolicy{
+		MinLength:            10,
+		MaxLength:            20,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		personal []string
+		want     []string
+	}{
+		{name: "符合所有規則", policy: strict, password: "Tr0ub4dor&3x", want: nil},
+		{name: "太短", policy: strict, password: "Ab1!", want: []string{ViolationTooShort}},
+		{name: "太長", policy: strict, password: "Aa1!aaaaaaaaaaaaaaaaaaaaa", want: []string{ViolationTooLong}},
+		{name: "缺少多種字元類型", policy: strict, password: "lowercaseonly", want: []string{ViolationMissingUpper, ViolationMissingDigit, ViolationMissingSymbol}},
+		{name: "中文字元計算長度", policy: &PasswordPolicy{MinLength: 4}, password: "正確馬匹", want: nil},
+		{name: "包含名稱", policy: strict, password: "Jeby-Secret-9", personal: []string{"Jeby Chen"}, want: []string{ViolationPersonalInfo}},
+		{name: "包含 email 帳號", policy: strict, password: "X!9john.doeX", personal: []string{"john.doe@example.com"}, want: []string{ViolationPersonalInfo}},
+		{name: "包含 email 片段且不分大小寫", policy: strict, password: "Xx!9DOE2024x", personal: []string{"john.doe@example.com"}, want: []string{ViolationPersonalInfo}},
+		{name: "過短的片段不檢查", policy: strict, password: "Xx!9AlBoxxxx", personal: []string{"Al Bo"}, want: nil},
+		{name: "未啟用個人資訊檢查", policy: &PasswordPolicy{MinLength: 8}, password: "john.doe123", personal: []string{"john.doe@example.com"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.personal...)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			codes := make([]string, 0, len(policyErr.Violations))
+			for _, v := range policyErr.Violations {
+				codes = append(codes, v.Code)
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tt.want, codes)
+		})
+	}
+}
+
+func TestPasswordPolicyErrorMessage(t *testing.T) {
+	err := (&PasswordPolicy{MinLength: 8, RequireDigit: true}).Validate("short")
+
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, "密碼長度至少需要 8 個字元；密碼需包含數字", err.Error())
+	assert.True(t, policyErr.Has(ViolationMissingDigit))
+	assert.False(t, policyErr.Has(ViolationBreached))
+}
+
+func TestLoadBreachedPasswordsFile(t *testing.T) {
+	// "password" 與 "123456" 的 SHA-1
+	content := "# 測試清單\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n7c4a8d09ca3762af61e59520943dc26494f8941b\n"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	checker, err := LoadBreachedPasswords(path)
+	require.NoError(t, err)
+
+	for password, want := range map[string]bool{"password": true, "123456": true, "not-in-the-list": false} {
+		breached, err := checker.IsBreached(password)
+		require.NoError(t, err)
+		assert.Equal(t, want, breached, password)
+	}
+
+	invalidPath := filepath.Join(t.TempDir(), "invalid.txt")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not-a-hash\n"), 0o600))
+	_, err = LoadBreachedPasswords(invalidPath)
+	assert.Error(t, err)
+
+	_, err = LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestLoadBreachedPasswordsRangeDir(t *testing.T) {
+	dir := t.TempDir()
+	// "password" 的 SHA-1 為 5BAA6 1E4C9B93F3F0682250B6CF8331B7EE68FD8
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6"),
+		[]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n"), 0o600))
+	// "123456" 的 SHA-1 為 7C4A8 D09CA3762AF61E59520943DC26494F8941B，使用 .txt 副檔名
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "7C4A8.txt"),
+		[]byte("D09CA3762AF61E59520943DC26494F8941B:37359195\n"), 0o600))
+
+	checker, err := LoadBreachedPasswords(dir)
+	require.NoError(t, err)
+
+	for password, want := range map[string]bool{"password": true, "123456": true, "no-range-file": false} {
+		breached, err := checker.IsBreached(password)
+		require.NoError(t, err)
+		assert.Equal(t, want, breached, password)
+	}
+
+	policy := &PasswordPolicy{MinLength: 6, Breached: checker}
+	var policyErr *PasswordPolicyError
+	require.ErrorAs(t, policy.Validate("password"), &policyErr)
+	assert.True(t, policyErr.Has(ViolationBreached))
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurposeToken(t *testing.T) {
+	setupTest(t)
+
+	token, err := GeneratePurposeToken("email_verification", 5, "verify@example.com", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := ValidatePurposeToken(token, "email_verification")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), claims.UserID)
+	assert.Equal(t, "verify@example.com", claims.Email)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 5*time.Second)
+
+	accessToken, err := GenerateToken(5, "verify@example.com")
+	require.NoError(t, err)
+
+	expired, err := GeneratePurposeToken("email_verification", 5, "verify@example.com", -time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		validate func() error
+		wantErr  error
+	}{
+		{
+			name:     "用途不符",
+			validate: func() error { _, err := ValidatePurposeToken(token, "password_reset"); return err },
+			wantErr:  ErrTokenPurpose,
+		},
+		{
+			name:     "access token 不能作為用途 token",
+			validate: func() error { _, err := ValidatePurposeToken(accessToken, "email_verification"); return err },
+			wantErr:  ErrTokenPurpose,
+		},
+		{
+			name:     "用途 token 不能作為 access token",
+			validate: func() error { _, err := ValidateToken(token); return err },
+			wantErr:  ErrTokenPurpose,
+		},
+		{
+			name:     "已過期",
+			validate: func() error { _, err := ValidatePurposeToken(expired, "email_verification"); return err },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate()
+			assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaimRequirementMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		middleware gin.HandlerFunc
+		claims     *Claims
+		wantCode   int
+	}{
+		{name: "email 已驗證", middleware: RequireVerifiedEmail(), claims: &Claims{UserID: 1, EmailVerified: true}, wantCode: http.StatusOK},
+		{name: "email 未驗證", middleware: RequireVerifiedEmail(), claims: &Claims{UserID: 1}, wantCode: http.StatusForbidden},
+		{name: "驗證 email 時 context 沒有 claims", middleware: RequireVerifiedEmail(), wantCode: http.StatusForbidden},
+		{name: "已通過兩步驟驗證", middleware: RequireMFA(), claims: &Claims{UserID: 1, MFA: true}, wantCode: http.StatusOK},
+		{name: "未通過兩步驟驗證", middleware: RequireMFA(), claims: &Claims{UserID: 1, EmailVerified: true}, wantCode: http.StatusForbidden},
+		{name: "要求兩步驟驗證時 context 沒有 claims", middleware: RequireMFA(), wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set("token_claims", tt.claims)
+				}
+				c.Next()
+			})
+			router.GET("/", tt.middleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
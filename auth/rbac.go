@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 角色定義；所有已登入會員皆隱含 RoleMember
+const (
+	RoleAdmin  = "admin"
+	RoleStaff  = "staff"
+	RoleMember = "member"
+)
+
+// Permission 表示一項可被授權的操作
+type Permission string
+
+// 權限定義
+const (
+	PermMemberRead    Permission = "member:read"
+	PermMemberUpdate  Permission = "member:update"
+	PermMemberDelete  Permission = "member:delete"
+	PermRoleManage    Permission = "role:manage"
+	PermProductUpdate Permission = "product:update"
+	PermProductDelete Permission = "product:delete"
+)
+
+// rolePermissions 角色與權限的對應表
+var rolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermMemberRead,
+		PermMemberUpdate,
+		PermMemberDelete,
+		PermRoleManage,
+		PermProductUpdate,
+		PermProductDelete,
+	},
+	RoleStaff: {
+		PermMemberRead,
+		PermProductUpdate,
+		PermProductDelete,
+	},
+	RoleMember: {},
+}
+
+// IsValidRole 檢查角色名稱是否有效
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsAssignableRole 檢查角色是否可由管理員授予或撤銷（member 為隱含角色，不需授予）
+func IsAssignableRole(role string) bool {
+	return IsValidRole(role) && role != RoleMember
+}
+
+// HasRole 檢查角色列表中是否包含任一指定角色
+func HasRole(roles []string, wanted ...string) bool {
+	for _, role := range wanted {
+		if role == RoleMember || slices.Contains(roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 檢查角色列表是否具備指定權限
+func HasPermission(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolesFromContext 取得 AuthMiddleware 存入 context 的角色
+func rolesFromContext(c *gin.Context) []string {
+	value, exists := c.Get("user_roles")
+	if !exists {
+		return nil
+	}
+	roles, _ := value.([]string)
+	return roles
+}
+
+// RequireRole 要求呼叫者具備任一指定角色，須放在 AuthMiddleware 之後。
+// 以 API key 認證時，key 還必須具備 admin scope
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasRole(rolesFromContext(c), roles...) || !apiKeyAllows(c, ScopeAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "權限不足"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyAllows 以 API key 認證時檢查 key 是否具備 scope；以 JWT 認證時一律允許
+func apiKeyAllows(c *gin.Context, scope string) bool {
+	scopes, ok := apiKeyScopes(c)
+	return !ok || slices.Contains(scopes, scope)
+}
+
+// RequirePermission 要求呼叫者具備所有指定權限，須放在 AuthMiddleware 之後。
+// 以 API key 認證時，權限為會員角色權限與 key 的 scope 之交集
+func RequirePermission(perms ...Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles := rolesFromContext(c)
+		for _, perm := range perms {
+			if !HasPermission(roles, perm) || !apiKeyAllows(c, string(perm)) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "權限不足"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
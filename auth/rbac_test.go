@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		perm  Permission
+		want  bool
+	}{
+		{name: "admin 可刪除會員", roles: []string{RoleAdmin}, perm: PermMemberDelete, want: true},
+		{name: "admin 可管理角色", roles: []string{RoleAdmin}, perm: PermRoleManage, want: true},
+		{name: "staff 可刪除產品", roles: []string{RoleStaff}, perm: PermProductDelete, want: true},
+		{name: "staff 不可刪除會員", roles: []string{RoleStaff}, perm: PermMemberDelete, want: false},
+		{name: "一般會員不可刪除產品", roles: []string{RoleMember}, perm: PermProductDelete, want: false},
+		{name: "沒有角色", roles: nil, perm: PermMemberRead, want: false},
+		{name: "未知角色", roles: []string{"root"}, perm: PermMemberDelete, want: false},
+		{name: "多個角色取聯集", roles: []string{RoleMember, RoleStaff}, perm: PermMemberRead, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasPermission(tt.roles, tt.perm))
+		})
+	}
+}
+
+func TestRoleValidation(t *testing.T) {
+	assert.True(t, IsValidRole(RoleAdmin))
+	assert.True(t, IsValidRole(RoleMember))
+	assert.False(t, IsValidRole("superuser"))
+
+	assert.True(t, IsAssignableRole(RoleStaff))
+	assert.False(t, IsAssignableRole(RoleMember), "member 為隱含角色，不可授予")
+	assert.False(t, IsAssignableRole(""))
+
+	assert.True(t, HasRole(nil, RoleMember), "所有會員皆隱含 member 角色")
+	assert.True(t, HasRole([]string{RoleStaff}, RoleAdmin, RoleStaff))
+	assert.False(t, HasRole([]string{RoleStaff}, RoleAdmin))
+}
+
+// performWithRoles 以指定角色執行中間件並回傳結果
+func performWithRoles(roles []string, setRoles bool, middleware gin.HandlerFunc) (*httptest.ResponseRecorder, bool) {
+	router := gin.New()
+	nextCalled := false
+	router.Use(func(c *gin.Context) {
+		if setRoles {
+			c.Set("user_roles", roles)
+		}
+		c.Next()
+	})
+	router.Use(middleware)
+	router.GET("/", func(c *gin.Context) {
+		nextCalled = true
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	return w, nextCalled
+}
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		setRoles bool
+		required []string
+		wantCode int
+	}{
+		{name: "admin 通過", roles: []string{RoleAdmin}, setRoles: true, required: []string{RoleAdmin}, wantCode: http.StatusOK},
+		{name: "staff 符合任一角色", roles: []string{RoleStaff}, setRoles: true, required: []string{RoleAdmin, RoleStaff}, wantCode: http.StatusOK},
+		{name: "一般會員被拒絕", roles: nil, setRoles: true, required: []string{RoleAdmin}, wantCode: http.StatusForbidden},
+		{name: "context 沒有角色", setRoles: false, required: []string{RoleAdmin}, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, nextCalled := performWithRoles(tt.roles, tt.setRoles, RequireRole(tt.required...))
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantCode == http.StatusOK, nextCalled)
+			if tt.wantCode == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "權限不足")
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		perms    []Permission
+		wantCode int
+	}{
+		{name: "admin 可刪除會員", roles: []string{RoleAdmin}, perms: []Permission{PermMemberDelete}, wantCode: http.StatusOK},
+		{name: "staff 無法刪除會員", roles: []string{RoleStaff}, perms: []Permission{PermMemberDelete}, wantCode: http.StatusForbidden},
+		{name: "需同時具備所有權限", roles: []string{RoleStaff}, perms: []Permission{PermProductDelete, PermRoleManage}, wantCode: http.StatusForbidden},
+		{name: "一般會員無法刪除產品", roles: nil, perms: []Permission{PermProductDelete}, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, nextCalled := performWithRoles(tt.roles, true, RequirePermission(tt.perms...))
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantCode == http.StatusOK, nextCalled)
+		})
+	}
+}
+
+func TestAuthMiddlewareSetsRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTest(t)
+
+	token, err := GenerateToken(7, "admin@example.com", WithRoles(RoleAdmin, RoleStaff))
+	assert.NoError(t, err)
+
+	claims, err := ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin, RoleStaff}, claims.Roles)
+
+	router := gin.New()
+	router.Use(AuthMiddleware(), RequirePermission(PermRoleManage))
+	router.GET("/", func(c *gin.Context) {
+		roles, exists := c.Get("user_roles")
+		assert.True(t, exists)
+		assert.Equal(t, []string{RoleAdmin, RoleStaff}, roles)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
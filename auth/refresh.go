@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL refresh token 的有效期限（30 天）
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// GenerateRandomToken 產生指定位元組長度的隨機字串（base64url 編碼，無填充）
+func GenerateRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateRefreshToken 產生新的 refresh token 明文
+func GenerateRefreshToken() (string, error) {
+	return GenerateRandomToken(32)
+}
+
+// HashToken 以 SHA-256 計算 token 的雜湊值，資料庫只保存雜湊而不保存明文
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRefreshToken(t *testing.T) {
+	token1, err := GenerateRefreshToken()
+	assert.NoError(t, err)
+	token2, err := GenerateRefreshToken()
+	assert.NoError(t, err)
+
+	// 32 bytes 經 base64url（無填充）編碼後長度為 43
+	assert.Len(t, token1, 43)
+	assert.NotEqual(t, token1, token2, "每次產生的 refresh token 應不同")
+}
+
+func TestGenerateRandomToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		wantLen int
+	}{
+		{name: "16 bytes", size: 16, wantLen: 22},
+		{name: "32 bytes", size: 32, wantLen: 43},
+		{name: "0 bytes", size: 0, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := GenerateRandomToken(tt.size)
+			assert.NoError(t, err)
+			assert.Len(t, token, tt.wantLen)
+		})
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{
+			name:  "空字串",
+			token: "",
+			want:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:  "一般字串",
+			token: "abc",
+			want:  "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HashToken(tt.token))
+		})
+	}
+
+	// 相同輸入應得到相同雜湊，不同輸入應不同
+	assert.Equal(t, HashToken("token"), HashToken("token"))
+	assert.NotEqual(t, HashToken("token-a"), HashToken("token-b"))
+}
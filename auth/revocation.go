@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationStore 保存已撤銷的 token，供 AuthMiddleware 在 token 到期前拒絕它們
+type RevocationStore interface {
+	// RevokeToken 撤銷單一 token（以 jti 識別），expiresAt 之後紀錄即可清除
+	RevokeToken(ctx context.Context, tokenID string, memberID int64, expiresAt time.Time) error
+	// RevokeAllForMember 撤銷會員在 before 之前（含同一秒）簽發的所有 token
+	RevokeAllForMember(ctx context.Context, memberID int64, before time.Time) error
+	// RevokeSession 撤銷綁定到登入工作階段的所有 token，expiresAt 之後紀錄即可清除
+	RevokeSession(ctx context.Context, sessionID uint, memberID int64, expiresAt time.Time) error
+	// IsRevoked 判斷 token 是否已被撤銷
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+// MemoryRevocationStore 以記憶體保存撤銷紀錄，可單獨使用於測試，或作為資料庫實作的快取
+type MemoryRevocationStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	members  map[int64]time.Time
+	sessions map[uint]time.Time
+}
+
+// NewMemoryRevocationStore 建立空的記憶體撤銷紀錄
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens:   make(map[string]time.Time),
+		members:  make(map[int64]time.Time),
+		sessions: make(map[uint]time.Time),
+	}
+}
+
+// RevokeToken 撤銷單一 token
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, tokenID string, _ int64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeAllForMember 撤銷會員在 before 之前簽發的所有 token，保留較晚的撤銷時間
+func (s *MemoryRevocationStore) RevokeAllForMember(_ context.Context, memberID int64, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.members[memberID]; !ok || before.After(current) {
+		s.members[memberID] = before
+	}
+	return nil
+}
+
+// RevokeSession 撤銷登入工作階段的所有 token
+func (s *MemoryRevocationStore) RevokeSession(_ context.Context, sessionID uint, _ int64, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.sessions[sessionID]; !ok || expiresAt.After(current) {
+		s.sessions[sessionID] = expiresAt
+	}
+	return nil
+}
+
+// IsRevoked 判斷 token 是否已被撤銷
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, claims *Claims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if claims.ID != "" {
+		if _, ok := s.tokens[claims.ID]; ok {
+			return true, nil
+		}
+	}
+
+	if claims.SessionID != 0 {
+		if _, ok := s.sessions[claims.SessionID]; ok {
+			return true, nil
+		}
+	}
+
+	if before, ok := s.members[claims.UserID]; ok {
+		// iat 只精確到秒，與撤銷時間同一秒簽發的 token 也視為已撤銷
+		if claims.IssuedAt == nil || !claims.IssuedAt.Time.After(before.Truncate(time.Second)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Purge 清除已過期 token 的紀錄；會員層級的撤銷在最長 token 有效期過後即可清除
+func (s *MemoryRevocationStore) Purge(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, expiresAt := range s.tokens {
+		if now.After(expiresAt) {
+			delete(s.tokens, id)
+		}
+	}
+	for memberID, before := range s.members {
+		if now.After(before.Add(AccessTokenTTL)) {
+			delete(s.members, memberID)
+		}
+	}
+	for sessionID, expiresAt := range s.sessions {
+		if now.After(expiresAt) {
+			delete(s.sessions, sessionID)
+		}
+	}
+}
+
+var (
+	revocationMu    sync.RWMutex
+	revocationStore RevocationStore
+)
+
+// SetRevocationStore 設定 AuthMiddleware 使用的撤銷紀錄，設為 nil 時不檢查撤銷
+func SetRevocationStore(store RevocationStore) {
+	revocationMu.Lock()
+	defer revocationMu.Unlock()
+	revocationStore = store
+}
+
+// CurrentRevocationStore 回傳目前的撤銷紀錄，未設定時為 nil
+func CurrentRevocationStore() RevocationStore {
+	revocationMu.RLock()
+	defer revocationMu.RUnlock()
+	return revocationStore
+}
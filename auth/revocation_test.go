@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTokenSetsJTI(t *testing.T) {
+	setupTest(t)
+
+	token1, err := GenerateToken(1, "jti@example.com")
+	require.NoError(t, err)
+	token2, err := GenerateToken(1, "jti@example.com")
+	require.NoError(t, err)
+
+	claims1, err := ValidateToken(token1)
+	require.NoError(t, err)
+	claims2, err := ValidateToken(token2)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, claims1.ID)
+	assert.NotEqual(t, claims1.ID, claims2.ID, "每個 token 的 jti 應不同")
+}
+
+func TestMemoryRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	claimsAt := func(id string, userID int64, issuedAt time.Time) *Claims {
+		return &Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:       id,
+				IssuedAt: jwt.NewNumericDate(issuedAt),
+			},
+		}
+	}
+
+	store := NewMemoryRevocationStore()
+	require.NoError(t, store.RevokeToken(ctx, "revoked-jti", 1, now.Add(time.Hour)))
+	require.NoError(t, store.RevokeAllForMember(ctx, 2, now))
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		want   bool
+	}{
+		{name: "已撤銷的 jti", claims: claimsAt("revoked-jti", 1, now), want: true},
+		{name: "未撤銷的 jti", claims: claimsAt("other-jti", 1, now), want: false},
+		{name: "會員撤銷前簽發的 token", claims: claimsAt("a", 2, now.Add(-time.Minute)), want: true},
+		{name: "與撤銷同一秒簽發的 token", claims: claimsAt("b", 2, now), want: true},
+		{name: "會員撤銷後簽發的 token", claims: claimsAt("c", 2, now.Add(2*time.Second)), want: false},
+		{name: "其他會員不受影響", claims: claimsAt("d", 3, now.Add(-time.Minute)), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := store.IsRevoked(ctx, tt.claims)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, revoked)
+		})
+	}
+
+	t.Run("較早的撤銷時間不會覆蓋較晚的", func(t *testing.T) {
+		require.NoError(t, store.RevokeAllForMember(ctx, 2, now.Add(-time.Hour)))
+		revoked, err := store.IsRevoked(ctx, claimsAt("e", 2, now.Add(-time.Minute)))
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("Purge 清除過期紀錄", func(t *testing.T) {
+		store.Purge(now.Add(2 * time.Hour))
+		revoked, _ := store.IsRevoked(ctx, claimsAt("revoked-jti", 1, now))
+		assert.False(t, revoked, "token 過期後紀錄應被清除")
+		revoked, _ = store.IsRevoked(ctx, claimsAt("f", 2, now.Add(-time.Minute)))
+		assert.True(t, revoked, "會員層級撤銷在 access token 有效期內應保留")
+
+		store.Purge(now.Add(AccessTokenTTL + time.Minute))
+		revoked, _ = store.IsRevoked(ctx, claimsAt("f", 2, now.Add(-time.Minute)))
+		assert.False(t, revoked)
+	})
+}
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTest(t)
+
+	store := NewMemoryRevocationStore()
+	SetRevocationStore(store)
+	t.Cleanup(func() { SetRevocationStore(nil) })
+
+	token, err := GenerateToken(5, "logout@example.com")
+	require.NoError(t, err)
+
+	perform := func() *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(AuthMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			claims, exists := c.Get("token_claims")
+			assert.True(t, exists)
+			assert.Equal(t, int64(5), claims.(*Claims).UserID)
+			c.Status(http.StatusOK)
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, perform().Code)
+
+	claims, err := ValidateToken(token)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeToken(context.Background(), claims.ID, claims.UserID, claims.ExpiresAt.Time))
+
+	w := perform()
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token 已被撤銷")
+}
+
+func TestMemoryRevocationStoreSession(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	require.NoError(t, store.RevokeSession(ctx, 10, 1, now.Add(AccessTokenTTL)))
+
+	sessionClaims := func(sessionID uint) *Claims {
+		return &Claims{UserID: 1, SessionID: sessionID, RegisteredClaims: jwt.RegisteredClaims{ID: "x", IssuedAt: jwt.NewNumericDate(now)}}
+	}
+
+	revoked, err := store.IsRevoked(ctx, sessionClaims(10))
+	require.NoError(t, err)
+	assert.True(t, revoked, "已撤銷工作階段的 token")
+
+	revoked, _ = store.IsRevoked(ctx, sessionClaims(11))
+	assert.False(t, revoked, "其他工作階段不受影響")
+
+	revoked, _ = store.IsRevoked(ctx, sessionClaims(0))
+	assert.False(t, revoked, "未綁定工作階段的 token 不受影響")
+
+	store.Purge(now.Add(AccessTokenTTL + time.Minute))
+	revoked, _ = store.IsRevoked(ctx, sessionClaims(10))
+	assert.False(t, revoked, "token 全部過期後紀錄應被清除")
+}
+
+// recordingSessionTracker 記錄 AuthMiddleware 回報的工作階段使用紀錄
+type recordingSessionTracker struct {
+	sessions []uint
+	ip       string
+	agent    string
+}
+
+func (r *recordingSessionTracker) TouchSession(_ context.Context, claims *Claims, clientIP, userAgent string) {
+	r.sessions = append(r.sessions, claims.SessionID)
+	r.ip, r.agent = clientIP, userAgent
+}
+
+func TestAuthMiddlewareSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTest(t)
+
+	store := NewMemoryRevocationStore()
+	SetRevocationStore(store)
+	t.Cleanup(func() { SetRevocationStore(nil) })
+	tracker := &recordingSessionTracker{}
+	SetSessionTracker(tracker)
+	t.Cleanup(func() { SetSessionTracker(nil) })
+
+	perform := func(token string) int {
+		router := gin.New()
+		router.Use(AuthMiddleware())
+		router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("User-Agent", "test-device/1.0")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	withSession, err := GenerateToken(5, "device@example.com", WithSession(42))
+	require.NoError(t, err)
+	withoutSession, err := GenerateToken(5, "device@example.com")
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(withSession)
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), claims.SessionID)
+
+	assert.Equal(t, http.StatusOK, perform(withSession))
+	assert.Equal(t, http.StatusOK, perform(withoutSession))
+	assert.Equal(t, []uint{42}, tracker.sessions, "只有綁定工作階段的 token 會更新使用紀錄")
+	assert.Equal(t, "test-device/1.0", tracker.agent)
+
+	require.NoError(t, store.RevokeSession(context.Background(), 42, 5, time.Now().Add(AccessTokenTTL)))
+	assert.Equal(t, http.StatusUnauthorized, perform(withSession))
+	assert.Equal(t, http.StatusOK, perform(withoutSession))
+	assert.Equal(t, []uint{42}, tracker.sessions, "已撤銷的工作階段不會更新使用紀錄")
+}
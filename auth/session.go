@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// SessionTracker 記錄登入工作階段最後一次使用的時間與來源，由 AuthMiddleware 在認證成功後呼叫
+type SessionTracker interface {
+	TouchSession(ctx context.Context, claims *Claims, clientIP, userAgent string)
+}
+
+var (
+	sessionTrackerMu sync.RWMutex
+	sessionTracker   SessionTracker
+)
+
+// SetSessionTracker 設定工作階段使用紀錄，設為 nil 時不記錄
+func SetSessionTracker(tracker SessionTracker) {
+	sessionTrackerMu.Lock()
+	defer sessionTrackerMu.Unlock()
+	sessionTracker = tracker
+}
+
+// CurrentSessionTracker 回傳目前的工作階段使用紀錄，未設定時為 nil
+func CurrentSessionTracker() SessionTracker {
+	sessionTrackerMu.RLock()
+	defer sessionTrackerMu.RUnlock()
+	return sessionTracker
+}
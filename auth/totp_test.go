@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 附錄 D 測試向量
+	key := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, want := range expected {
+		assert.Equal(t, want, hotp(key, uint64(counter), 6), "counter %d", counter)
+	}
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 附錄 B 的 SHA1 測試向量（8 位數）
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name string
+		unix int64
+		want string
+	}{
+		{name: "T=59", unix: 59, want: "94287082"},
+		{name: "T=1111111109", unix: 1111111109, want: "07081804"},
+		{name: "T=1111111111", unix: 1111111111, want: "14050471"},
+		{name: "T=1234567890", unix: 1234567890, want: "89005924"},
+		{name: "T=2000000000", unix: 2000000000, want: "69279037"},
+		{name: "T=20000000000", unix: 20000000000, want: "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := totpStep(time.Unix(tt.unix, 0))
+			assert.Equal(t, tt.want, hotp(key, uint64(step), 8))
+		})
+	}
+
+	// 6 位數驗證碼為 8 位數結果的後 6 碼
+	secret := base32.StdEncoding.EncodeToString(key)
+	code, err := TOTPCode(secret, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "287082", code)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+	previous, err := TOTPCode(secret, now.Add(-TOTPPeriod))
+	require.NoError(t, err)
+	tooOld, err := TOTPCode(secret, now.Add(-3*TOTPPeriod))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{name: "目前的驗證碼", secret: secret, code: code, wantOK: true, wantStep: totpStep(now)},
+		{name: "允許前一個區間", secret: secret, code: previous, wantOK: true, wantStep: totpStep(now) - 1},
+		{name: "超過誤差範圍", secret: secret, code: tooOld, wantOK: false},
+		{name: "位數錯誤", secret: secret, code: "12345", wantOK: false},
+		{name: "無效的金鑰", secret: "not base32!", code: code, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.wantStep, step)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Member API", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Member API:user@example.com", parsed.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", parsed.Query().Get("secret"))
+	assert.Equal(t, "Member API", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+
+	format := regexp.MustCompile(`^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.False(t, seen[code], "復原碼不應重複")
+		seen[code] = true
+		assert.Equal(t, code, NormalizeRecoveryCode(code))
+	}
+
+	assert.Equal(t, "abcd-efgh-ijkl-mnop", NormalizeRecoveryCode(" ABCD EFGH-ijklmnop "))
+}
@@ -1,14 +1,25 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	Database DatabaseConfig
 	Server   ServerConfig
+	JWT      JWTConfig
+	Mail     MailConfig
+	Auth     AuthConfig
+	Password PasswordConfig
+	OIDC     []OIDCProviderConfig
+	Account  AccountConfig
+	WebAuthn WebAuthnConfig
+	Notify   NotifyConfig
+	Outbox   OutboxConfig
 }
 
 type DatabaseConfig struct {
@@ -20,9 +31,134 @@ type DatabaseConfig struct {
 
 type ServerConfig struct {
 	Port string
+	// BaseURL 郵件中連結使用的前端網址
+	BaseURL string
+}
+
+// MailConfig 郵件寄送設定；未設定 SMTPHost 時郵件寫入 OutboxDir（或僅保存在記憶體）
+type MailConfig struct {
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	OutboxDir    string
+}
+
+// AuthConfig 帳號安全相關設定
+type AuthConfig struct {
+	// RequireVerifiedEmail 為 true 時，新增、修改與刪除產品（REST 與 GraphQL）需要已驗證的 email
+	RequireVerifiedEmail bool
+	// RequireAdminMFA 為 true 時，管理端點需要通過兩步驟驗證的 token
+	RequireAdminMFA bool
+	// MagicLinkLogin 為 true 時，開放以寄到信箱的一次性連結免密碼登入；會員仍需自行選擇啟用
+	MagicLinkLogin bool
+
+	// 帳號連續登入失敗 MaxFailedLogins 次後鎖定 LockoutDuration，之後每次失敗加倍，最長 MaxLockoutDuration
+	MaxFailedLogins    int
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+
+	// 同一 IP 登入失敗 IPMaxFailedLogins 次後開始延遲 IPDelay，之後每次失敗加倍，最長 IPMaxDelay
+	IPMaxFailedLogins int
+	IPDelay           time.Duration
+	IPMaxDelay        time.Duration
+}
+
+// PasswordConfig 密碼雜湊設定；登入成功時，使用其他演算法或參數的舊雜湊會自動以此設定重新雜湊
+type PasswordConfig struct {
+	// HashAlgorithm 為 argon2id 或 bcrypt
+	HashAlgorithm     string
+	Argon2Memory      int // KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+
+	// 密碼規則
+	MinLength            int
+	MaxLength            int
+	RequireUpper         bool
+	RequireLower         bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool
+	// BreachedListPath 外洩密碼清單（SHA-1 雜湊檔案，或依前 5 碼分檔的目錄），空字串表示不檢查
+	BreachedListPath string
+}
+
+// OIDCProviderConfig 外部 OpenID Connect IdP 設定，由 OIDC_PROVIDERS 列出名稱，
+// 各項設定讀取 OIDC_<NAME>_* 環境變數（NAME 為大寫）
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL 未設定時為 {APP_BASE_URL}/api/v1/oidc/{name}/callback
+	RedirectURL string
+	Scopes      []string
+	// AllowedDomains 允許登入的 email 網域，空白表示不限制
+	AllowedDomains []string
+	// AutoLink 以 IdP 已驗證的 email 自動連結相同 email 的既有會員（管理員、工作人員與已啟用兩步驟驗證的會員除外）
+	AutoLink bool
+	// AutoProvision 第一次登入時自動建立會員
+	AutoProvision bool
+}
+
+// AccountConfig 會員自行刪除帳號的設定
+type AccountConfig struct {
+	// DeletionGracePeriod 申請刪除後到匿名化之間的緩衝期，期間內可取消；管理員刪除的會員同樣在此期間後匿名化
+	DeletionGracePeriod time.Duration
+	// AnonymizeInterval 背景工作檢查到期帳號的間隔
+	AnonymizeInterval time.Duration
+}
+
+// WebAuthnConfig passkey 登入的 relying party 設定；未設定時由 APP_BASE_URL 推得
+type WebAuthnConfig struct {
+	// RPID passkey 綁定的網域，預設為 APP_BASE_URL 的主機名稱
+	RPID   string
+	RPName string
+	// Origins 允許發起 passkey 註冊與登入的前端 origin，預設為 APP_BASE_URL 的 origin
+	Origins []string
+}
+
+// NotifyConfig 通知管道設定；站內收件匣一律啟用
+type NotifyConfig struct {
+	// Email 為 true 時，通知同時寄給 email 已驗證的會員
+	Email bool
+	// WebhookURL 設定時，每則通知也以 HTTP POST 送到此網址；WebhookSecret 用於簽章
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+}
+
+// OutboxConfig 背景寄送郵件與通知的 outbox worker 設定
+type OutboxConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	// MaxAttempts 最多嘗試次數，之後訊息進入 dead 狀態
+	MaxAttempts int
+	// 失敗後等待 BaseBackoff，之後每次加倍（加上隨機抖動），最長 MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Retention 已送達的訊息保留期限
+	Retention time.Duration
+	// HandlerTimeout 單則訊息的處理期限，需大於 webhook 與 SMTP 的逾時
+	HandlerTimeout time.Duration
+	// ShutdownTimeout 關閉時等待處理中訊息完成的最長時間
+	ShutdownTimeout time.Duration
+}
+
+// JWTConfig 非對稱簽章金鑰設定；未設定 SigningKeyFile 時使用 Secret 的 HS256，兩者皆未設定時無法啟動
+type JWTConfig struct {
+	Secret             string
+	SigningKeyFile     string
+	SigningKeyID       string
+	PreviousKeyFiles   []string
+	KeyRotationOverlap time.Duration
 }
 
 func Load() *Config {
+	baseURL := getEnv("APP_BASE_URL", "http://localhost:8080")
 	return &Config{
 		Database: DatabaseConfig{
 			DSN:             getEnv("POSTGRES_DSN", ""),
@@ -31,9 +167,114 @@ func Load() *Config {
 			ConnMaxLifetime: time.Hour,
 		},
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
+			Port:    getEnv("PORT", "8080"),
+			BaseURL: baseURL,
+		},
+		JWT: JWTConfig{
+			Secret:             getEnv("JWT_SECRET", ""),
+			SigningKeyFile:     getEnv("JWT_SIGNING_KEY_FILE", ""),
+			SigningKeyID:       getEnv("JWT_SIGNING_KEY_ID", ""),
+			PreviousKeyFiles:   getEnvList("JWT_PREVIOUS_KEY_FILES"),
+			KeyRotationOverlap: getEnvDuration("JWT_KEY_ROTATION_OVERLAP", 24*time.Hour),
+		},
+		Mail: MailConfig{
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("MAIL_FROM", "noreply@localhost"),
+			OutboxDir:    getEnv("MAIL_OUTBOX_DIR", ""),
+		},
+		Auth: AuthConfig{
+			RequireVerifiedEmail: getEnvBool("REQUIRE_VERIFIED_EMAIL", false),
+			RequireAdminMFA:      getEnvBool("REQUIRE_ADMIN_MFA", false),
+			MagicLinkLogin:       getEnvBool("MAGIC_LINK_LOGIN_ENABLED", false),
+			MaxFailedLogins:      getEnvInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+			LockoutDuration:      getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			MaxLockoutDuration:   getEnvDuration("LOGIN_MAX_LOCKOUT_DURATION", 24*time.Hour),
+			IPMaxFailedLogins:    getEnvInt("LOGIN_IP_MAX_FAILED_ATTEMPTS", 10),
+			IPDelay:              getEnvDuration("LOGIN_IP_DELAY", time.Second),
+			IPMaxDelay:           getEnvDuration("LOGIN_IP_MAX_DELAY", 15*time.Minute),
+		},
+		Password: PasswordConfig{
+			HashAlgorithm:     getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      getEnvInt("ARGON2_MEMORY_KIB", 64*1024),
+			Argon2Iterations:  getEnvInt("ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvInt("ARGON2_PARALLELISM", 2),
+			BcryptCost:        getEnvInt("BCRYPT_COST", 10),
+
+			MinLength:            getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:            getEnvInt("PASSWORD_MAX_LENGTH", 128),
+			RequireUpper:         getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:         getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:         getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:        getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+			DisallowPersonalInfo: getEnvBool("PASSWORD_DISALLOW_PERSONAL_INFO", true),
+			BreachedListPath:     getEnv("PASSWORD_BREACHED_LIST", ""),
+		},
+		OIDC: loadOIDCProviders(baseURL),
+		Account: AccountConfig{
+			DeletionGracePeriod: getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			AnonymizeInterval:   getEnvDuration("ACCOUNT_ANONYMIZE_INTERVAL", time.Hour),
 		},
+		WebAuthn: loadWebAuthn(baseURL),
+		Notify: NotifyConfig{
+			Email:          getEnvBool("NOTIFY_EMAIL_ENABLED", true),
+			WebhookURL:     getEnv("NOTIFY_WEBHOOK_URL", ""),
+			WebhookSecret:  getEnv("NOTIFY_WEBHOOK_SECRET", ""),
+			WebhookTimeout: getEnvDuration("NOTIFY_WEBHOOK_TIMEOUT", 10*time.Second),
+		},
+		Outbox: OutboxConfig{
+			Workers:         getEnvInt("OUTBOX_WORKERS", 4),
+			PollInterval:    getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			MaxAttempts:     getEnvInt("OUTBOX_MAX_ATTEMPTS", 8),
+			BaseBackoff:     getEnvDuration("OUTBOX_BASE_BACKOFF", 10*time.Second),
+			MaxBackoff:      getEnvDuration("OUTBOX_MAX_BACKOFF", time.Hour),
+			Retention:       getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+			HandlerTimeout:  getEnvDuration("OUTBOX_HANDLER_TIMEOUT", time.Minute),
+			ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		},
+	}
+}
+
+// loadWebAuthn 讀取 passkey 的 relying party 設定，未設定的項目由 baseURL 推得
+func loadWebAuthn(baseURL string) WebAuthnConfig {
+	var host, origin string
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	origins := getEnvList("WEBAUTHN_ORIGINS")
+	if len(origins) == 0 && origin != "" {
+		origins = []string{origin}
 	}
+	return WebAuthnConfig{
+		RPID:    getEnv("WEBAUTHN_RP_ID", host),
+		RPName:  getEnv("WEBAUTHN_RP_NAME", "Member API"),
+		Origins: origins,
+	}
+}
+
+// loadOIDCProviders 讀取 OIDC_PROVIDERS 列出的每個 IdP 設定
+func loadOIDCProviders(baseURL string) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvList("OIDC_PROVIDERS") {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:           name,
+			Issuer:         getEnv(prefix+"ISSUER", ""),
+			ClientID:       getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret:   getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:    getEnv(prefix+"REDIRECT_URL", strings.TrimRight(baseURL, "/")+"/api/v1/oidc/"+name+"/callback"),
+			Scopes:         getEnvList(prefix + "SCOPES"),
+			AllowedDomains: getEnvList(prefix + "ALLOWED_DOMAINS"),
+			AutoLink:       getEnvBool(prefix+"AUTO_LINK", false),
+			AutoProvision:  getEnvBool(prefix+"AUTO_PROVISION", false),
+		})
+	}
+	return providers
 }
 
 func getEnv(key, defaultValue string) string {
@@ -51,3 +292,32 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList 讀取以逗號分隔的環境變數，忽略空白項目
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -22,6 +22,7 @@ func TestLoad(t *testing.T) {
 				_ = os.Unsetenv("DB_MAX_OPEN_CONNS")
 				_ = os.Unsetenv("DB_MAX_IDLE_CONNS")
 				_ = os.Unsetenv("PORT")
+				_ = os.Unsetenv("JWT_SECRET")
 			},
 			cleanup: func() {},
 			validate: func(t *testing.T, cfg *Config) {
@@ -30,6 +31,41 @@ func TestLoad(t *testing.T) {
 				assert.Equal(t, 25, cfg.Database.MaxIdleConns)
 				assert.Equal(t, time.Hour, cfg.Database.ConnMaxLifetime)
 				assert.Equal(t, "8080", cfg.Server.Port)
+				assert.Equal(t, "", cfg.JWT.Secret)
+				assert.Equal(t, "", cfg.JWT.SigningKeyFile)
+				assert.Empty(t, cfg.JWT.PreviousKeyFiles)
+				assert.Equal(t, 24*time.Hour, cfg.JWT.KeyRotationOverlap)
+				assert.Equal(t, "http://localhost:8080", cfg.Server.BaseURL)
+				assert.Equal(t, "", cfg.Mail.SMTPHost)
+				assert.Equal(t, 587, cfg.Mail.SMTPPort)
+				assert.Equal(t, "noreply@localhost", cfg.Mail.From)
+				assert.False(t, cfg.Auth.RequireVerifiedEmail)
+				assert.False(t, cfg.Auth.RequireAdminMFA)
+				assert.False(t, cfg.Auth.MagicLinkLogin)
+				assert.Equal(t, "localhost", cfg.WebAuthn.RPID)
+				assert.Equal(t, []string{"http://localhost:8080"}, cfg.WebAuthn.Origins)
+				assert.Equal(t, 5, cfg.Auth.MaxFailedLogins)
+				assert.Equal(t, 15*time.Minute, cfg.Auth.LockoutDuration)
+				assert.Equal(t, 10, cfg.Auth.IPMaxFailedLogins)
+				assert.Equal(t, time.Second, cfg.Auth.IPDelay)
+				assert.Equal(t, "argon2id", cfg.Password.HashAlgorithm)
+				assert.Equal(t, 64*1024, cfg.Password.Argon2Memory)
+				assert.Equal(t, 10, cfg.Password.BcryptCost)
+				assert.Equal(t, 8, cfg.Password.MinLength)
+				assert.True(t, cfg.Password.DisallowPersonalInfo)
+				assert.Equal(t, "", cfg.Password.BreachedListPath)
+				assert.Empty(t, cfg.OIDC)
+				assert.Equal(t, 30*24*time.Hour, cfg.Account.DeletionGracePeriod)
+				assert.Equal(t, time.Hour, cfg.Account.AnonymizeInterval)
+				assert.True(t, cfg.Notify.Email)
+				assert.Equal(t, "", cfg.Notify.WebhookURL)
+				assert.Equal(t, 10*time.Second, cfg.Notify.WebhookTimeout)
+				assert.Equal(t, 4, cfg.Outbox.Workers)
+				assert.Equal(t, 8, cfg.Outbox.MaxAttempts)
+				assert.Equal(t, 10*time.Second, cfg.Outbox.BaseBackoff)
+				assert.Equal(t, time.Hour, cfg.Outbox.MaxBackoff)
+				assert.Equal(t, time.Minute, cfg.Outbox.HandlerTimeout)
+				assert.Equal(t, 30*time.Second, cfg.Outbox.ShutdownTimeout)
 			},
 		},
 		{
@@ -234,3 +270,176 @@ func TestConfigStructure(t *testing.T) {
 	assert.Equal(t, 2*time.Hour, cfg.Database.ConnMaxLifetime)
 	assert.Equal(t, "8080", cfg.Server.Port)
 }
+
+func TestGetEnvDuration(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		defaultValue time.Duration
+		envValue     string
+		setEnv       bool
+		expected     time.Duration
+	}{
+		{
+			name:         "環境變數為有效時間長度",
+			key:          "TEST_DURATION",
+			defaultValue: time.Hour,
+			envValue:     "15m",
+			setEnv:       true,
+			expected:     15 * time.Minute,
+		},
+		{
+			name:         "環境變數不存在使用預設值",
+			key:          "TEST_DURATION_NOT_SET",
+			defaultValue: 24 * time.Hour,
+			setEnv:       false,
+			expected:     24 * time.Hour,
+		},
+		{
+			name:         "環境變數為無效格式使用預設值",
+			key:          "TEST_DURATION_INVALID",
+			defaultValue: time.Minute,
+			envValue:     "ten minutes",
+			setEnv:       true,
+			expected:     time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setEnv {
+				_ = os.Setenv(tt.key, tt.envValue)
+				defer func() { _ = os.Unsetenv(tt.key) }()
+			}
+
+			result := getEnvDuration(tt.key, tt.defaultValue)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetEnvList(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		setEnv   bool
+		expected []string
+	}{
+		{
+			name:     "逗號分隔的多個項目",
+			envValue: "a=/keys/a.pem, /keys/b.pem",
+			setEnv:   true,
+			expected: []string{"a=/keys/a.pem", "/keys/b.pem"},
+		},
+		{
+			name:     "忽略空白項目",
+			envValue: " , x ,,",
+			setEnv:   true,
+			expected: []string{"x"},
+		},
+		{
+			name:     "環境變數不存在",
+			setEnv:   false,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setEnv {
+				_ = os.Setenv("TEST_LIST", tt.envValue)
+				defer func() { _ = os.Unsetenv("TEST_LIST") }()
+			}
+
+			assert.Equal(t, tt.expected, getEnvList("TEST_LIST"))
+		})
+	}
+}
+
+func TestGetEnvBool(t *testing.T) {
+	tests := []struct {
+		name         string
+		defaultValue bool
+		envValue     string
+		setEnv       bool
+		expected     bool
+	}{
+		{name: "環境變數為 true", envValue: "true", setEnv: true, expected: true},
+		{name: "環境變數為 1", envValue: "1", setEnv: true, expected: true},
+		{name: "環境變數為 false 覆蓋預設值", defaultValue: true, envValue: "false", setEnv: true, expected: false},
+		{name: "環境變數不存在使用預設值", defaultValue: true, setEnv: false, expected: true},
+		{name: "環境變數為無效值使用預設值", envValue: "yes please", setEnv: true, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setEnv {
+				_ = os.Setenv("TEST_BOOL", tt.envValue)
+				defer func() { _ = os.Unsetenv("TEST_BOOL") }()
+			}
+
+			assert.Equal(t, tt.expected, getEnvBool("TEST_BOOL", tt.defaultValue))
+		})
+	}
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	env := map[string]string{
+		"OIDC_PROVIDERS":                     "Corp, google-workspace",
+		"OIDC_CORP_ISSUER":                   "https://login.corp.example",
+		"OIDC_CORP_CLIENT_ID":                "member-api",
+		"OIDC_CORP_CLIENT_SECRET":            "secret",
+		"OIDC_CORP_SCOPES":                   "email,profile",
+		"OIDC_CORP_ALLOWED_DOMAINS":          "corp.example",
+		"OIDC_CORP_AUTO_LINK":                "true",
+		"OIDC_CORP_AUTO_PROVISION":           "true",
+		"OIDC_GOOGLE_WORKSPACE_ISSUER":       "https://accounts.google.com",
+		"OIDC_GOOGLE_WORKSPACE_REDIRECT_URL": "https://app.example/callback",
+	}
+	for key, value := range env {
+		_ = os.Setenv(key, value)
+	}
+	defer func() {
+		for key := range env {
+			_ = os.Unsetenv(key)
+		}
+	}()
+
+	providers := loadOIDCProviders("https://api.example/")
+	assert.Equal(t, []OIDCProviderConfig{
+		{
+			Name:           "corp",
+			Issuer:         "https://login.corp.example",
+			ClientID:       "member-api",
+			ClientSecret:   "secret",
+			RedirectURL:    "https://api.example/api/v1/oidc/corp/callback",
+			Scopes:         []string{"email", "profile"},
+			AllowedDomains: []string{"corp.example"},
+			AutoLink:       true,
+			AutoProvision:  true,
+		},
+		{
+			Name:        "google-workspace",
+			Issuer:      "https://accounts.google.com",
+			RedirectURL: "https://app.example/callback",
+		},
+	}, providers)
+}
+
+func TestLoadWebAuthn(t *testing.T) {
+	assert.Equal(t, WebAuthnConfig{
+		RPID:    "app.example",
+		RPName:  "Member API",
+		Origins: []string{"https://app.example:8443"},
+	}, loadWebAuthn("https://app.example:8443/"))
+
+	_ = os.Setenv("WEBAUTHN_RP_ID", "example")
+	_ = os.Setenv("WEBAUTHN_ORIGINS", "https://app.example, https://admin.example")
+	defer func() {
+		_ = os.Unsetenv("WEBAUTHN_RP_ID")
+		_ = os.Unsetenv("WEBAUTHN_ORIGINS")
+	}()
+	cfg := loadWebAuthn("https://app.example")
+	assert.Equal(t, "example", cfg.RPID)
+	assert.Equal(t, []string{"https://app.example", "https://admin.example"}, cfg.Origins)
+}
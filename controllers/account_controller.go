@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accountDeletionGracePeriod 申請刪除帳號到匿名化之間的緩衝期
+var accountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// SetupAccountDeletion 設定刪除帳號的緩衝期
+func SetupAccountDeletion(gracePeriod time.Duration) {
+	accountDeletionGracePeriod = gracePeriod
+}
+
+type DeleteAccountRequest struct {
+	// Password 以外部帳號建立、尚未設定密碼的會員可省略
+	Password string `json:"password" example:"password123"`
+}
+
+// ExportProfile 匯出個人資料
+// @Summary 匯出個人資料
+// @Description 匯出我們保存的所有與目前會員相關的資料：基本資料、角色、連結的外部帳號、登入裝置、API key（不含 key 本身）、建立的產品與相關稽核紀錄。format=zip 時每個區塊為一個 JSON 檔案。不接受 API key 與模擬登入
+// @Tags 用戶
+// @Produce json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param format query string false "json（預設）或 zip" Enums(json, zip)
+// @Success 200 {object} services.MemberExport "匯出內容"
+// @Failure 400 {object} map[string]string "無效的格式"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "用戶不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /profile/export [get]
+func ExportProfile(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 必須為 json 或 zip"})
+		return
+	}
+
+	export, err := services.NewMemberService(db).ExportData(c.Request.Context(), memberID, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		if errors.Is(err, services.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用戶不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("member-%d-export-%s", memberID, export.ExportedAt.Format("20060102"))
+	c.Header("Cache-Control", "no-store")
+	if format == "zip" {
+		var buf bytes.Buffer
+		if err := export.WriteZip(&buf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	c.JSON(http.StatusOK, export)
+}
+
+// RequestAccountDeletion 申請刪除帳號
+// @Summary 申請刪除帳號
+// @Description 以目前的密碼驗證後申請刪除帳號，並寄送通知信。錯誤的密碼會計入登入失敗次數。緩衝期（ACCOUNT_DELETION_GRACE_PERIOD）內可取消；到期後個人資料會被匿名化且無法復原，建立的產品與稽核紀錄保留。不接受 API key 與模擬登入
+// @Tags 用戶
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param deletion body DeleteAccountRequest false "目前的密碼"
+// @Success 202 {object} map[string]interface{} "已排定刪除時間（scheduled_at）"
+// @Failure 400 {object} map[string]string "請求參數錯誤或目前的密碼錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 409 {object} map[string]string "已申請刪除帳號"
+// @Failure 423 {object} map[string]string "帳號因連續驗證失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 驗證失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /profile/deletion [post]
+func RequestAccountDeletion(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req DeleteAccountRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "驗證嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	svc := services.NewAccountDeletionService(db, accountDeletionGracePeriod)
+	scheduledAt, err := svc.Schedule(c.Request.Context(), memberID, req.Password, clientIP, c.Request.UserAgent(), accountLockout)
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			loginThrottle.Failure(clientIP)
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrDeletionAlreadyScheduled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCurrentPasswordIncorrect):
+			loginThrottle.Failure(clientIP)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	loginThrottle.Reset(clientIP)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "已申請刪除帳號，期限前可取消",
+		"scheduled_at": scheduledAt,
+	})
+}
+
+// CancelAccountDeletion 取消刪除帳號
+// @Summary 取消刪除帳號
+// @Description 在緩衝期內取消刪除帳號的申請。不接受 API key 與模擬登入
+// @Tags 用戶
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "已取消"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "沒有待處理的刪除申請"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /profile/deletion [delete]
+func CancelAccountDeletion(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	svc := services.NewAccountDeletionService(db, accountDeletionGracePeriod)
+	if err := svc.Cancel(c.Request.Context(), memberID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if errors.Is(err, services.ErrDeletionNotScheduled) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已取消刪除帳號"})
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"member_API/auth"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleRequest represents the request body for granting a role.
+type RoleRequest struct {
+	Role string `json:"role" binding:"required" example:"staff"`
+}
+
+// RolesResponse represents the roles held by a member.
+type RolesResponse struct {
+	MemberID uint     `json:"member_id" example:"1"`
+	Roles    []string `json:"roles" example:"admin,member"`
+}
+
+// parseMemberID parses the :id path parameter as a member ID.
+func parseMemberID(c *gin.Context) (uint, bool) {
+	memberID, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return uint(memberID), true
+}
+
+// respondRoleError maps role service errors to HTTP responses.
+func respondRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetMemberRoles returns the roles of a member.
+// @Summary 獲取會員角色
+// @Description 獲取指定會員的角色列表，僅限管理員
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Success 200 {object} RolesResponse "獲取成功"
+// @Failure 400 {object} map[string]string "無效的會員 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/roles [get]
+func GetMemberRoles(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	svc := services.NewMemberService(db)
+	if _, err := svc.GetMemberByID(memberID); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	roles, err := svc.GetRoles(memberID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{MemberID: memberID, Roles: roles})
+}
+
+// GrantMemberRole grants a role to a member.
+// @Summary 授予會員角色
+// @Description 授予指定會員角色（admin 或 staff），僅限管理員。會員現有的 token 會一併撤銷，重新登入後生效
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Param role body RoleRequest true "角色"
+// @Success 200 {object} RolesResponse "授予成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤或無效的角色"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/roles [post]
+func GrantMemberRole(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := services.NewMemberService(db)
+	if err := svc.GrantRole(memberID, req.Role, currentUserID(c)); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	// 角色寫在 token 中，撤銷現有 token 讓會員重新登入取得新角色
+	if err := revokeAllTokens(c.Request.Context(), memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := svc.GetRoles(memberID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{MemberID: memberID, Roles: roles})
+}
+
+// RevokeMemberRole revokes a role from a member.
+// @Summary 撤銷會員角色
+// @Description 撤銷指定會員的角色，僅限管理員。會員現有的 token 會一併撤銷，重新登入後生效
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Param role path string true "角色" example(staff)
+// @Success 200 {object} RolesResponse "撤銷成功"
+// @Failure 400 {object} map[string]string "無效的角色"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/roles/{role} [delete]
+func RevokeMemberRole(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	svc := services.NewMemberService(db)
+	if err := svc.RevokeRole(memberID, c.Param("role")); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	// 角色寫在 token 中，不撤銷現有 token 的話被撤銷的角色在 token 到期前仍然有效
+	if err := revokeAllTokens(c.Request.Context(), memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	roles, err := svc.GetRoles(memberID)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{MemberID: memberID, Roles: roles})
+}
+
+// RevokeMemberTokens revokes every token issued to a member.
+// @Summary 撤銷會員所有 token
+// @Description 撤銷指定會員目前所有的 access token 與 refresh token，強制其重新登入，僅限管理員
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Success 200 {object} map[string]string "撤銷成功"
+// @Failure 400 {object} map[string]string "無效的會員 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/revoke-tokens [post]
+func RevokeMemberTokens(c *gin.Context) {
+	store := auth.CurrentRevocationStore()
+	if db == nil || store == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := services.NewMemberService(db).GetMemberByID(memberID); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	if err := revokeAllTokens(c.Request.Context(), memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all tokens revoked"})
+}
+
+// revokeAllTokens revokes every access token and refresh token issued to a member.
+func revokeAllTokens(ctx context.Context, memberID uint) error {
+	if store := auth.CurrentRevocationStore(); store != nil {
+		if err := store.RevokeAllForMember(ctx, int64(memberID), time.Now()); err != nil {
+			return err
+		}
+	}
+	return services.NewRefreshTokenService(db).RevokeAllForMember(memberID)
+}
+
+// UnlockMember clears a member's login lockout.
+// @Summary 解除會員登入鎖定
+// @Description 清除指定會員的登入失敗次數並解除暫時鎖定，僅限管理員
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Success 200 {object} map[string]string "解除成功"
+// @Failure 400 {object} map[string]string "無效的會員 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/unlock [post]
+func UnlockMember(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewMemberService(db).UnlockMember(memberID, currentUserID(c)); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member unlocked"})
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"member_API/models"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest is the request body for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"nightly-export"`
+	// Scopes: read, write, admin, or a permission such as member:read
+	Scopes []string `json:"scopes" binding:"required,min=1" example:"read"`
+	// ExpiresInDays omitted or 0 means the key never expires
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0,max=3650" example:"90"`
+}
+
+// CreateAPIKeyResponse contains the plaintext key, which is only shown once.
+type CreateAPIKeyResponse struct {
+	Key    string        `json:"key" example:"mk_1a2b3c4d_3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
+	APIKey models.APIKey `json:"api_key"`
+}
+
+// CreateAPIKey 建立 API key
+// @Summary 建立 API key
+// @Description 建立供程式使用的 API key，以 "Authorization: ApiKey {key}" 呼叫 API。明文只會在此回應中顯示一次；權限為會員角色與 scope 的交集（read 允許讀取、write 允許寫入、admin 允許管理端點，或指定單一權限如 member:read）
+// @Tags API Key
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param apiKey body CreateAPIKeyRequest true "名稱、scope 與有效天數"
+// @Success 201 {object} CreateAPIKeyResponse "建立成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤或無效的 scope"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不可使用 API key 管理 API key"
+// @Failure 409 {object} map[string]string "API key 數量已達上限"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	plain, key, err := services.NewAPIKeyService(db).Create(memberID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKeyScope), errors.Is(err, services.ErrAPIKeyExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAPIKeyLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{Key: plain, APIKey: *key})
+}
+
+// ListAPIKeys 列出 API key
+// @Summary 列出 API key
+// @Description 列出目前會員的所有 API key（不含明文），包含最後使用時間與來源 IP
+// @Tags API Key
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKey "API key 列表"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不可使用 API key 管理 API key"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	keys, err := services.NewAPIKeyService(db).List(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey 撤銷 API key
+// @Summary 撤銷 API key
+// @Description 撤銷目前會員的 API key，撤銷後立即失效
+// @Tags API Key
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID" example(1)
+// @Success 200 {object} map[string]string "撤銷成功"
+// @Failure 400 {object} map[string]string "無效的 API key ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不可使用 API key 管理 API key"
+// @Failure 404 {object} map[string]string "API key 不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的 API key ID"})
+		return
+	}
+
+	if err := services.NewAPIKeyService(db).Revoke(memberID, uint(keyID)); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key 已撤銷"})
+}
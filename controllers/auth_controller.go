@@ -2,7 +2,10 @@ package controllers
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"member_API/auth"
 	"member_API/models"
@@ -20,23 +23,96 @@ type LoginRequest struct {
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required" example:"張三"`
 	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
-	Password string `json:"password" binding:"required,min=6" example:"password123"`
+	Password string `json:"password" binding:"required" example:"correct-horse-battery"`
+	// Locale selects the language of emails and notifications; defaults to the Accept-Language header
+	Locale string `json:"locale" binding:"omitempty,oneof=zh-TW en" example:"zh-TW"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
 }
 
 type AuthResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User  User   `json:"user"`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token" example:"3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"`
+	User         User   `json:"user"`
+}
+
+var (
+	// accountLockout 帳號層級的登入失敗鎖定規則（零值表示停用）
+	accountLockout auth.LockoutPolicy
+	// loginThrottle 來源 IP 層級的登入節流（nil 表示停用）
+	loginThrottle *auth.LoginThrottle
+)
+
+// SetupLoginProtection 設定登入的暴力破解防護
+func SetupLoginProtection(account auth.LockoutPolicy, throttle *auth.LoginThrottle) {
+	accountLockout = account
+	loginThrottle = throttle
+}
+
+// setRetryAfter 設定 Retry-After 標頭（秒數，無條件進位）
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
+
+// generateAccessToken 載入會員角色並簽發綁定到工作階段的 access token。
+// 已啟用兩步驟驗證的會員只能通過第二因素登入，以 passkey 登入的工作階段同樣視為多因素
+func generateAccessToken(member *models.Member, session *models.Session) (string, error) {
+	roles, err := services.NewMemberService(db).GetRoles(member.ID)
+	if err != nil {
+		return "", err
+	}
+	return auth.GenerateToken(int64(member.ID), member.Email,
+		auth.WithRoles(roles...),
+		auth.WithEmailVerified(member.EmailVerifiedAt != nil),
+		auth.WithMFA(member.TOTPEnabledAt != nil || session.Passkey),
+		auth.WithSession(session.ID))
+}
+
+// newAuthResponse 為會員建立新的登入工作階段，並簽發 access token 與新的 refresh token 家族
+func newAuthResponse(c *gin.Context, member *models.Member) (*AuthResponse, error) {
+	return issueAuthResponse(c, member, services.NewSessionService(db).Start)
+}
+
+// issueAuthResponse 以 start 建立工作階段後簽發 access token 與 refresh token
+func issueAuthResponse(c *gin.Context, member *models.Member, start func(memberID uint, userAgent, clientIP string) (*models.Session, string, error)) (*AuthResponse, error) {
+	user := User{ID: int64(member.ID), Name: member.Name, Email: member.Email}
+
+	session, refreshToken, err := start(member.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateAccessToken(member, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
 }
 
 // Register 用戶註冊
 // @Summary 用戶註冊
-// @Description 註冊新用戶，返回 JWT token 和用戶信息
+// @Description 註冊新用戶，返回 JWT token、refresh token 和用戶信息
 // @Tags 認證
 // @Accept json
 // @Produce json
 // @Param register body RegisterRequest true "註冊信息"
 // @Success 201 {object} AuthResponse "註冊成功"
-// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 400 {object} PasswordPolicyResponse "請求參數錯誤或密碼不符合密碼規則"
 // @Failure 409 {object} map[string]string "該電子郵件已被註冊"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /register [post]
@@ -55,10 +131,19 @@ func Register(input *gin.Context) {
 	// 使用 Service 層建立會員（自動處理密碼加密、審計欄位等）
 	svc := services.NewMemberService(db)
 
+	// 未指定語系時依 Accept-Language 選擇
+	locale := req.Locale
+	if locale == "" {
+		locale = input.GetHeader("Accept-Language")
+	}
+
 	// 註冊時使用 creatorId = 0 表示自行註冊
-	member, err := svc.CreateMember(req.Name, req.Email, req.Password, 0)
+	member, err := svc.CreateMember(req.Name, req.Email, req.Password, locale, 0)
 	if err != nil {
-		if err.Error() == "email 已被使用" {
+		if respondPasswordPolicyError(input, err) {
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
 			input.JSON(http.StatusConflict, gin.H{"error": "該電子郵件已被註冊"})
 			return
 		}
@@ -66,31 +151,29 @@ func Register(input *gin.Context) {
 		return
 	}
 
-	user := User{ID: int64(member.ID), Name: member.Name, Email: member.Email}
-
 	// 生成 token
-	token, err := auth.GenerateToken(user.ID, user.Email)
+	resp, err := newAuthResponse(input, member)
 	if err != nil {
 		input.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
 		return
 	}
 
-	input.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
-	})
+	input.JSON(http.StatusCreated, resp)
 }
 
 // Login 用戶登入
 // @Summary 用戶登入
-// @Description 用戶登入，驗證郵件和密碼後返回 JWT token 和用戶信息
+// @Description 用戶登入，驗證郵件和密碼後返回 JWT token、refresh token 和用戶信息
 // @Tags 認證
 // @Accept json
 // @Produce json
 // @Param login body LoginRequest true "登入信息"
 // @Success 200 {object} AuthResponse "登入成功"
+// @Success 202 {object} MFAChallengeResponse "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入"
 // @Failure 400 {object} map[string]string "請求參數錯誤"
 // @Failure 401 {object} map[string]string "電子郵件或密碼錯誤"
+// @Failure 423 {object} map[string]string "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /login [post]
 func Login(input *gin.Context) {
@@ -105,45 +188,112 @@ func Login(input *gin.Context) {
 		return
 	}
 
-	// 查詢用戶
-	var member models.Member
-	err := db.WithContext(input.Request.Context()).
-		Where("email = ?", req.Email).
-		First(&member).Error
+	// 同一來源 IP 失敗過多時先行拒絕
+	clientIP := input.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(input, wait)
+		input.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
 
+	// 驗證密碼（失敗時累計次數並視情況鎖定帳號）
+	member, err := services.NewMemberService(db).Authenticate(input.Request.Context(), req.Email, req.Password, accountLockout)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			input.JSON(http.StatusUnauthorized, gin.H{"error": "電子郵件或密碼錯誤"})
-			return
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			loginThrottle.Failure(clientIP)
+			setRetryAfter(input, time.Until(locked.Until))
+			input.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			loginThrottle.Failure(clientIP)
+			input.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			input.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
-		input.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	loginThrottle.Reset(clientIP)
 
-	// 驗證密碼
-	if !auth.CheckPassword(req.Password, member.PasswordHash) {
-		input.JSON(http.StatusUnauthorized, gin.H{"error": "電子郵件或密碼錯誤"})
+	// 已啟用兩步驟驗證時，先回傳第二階段的 mfa_token
+	if member.TOTPEnabledAt != nil {
+		newMFAChallengeResponse(input, member.ID, member.Email)
 		return
 	}
 
-	user := User{ID: int64(member.ID), Name: member.Name, Email: member.Email}
-
 	// 生成 token
-	token, err := auth.GenerateToken(user.ID, user.Email)
+	resp, err := newAuthResponse(input, member)
 	if err != nil {
 		input.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
 		return
 	}
 
-	input.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
+	input.JSON(http.StatusOK, resp)
+}
+
+// RefreshToken 換發 token
+// @Summary 換發 token
+// @Description 使用 refresh token 換發新的 access token 與 refresh token（舊的 refresh token 隨即失效）。重複使用已換發過的 refresh token 會撤銷該登入的所有 refresh token
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param refresh body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} AuthResponse "換發成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "refresh token 無效、過期或已被重複使用"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refreshToken, issued, err := services.NewRefreshTokenService(db).Rotate(c.Request.Context(), req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := services.NewMemberService(db).GetMemberByID(issued.MemberID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用戶不存在"})
+		return
+	}
+
+	session, err := services.NewSessionService(db).Get(issued.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := User{ID: int64(member.ID), Name: member.Name, Email: member.Email}
+	token, err := generateAccessToken(member, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         user,
 	})
 }
 
 // GetProfile 獲取當前用戶信息（需要認證）
 // @Summary 獲取當前用戶信息
-// @Description 獲取當前登入用戶的詳細信息，需要 JWT 認證
+// @Description 獲取當前登入用戶的詳細信息，需要 JWT 認證。以模擬登入 token 呼叫時，回應包含 impersonation 欄位（實際操作的管理員與到期時間），供前端顯示提示橫幅；已申請刪除帳號時包含 deletion_scheduled_at
 // @Tags 用戶
 // @Accept json
 // @Produce json
@@ -173,7 +323,7 @@ func GetProfile(c *gin.Context) {
 
 	var member models.Member
 	if err := db.WithContext(c.Request.Context()).
-		Select("id", "name", "email").
+		Select("id", "name", "email", "email_verified_at", "deletion_scheduled_at").
 		First(&member, idValue).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "用戶不存在"})
@@ -183,5 +333,92 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": User{ID: int64(member.ID), Name: member.Name, Email: member.Email}})
+	resp := gin.H{
+		"user":           User{ID: int64(member.ID), Name: member.Name, Email: member.Email},
+		"email_verified": member.EmailVerifiedAt != nil,
+	}
+	if member.DeletionScheduledAt != nil {
+		resp["deletion_scheduled_at"] = member.DeletionScheduledAt
+	}
+	if banner := impersonationBanner(currentClaims(c)); banner != nil {
+		resp["impersonation"] = banner
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout 用戶登出
+// @Summary 用戶登出
+// @Description 撤銷目前使用的 access token 並結束其工作階段（該登入的 refresh token 一併失效）；若提供 refresh token，其所屬登入也會一併撤銷
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param logout body LogoutRequest false "要一併撤銷的 refresh token"
+// @Success 200 {object} map[string]string "登出成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /logout [post]
+func Logout(c *gin.Context) {
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	store := auth.CurrentRevocationStore()
+	if store == nil || db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token 撤銷功能未配置"})
+		return
+	}
+
+	if err := store.RevokeToken(c.Request.Context(), claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 結束目前的工作階段，該登入的 refresh token 一併失效
+	if claims.SessionID != 0 {
+		err := services.NewSessionService(db).Revoke(c.Request.Context(), uint(claims.UserID), claims.SessionID)
+		if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		err := services.NewRefreshTokenService(db).Revoke(req.RefreshToken, uint(claims.UserID))
+		if err != nil && !errors.Is(err, services.ErrRefreshTokenInvalid) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// GetJWKS 公開 JWT 驗證公鑰
+// @Summary 獲取 JWT 驗證公鑰
+// @Description 以 JWKS 格式公開目前用於驗證 token 的公鑰（包含輪替重疊期內的舊金鑰）。使用 HS256 共享密鑰時 keys 為空陣列
+// @Tags 認證
+// @Produce json
+// @Success 200 {object} auth.JWKSet "JWKS"
+// @Router /.well-known/jwks.json [get]
+func GetJWKS(c *gin.Context) {
+	ring := auth.CurrentKeyRing()
+	if ring == nil {
+		c.JSON(http.StatusOK, auth.JWKSet{Keys: []auth.JWK{}})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, ring.JWKS())
 }
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+type ChangeEmailRequest struct {
+	Password string `json:"password" binding:"required" example:"password123"`
+	NewEmail string `json:"new_email" binding:"required,email" example:"new@example.com"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required" example:"q9C1bZ0x..."`
+}
+
+// VerifyEmail 驗證電子郵件
+// @Summary 驗證電子郵件
+// @Description 使用驗證信中的 token 完成電子郵件驗證。驗證後需以 refresh token 換發新的 access token，才能使用需要已驗證 email 的功能
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param verify body VerifyEmailRequest true "驗證信中的 token"
+// @Success 200 {object} map[string]string "驗證成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤或連結無效、已過期"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /email/verify [post]
+func VerifyEmail(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := services.NewMemberService(db).VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrEmailVerificationInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email 驗證成功"})
+}
+
+// ResendVerificationEmail 重新寄送驗證信
+// @Summary 重新寄送驗證信
+// @Description 重新寄送電子郵件驗證信給目前登入的會員
+// @Tags 認證
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "已寄出"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 409 {object} map[string]string "email 已完成驗證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /email/verify/resend [post]
+func ResendVerificationEmail(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	if err := services.NewMemberService(db).ResendVerificationEmail(c.Request.Context(), memberID); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "驗證信已寄出"})
+}
+
+// ChangeEmail 申請變更電子郵件
+// @Summary 申請變更電子郵件
+// @Description 以目前的密碼驗證後，寄送確認連結到新的電子郵件並通知原信箱；點擊確認連結後才會變更。不接受 API key 與模擬登入
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param change body ChangeEmailRequest true "目前的密碼與新的電子郵件"
+// @Success 202 {object} map[string]string "已寄出確認信"
+// @Failure 400 {object} map[string]string "請求參數錯誤、目前的密碼錯誤或新 email 與目前相同"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 409 {object} map[string]string "email 已被使用"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /email/change [post]
+func ChangeEmail(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewMemberService(db).RequestEmailChange(c.Request.Context(), memberID, req.Password, req.NewEmail); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCurrentPasswordIncorrect), errors.Is(err, services.ErrPasswordNotSet), errors.Is(err, services.ErrEmailUnchanged):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "確認信已寄到新的電子郵件，請點擊信中連結完成變更"})
+}
+
+// ConfirmEmailChange 確認變更電子郵件
+// @Summary 確認變更電子郵件
+// @Description 使用寄到新信箱的確認連結中的 token 完成變更，新的電子郵件視為已驗證。變更後需以 refresh token 換發新的 access token
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param confirm body ConfirmEmailChangeRequest true "確認信中的 token"
+// @Success 200 {object} map[string]User "變更成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤或連結無效、已過期"
+// @Failure 409 {object} map[string]string "email 已被使用"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /email/change/confirm [post]
+func ConfirmEmailChange(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := services.NewMemberService(db).ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrActionTokenInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": User{ID: int64(member.ID), Name: member.Name, Email: member.Email}})
+}
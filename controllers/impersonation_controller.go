@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"member_API/auth"
+	"member_API/models"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonateRequest represents the request body for starting an impersonation.
+type ImpersonateRequest struct {
+	// Reason is recorded in the audit log, e.g. the support ticket being investigated
+	Reason string `json:"reason" binding:"required,max=500" example:"Ticket #1234: member cannot see their orders"`
+}
+
+// ImpersonationResponse contains the short-lived token acting as the member.
+type ImpersonationResponse struct {
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// ImpersonationBanner tells clients the current token is an administrator acting as the member.
+type ImpersonationBanner struct {
+	ActorID    int64     `json:"actor_id" example:"1"`
+	ActorEmail string    `json:"actor_email" example:"admin@example.com"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Message    string    `json:"message" example:"管理員 admin@example.com 正以此會員身分檢視，寫入操作已停用"`
+}
+
+// AuditLogsResponse is a page of audit log entries.
+type AuditLogsResponse struct {
+	Logs   []models.AuditLog `json:"logs"`
+	Total  int64             `json:"total" example:"42"`
+	Limit  int               `json:"limit" example:"50"`
+	Offset int               `json:"offset" example:"0"`
+}
+
+// impersonationBanner returns the banner for an impersonation token, or nil for a regular token.
+func impersonationBanner(claims *auth.Claims) *ImpersonationBanner {
+	if claims == nil || !claims.IsImpersonated() {
+		return nil
+	}
+	return &ImpersonationBanner{
+		ActorID:    claims.Actor.UserID,
+		ActorEmail: claims.Actor.Email,
+		ExpiresAt:  claims.ExpiresAt.Time,
+		Message:    "管理員 " + claims.Actor.Email + " 正以此會員身分檢視，寫入操作已停用",
+	}
+}
+
+// StartImpersonation issues a short-lived token acting as a member.
+// @Summary 模擬會員登入
+// @Description 簽發代表指定會員的短期 token（15 分鐘），供客服重現會員看到的畫面，僅限管理員且不接受 API key。
+// @Description token 帶有 act claim 記錄實際操作的管理員，只能執行讀取操作；不可模擬自己或其他管理員。開始與結束皆寫入稽核紀錄
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(2)
+// @Param request body ImpersonateRequest true "模擬原因"
+// @Success 200 {object} ImpersonationResponse "模擬 token"
+// @Failure 400 {object} map[string]string "請求參數錯誤或不可模擬自己"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足或目標為管理員"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	result, err := services.NewImpersonationService(db).Start(c.Request.Context(), claims, memberID, req.Reason, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImpersonateSelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImpersonateAdmin), errors.Is(err, services.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			respondRoleError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ImpersonationResponse{
+		Token:     result.Token,
+		ExpiresAt: result.ExpiresAt,
+		User:      User{ID: int64(result.Member.ID), Name: result.Member.Name, Email: result.Member.Email},
+	})
+}
+
+// StopImpersonation ends the impersonation the current token belongs to.
+// @Summary 結束模擬登入
+// @Description 以模擬登入 token 呼叫，立即撤銷該 token 並寫入稽核紀錄
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "已結束"
+// @Failure 400 {object} map[string]string "目前不是模擬登入"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /impersonation/stop [post]
+func StopImpersonation(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	if err := services.NewImpersonationService(db).Stop(c.Request.Context(), claims, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if errors.Is(err, services.ErrNotImpersonating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "impersonation ended"})
+}
+
+// GetAuditLogs lists audit log entries.
+// @Summary 查詢稽核紀錄
+// @Description 依動作、操作者或目標會員查詢稽核紀錄（新到舊），僅限管理員
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Param action query string false "動作，例如 impersonation.start"
+// @Param actor_id query int false "操作者會員 ID"
+// @Param target_id query int false "目標會員 ID"
+// @Param limit query int false "限制返回數量" default(50) minimum(1) maximum(100)
+// @Param offset query int false "偏移量" default(0) minimum(0)
+// @Success 200 {object} AuditLogsResponse "稽核紀錄"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/audit-logs [get]
+func GetAuditLogs(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	actorID, _ := strconv.ParseUint(c.Query("actor_id"), 10, strconv.IntSize)
+	targetID, _ := strconv.ParseUint(c.Query("target_id"), 10, strconv.IntSize)
+
+	logs, total, err := services.NewAuditService(db).List(c.Request.Context(), services.AuditFilter{
+		Action:   c.Query("action"),
+		ActorID:  uint(actorID),
+		TargetID: uint(targetID),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditLogsResponse{Logs: logs, Total: total, Limit: limit, Offset: offset})
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+type MagicLinkSettingRequest struct {
+	Enabled *bool `json:"enabled" binding:"required" example:"true"`
+}
+
+type MagicLinkSettingResponse struct {
+	Enabled   bool       `json:"enabled" example:"true"`
+	EnabledAt *time.Time `json:"enabled_at,omitempty"`
+}
+
+// SetMagicLinkLogin 設定是否允許以登入連結登入
+// @Summary 設定免密碼登入連結
+// @Description 由會員自行選擇是否允許以寄到信箱的登入連結免密碼登入，預設不允許。停用後尚未使用的連結隨即失效。不接受 API key 與模擬登入。需設定 MAGIC_LINK_LOGIN_ENABLED
+// @Tags 用戶
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param setting body MagicLinkSettingRequest true "是否允許"
+// @Success 200 {object} MagicLinkSettingResponse "設定成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "用戶不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /profile/magic-link [put]
+func SetMagicLinkLogin(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req MagicLinkSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := services.NewMemberService(db).SetMagicLinkLogin(c.Request.Context(), memberID, *req.Enabled)
+	if err != nil {
+		if errors.Is(err, services.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用戶不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MagicLinkSettingResponse{
+		Enabled:   member.MagicLinkEnabledAt != nil,
+		EnabledAt: member.MagicLinkEnabledAt,
+	})
+}
+
+// RequestMagicLink 申請免密碼登入連結
+// @Summary 申請免密碼登入連結
+// @Description 寄送 15 分鐘內有效、只能使用一次的登入連結到會員的電子郵件，只寄給已選擇使用登入連結的會員。無論該電子郵件是否已註冊或是否已選擇使用都返回相同結果。需設定 MAGIC_LINK_LOGIN_ENABLED
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param link body MagicLinkRequest true "會員電子郵件"
+// @Success 200 {object} map[string]string "已受理"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /login/link [post]
+func RequestMagicLink(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	if err := services.NewMemberService(db).RequestMagicLink(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "若該電子郵件已註冊並啟用登入連結，我們已寄出登入連結"})
+}
+
+// MagicLinkLogin 以登入連結登入
+// @Summary 以登入連結登入
+// @Description 使用登入連結中的 token 登入，返回與密碼登入相同的 JWT token、refresh token 和用戶信息；連結使用後即失效，且視為已驗證電子郵件；會員已停用登入連結時連結無效。已啟用兩步驟驗證的會員需再以 mfa_token 呼叫 /login/mfa。需設定 MAGIC_LINK_LOGIN_ENABLED
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param login body MagicLinkLoginRequest true "登入連結中的 token"
+// @Success 200 {object} AuthResponse "登入成功"
+// @Success 202 {object} MFAChallengeResponse "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "連結無效、已過期或已使用"
+// @Failure 423 {object} map[string]string "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /login/link/verify [post]
+func MagicLinkLogin(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	member, err := services.NewMemberService(db).ConsumeMagicLink(c.Request.Context(), req.Token)
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrMagicLinkInvalid):
+			loginThrottle.Failure(clientIP)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	loginThrottle.Reset(clientIP)
+
+	// 登入連結只證明持有信箱，已啟用兩步驟驗證時仍需第二因素
+	if member.TOTPEnabledAt != nil {
+		newMFAChallengeResponse(c, member.ID, member.Email)
+		return
+	}
+
+	resp, err := newAuthResponse(c, member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
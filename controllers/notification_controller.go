@@ -0,0 +1,444 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"member_API/models"
+	"member_API/notify"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationsResponse is a page of the current member's notifications.
+type NotificationsResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	Total         int64                 `json:"total" example:"12"`
+	// Unread is the number of unread notifications still in the inbox, regardless of the filters
+	Unread int64 `json:"unread" example:"3"`
+	Limit  int   `json:"limit" example:"50"`
+	Offset int   `json:"offset" example:"0"`
+}
+
+// UnreadCountResponse contains the number of unread notifications in the inbox.
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread" example:"3"`
+}
+
+// MarkAllReadResponse contains the number of notifications marked as read.
+type MarkAllReadResponse struct {
+	Updated int64 `json:"updated" example:"3"`
+}
+
+// SendNotificationRequest represents the request body for sending a notification to a member.
+type SendNotificationRequest struct {
+	// Type names the event; defaults to admin.message
+	Type    string                 `json:"type" binding:"max=64" example:"admin.message"`
+	Title   string                 `json:"title" binding:"required,max=200" example:"系統維護通知"`
+	Body    string                 `json:"body" binding:"max=10000" example:"系統將於今晚 23:00 進行維護"`
+	Payload map[string]interface{} `json:"payload"`
+	// Urgent notifications are delivered immediately even during the member's quiet hours
+	Urgent bool `json:"urgent" example:"false"`
+}
+
+// SendNotificationResponse contains the ID of the queued notification.
+type SendNotificationResponse struct {
+	MessageID string `json:"message_id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+}
+
+// parseBoolQuery 解析選填的布林查詢參數，未提供時回傳 nil
+func parseBoolQuery(c *gin.Context, key string) (*bool, error) {
+	raw, ok := c.GetQuery(key)
+	if !ok || raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// notificationParams 取得目前會員與路徑中的通知 ID，失敗時已寫入回應
+func notificationParams(c *gin.Context) (uint, uint, bool) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return 0, 0, false
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的通知 ID"})
+		return 0, 0, false
+	}
+	return memberID, uint(id), true
+}
+
+// respondNotificationError 將 NotificationService 的錯誤轉為 HTTP 回應
+func respondNotificationError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// ListNotifications lists the current member's notifications.
+// @Summary 列出通知
+// @Description 列出目前會員的通知（新到舊）。預設只列出收件匣中（未封存）的通知，archived=true 列出已封存的通知
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "true 只列出未讀，false 只列出已讀"
+// @Param archived query bool false "true 只列出已封存的通知" default(false)
+// @Param type query string false "通知類型，例如 account.password_changed"
+// @Param limit query int false "限制返回數量" default(50) minimum(1) maximum(100)
+// @Param offset query int false "偏移量" default(0) minimum(0)
+// @Success 200 {object} NotificationsResponse "通知列表"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications [get]
+func ListNotifications(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	unread, err := parseBoolQuery(c, "unread")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unread 必須為 true 或 false"})
+		return
+	}
+	archived, err := parseBoolQuery(c, "archived")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archived 必須為 true 或 false"})
+		return
+	}
+	if archived == nil {
+		inbox := false
+		archived = &inbox
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit > 100 {
+		limit = 100
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	svc := services.NewNotificationService(db)
+	notifications, total, err := svc.List(c.Request.Context(), memberID, services.NotificationFilter{
+		Unread:   unread,
+		Archived: archived,
+		Type:     c.Query("type"),
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	count, err := svc.UnreadCount(c.Request.Context(), memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationsResponse{
+		Notifications: notifications,
+		Total:         total,
+		Unread:        count,
+		Limit:         limit,
+		Offset:        offset,
+	})
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications.
+// @Summary 未讀通知數量
+// @Description 回傳收件匣中（未封存）的未讀通知數量，供顯示通知徽章
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} UnreadCountResponse "未讀數量"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/unread-count [get]
+func GetUnreadNotificationCount(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	count, err := services.NewNotificationService(db).UnreadCount(c.Request.Context(), memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, UnreadCountResponse{Unread: count})
+}
+
+// GetNotification returns a single notification.
+// @Summary 取得通知
+// @Description 取得目前會員的單一通知，不會自動標記為已讀
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} models.Notification "通知"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id} [get]
+func GetNotification(c *gin.Context) {
+	memberID, id, ok := notificationParams(c)
+	if !ok {
+		return
+	}
+
+	notification, err := services.NewNotificationService(db).Get(c.Request.Context(), memberID, id)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkNotificationRead marks a notification as read.
+// @Summary 標記通知為已讀
+// @Description 將目前會員的通知標記為已讀，已讀的通知不做任何變更
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} models.Notification "更新後的通知"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id}/read [post]
+func MarkNotificationRead(c *gin.Context) {
+	setNotificationRead(c, true)
+}
+
+// MarkNotificationUnread marks a notification as unread.
+// @Summary 標記通知為未讀
+// @Description 將目前會員的通知標記為未讀，未讀的通知不做任何變更
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} models.Notification "更新後的通知"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id}/read [delete]
+func MarkNotificationUnread(c *gin.Context) {
+	setNotificationRead(c, false)
+}
+
+func setNotificationRead(c *gin.Context, read bool) {
+	memberID, id, ok := notificationParams(c)
+	if !ok {
+		return
+	}
+
+	notification, err := services.NewNotificationService(db).MarkRead(c.Request.Context(), memberID, id, read)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks every unread notification as read.
+// @Summary 全部標記為已讀
+// @Description 將目前會員所有未讀的通知（包含已封存的）標記為已讀
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} MarkAllReadResponse "更新的數量"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/read-all [post]
+func MarkAllNotificationsRead(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	updated, err := services.NewNotificationService(db).MarkAllRead(c.Request.Context(), memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MarkAllReadResponse{Updated: updated})
+}
+
+// ArchiveNotification moves a notification out of the inbox.
+// @Summary 封存通知
+// @Description 將目前會員的通知移出收件匣，可透過 archived=true 查詢
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} models.Notification "更新後的通知"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id}/archive [post]
+func ArchiveNotification(c *gin.Context) {
+	setNotificationArchived(c, true)
+}
+
+// UnarchiveNotification moves an archived notification back to the inbox.
+// @Summary 取消封存通知
+// @Description 將已封存的通知移回收件匣
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} models.Notification "更新後的通知"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id}/archive [delete]
+func UnarchiveNotification(c *gin.Context) {
+	setNotificationArchived(c, false)
+}
+
+func setNotificationArchived(c *gin.Context, archived bool) {
+	memberID, id, ok := notificationParams(c)
+	if !ok {
+		return
+	}
+
+	notification, err := services.NewNotificationService(db).Archive(c.Request.Context(), memberID, id, archived)
+	if err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// DeleteNotification deletes a notification.
+// @Summary 刪除通知
+// @Description 刪除目前會員的通知（軟刪除）
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知 ID" example(1)
+// @Success 200 {object} map[string]string "刪除成功"
+// @Failure 400 {object} map[string]string "無效的通知 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "通知不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notifications/{id} [delete]
+func DeleteNotification(c *gin.Context) {
+	memberID, id, ok := notificationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := services.NewNotificationService(db).Delete(c.Request.Context(), memberID, id); err != nil {
+		respondNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "通知已刪除"})
+}
+
+// SendNotification sends a notification to a member over every eligible channel.
+// @Summary 傳送通知給會員
+// @Description 將通知排入佇列，由背景 worker 透過會員符合條件的每個管道（站內收件匣、已驗證的 email、webhook）傳送，僅限管理員。各管道分別重試，個別管道失敗不影響其他管道。會員關閉的通知類型不會傳送；會員的勿擾時段內，email 與 webhook 延後到時段結束才傳送，urgent 為 true 時立即傳送
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "會員 ID" example(1)
+// @Param notification body SendNotificationRequest true "通知內容"
+// @Success 202 {object} SendNotificationResponse "已排入佇列"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/members/{id}/notifications [post]
+func SendNotification(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	memberID, ok := parseMemberID(c)
+	if !ok {
+		return
+	}
+
+	var req SendNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Type == "" {
+		req.Type = "admin.message"
+	}
+
+	messageID, err := services.NewNotificationService(db).Queue(c.Request.Context(), memberID, notify.Message{
+		Type:    req.Type,
+		Title:   req.Title,
+		Body:    req.Body,
+		Payload: req.Payload,
+		Urgent:  req.Urgent,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrMemberNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, SendNotificationResponse{MessageID: messageID})
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateNotificationPreferencesRequest represents the request body for updating notification preferences.
+type UpdateNotificationPreferencesRequest struct {
+	// Locale of notifications and emails; unchanged when empty
+	Locale string `json:"locale" example:"en"`
+	// Timezone is an IANA time zone name used for quiet hours; unchanged when empty
+	Timezone    string                                  `json:"timezone" example:"Asia/Taipei"`
+	Preferences []services.NotificationPreferenceUpdate `json:"preferences" binding:"dive"`
+}
+
+// QuietHoursRequest represents the request body for setting quiet hours.
+type QuietHoursRequest struct {
+	// Start and End are HH:MM in the member's timezone; End before Start spans midnight
+	Start string `json:"start" binding:"required" example:"22:00"`
+	End   string `json:"end" binding:"required" example:"07:00"`
+	// Timezone is an IANA time zone name; unchanged when empty
+	Timezone string `json:"timezone" example:"Asia/Taipei"`
+}
+
+// NotificationPreferencesResponse contains the member's preferences and the configurable notification types.
+type NotificationPreferencesResponse struct {
+	*services.NotificationPreferences
+	Locales []string `json:"locales" example:"zh-TW,en"`
+}
+
+// respondPreferenceError maps notification preference service errors to HTTP responses.
+func respondPreferenceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownNotificationType),
+		errors.Is(err, services.ErrUnknownChannel),
+		errors.Is(err, services.ErrMandatoryNotification),
+		errors.Is(err, services.ErrUnsupportedLocale),
+		errors.Is(err, services.ErrInvalidTimezone),
+		errors.Is(err, services.ErrInvalidQuietHours):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// preferencesResponse 回傳偏好設定，失敗時寫入錯誤回應
+func preferencesResponse(c *gin.Context, prefs *services.NotificationPreferences, err error) {
+	if err != nil {
+		respondPreferenceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, NotificationPreferencesResponse{NotificationPreferences: prefs, Locales: services.SupportedLocales})
+}
+
+// preferenceMember 取得目前會員，失敗時已寫入回應
+func preferenceMember(c *gin.Context) (uint, bool) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return 0, false
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return 0, false
+	}
+	return memberID, true
+}
+
+// GetNotificationPreferences returns the current member's notification preferences.
+// @Summary 取得通知偏好
+// @Description 取得目前會員每種通知類型在各管道（inbox、email、webhook）是否接收、語系、時區與勿擾時段。mandatory 的類型（帳號安全通知）無法關閉；default_enabled 為 false 的類型需要會員選擇接收
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse "通知偏好"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notification-preferences [get]
+func GetNotificationPreferences(c *gin.Context) {
+	memberID, ok := preferenceMember(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := services.NewNotificationPreferenceService(db).Get(c.Request.Context(), memberID)
+	preferencesResponse(c, prefs, err)
+}
+
+// UpdateNotificationPreferences updates the current member's notification preferences.
+// @Summary 變更通知偏好
+// @Description 變更目前會員的語系、時區，以及通知類型在各管道是否接收；只變更列出的類型與管道。關閉 mandatory 的類型時拒絕整個請求
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preferences body UpdateNotificationPreferencesRequest true "通知偏好"
+// @Success 200 {object} NotificationPreferencesResponse "變更後的通知偏好"
+// @Failure 400 {object} map[string]string "請求參數錯誤、未知的類型或管道、無法關閉的類型、不支援的語系或無效的時區"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notification-preferences [put]
+func UpdateNotificationPreferences(c *gin.Context) {
+	memberID, ok := preferenceMember(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := services.NewNotificationPreferenceService(db).Update(c.Request.Context(), memberID, req.Locale, req.Timezone, req.Preferences)
+	preferencesResponse(c, prefs, err)
+}
+
+// SetQuietHours sets the current member's quiet hours.
+// @Summary 設定勿擾時段
+// @Description 設定目前會員的勿擾時段（會員時區的 HH:MM，結束早於開始表示跨越午夜）。時段內非緊急的 email 與 webhook 通知延後到時段結束才傳送，站內收件匣不受影響
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param quiet_hours body QuietHoursRequest true "勿擾時段"
+// @Success 200 {object} NotificationPreferencesResponse "變更後的通知偏好"
+// @Failure 400 {object} map[string]string "請求參數錯誤、時間格式錯誤或無效的時區"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notification-preferences/quiet-hours [put]
+func SetQuietHours(c *gin.Context) {
+	memberID, ok := preferenceMember(c)
+	if !ok {
+		return
+	}
+
+	var req QuietHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	hours, err := services.ParseQuietHours(req.Start, req.End)
+	if err != nil {
+		respondPreferenceError(c, err)
+		return
+	}
+
+	prefs, err := services.NewNotificationPreferenceService(db).SetQuietHours(c.Request.Context(), memberID, &hours, req.Timezone)
+	preferencesResponse(c, prefs, err)
+}
+
+// ClearQuietHours removes the current member's quiet hours.
+// @Summary 取消勿擾時段
+// @Description 取消目前會員的勿擾時段，之後的通知立即傳送
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse "變更後的通知偏好"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "會員不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /notification-preferences/quiet-hours [delete]
+func ClearQuietHours(c *gin.Context) {
+	memberID, ok := preferenceMember(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := services.NewNotificationPreferenceService(db).SetQuietHours(c.Request.Context(), memberID, nil, "")
+	preferencesResponse(c, prefs, err)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"member_API/notify"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationTemplatesResponse lists the current templates and the events that support them.
+type NotificationTemplatesResponse struct {
+	Templates []services.NotificationTemplateEntry `json:"templates"`
+	Events    []services.NotificationEvent         `json:"events"`
+	Locales   []string                             `json:"locales" example:"zh-TW,en"`
+}
+
+// PreviewNotificationTemplateRequest represents the request body for previewing a template.
+type PreviewNotificationTemplateRequest struct {
+	Type    string `json:"type" binding:"required" example:"account.password_changed"`
+	Channel string `json:"channel" binding:"required" example:"email"`
+	Locale  string `json:"locale" binding:"required" example:"zh-TW"`
+	// Template is rendered instead of the current template when provided
+	Template *notify.Template `json:"template"`
+	// Data overrides the event's sample data
+	Data map[string]interface{} `json:"data"`
+}
+
+// templateKeyParam builds the template key from the :type, :channel and :locale path parameters.
+func templateKeyParam(c *gin.Context) notify.TemplateKey {
+	return notify.TemplateKey{
+		Type:    c.Param("type"),
+		Channel: c.Param("channel"),
+		Locale:  c.Param("locale"),
+	}
+}
+
+// respondTemplateError maps notification template service errors to HTTP responses.
+func respondTemplateError(c *gin.Context, err error) {
+	var templateErr *notify.TemplateError
+	switch {
+	case errors.As(err, &templateErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownNotificationEvent),
+		errors.Is(err, services.ErrUnsupportedChannel),
+		errors.Is(err, services.ErrUnsupportedLocale),
+		errors.Is(err, services.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ListNotificationTemplates lists the current notification templates.
+// @Summary 列出通知範本
+// @Description 列出每個事件、管道與語系目前使用的範本（內建或自訂），以及各事件範本可使用的變數與範例資料，僅限管理員
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationTemplatesResponse "範本列表"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/notification-templates [get]
+func ListNotificationTemplates(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	templates, err := services.NewNotificationTemplateService(db).List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationTemplatesResponse{
+		Templates: templates,
+		Events:    services.NotificationEvents(),
+		Locales:   services.SupportedLocales,
+	})
+}
+
+// UpdateNotificationTemplate overrides the built-in template of an event, channel and locale.
+// @Summary 自訂通知範本
+// @Description 以自訂範本取代內建範本，僅限管理員。subject 與 text 使用 text/template 語法，html 使用 html/template 語法（只用於 email，可留空；其他管道不可提供），引用事件沒有提供的變數時拒絕儲存
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "事件類型" example(account.password_changed)
+// @Param channel path string true "管道" example(email)
+// @Param locale path string true "語系" example(zh-TW)
+// @Param template body notify.Template true "範本"
+// @Success 200 {object} services.NotificationTemplateEntry "儲存成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤、範本語法錯誤或引用未知的變數"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "事件不支援範本、管道或語系"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/notification-templates/{type}/{channel}/{locale} [put]
+func UpdateNotificationTemplate(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	var req notify.Template
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := services.NewNotificationTemplateService(db).Save(c.Request.Context(), templateKeyParam(c), req, currentUserID(c))
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// ResetNotificationTemplate removes a custom template so the built-in template is used again.
+// @Summary 還原內建通知範本
+// @Description 刪除自訂範本，改回使用內建範本，僅限管理員
+// @Tags 管理
+// @Produce json
+// @Security BearerAuth
+// @Param type path string true "事件類型" example(account.password_changed)
+// @Param channel path string true "管道" example(email)
+// @Param locale path string true "語系" example(zh-TW)
+// @Success 200 {object} map[string]string "已還原"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "沒有自訂範本，或事件不支援範本、管道或語系"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/notification-templates/{type}/{channel}/{locale} [delete]
+func ResetNotificationTemplate(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	if err := services.NewNotificationTemplateService(db).Reset(c.Request.Context(), templateKeyParam(c)); err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "template reset to default"})
+}
+
+// PreviewNotificationTemplate renders a template with the event's sample data.
+// @Summary 預覽通知範本
+// @Description 以事件的範例資料渲染範本，僅限管理員。提供 template 時預覽尚未儲存的範本（同樣會檢查語法與變數），否則預覽目前使用的範本；data 可取代範例資料中的變數
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param preview body PreviewNotificationTemplateRequest true "預覽條件"
+// @Success 200 {object} notify.Rendered "渲染結果"
+// @Failure 400 {object} map[string]string "請求參數錯誤、範本語法錯誤、引用未知的變數或渲染失敗"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "事件不支援範本、管道或語系"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /admin/notification-templates/preview [post]
+func PreviewNotificationTemplate(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "database connection not configured"})
+		return
+	}
+
+	var req PreviewNotificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rendered, err := services.NewNotificationTemplateService(db).Preview(c.Request.Context(), notify.TemplateKey{
+		Type:    req.Type,
+		Channel: req.Channel,
+		Locale:  req.Locale,
+	}, req.Template, req.Data)
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
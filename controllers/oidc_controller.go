@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"member_API/oidc"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCProviderInfo describes an external identity provider available for login.
+type OIDCProviderInfo struct {
+	Name     string `json:"name" example:"corp"`
+	LoginURL string `json:"login_url" example:"/api/v1/oidc/corp/login"`
+}
+
+var (
+	// oidcProviders 以名稱索引的外部 IdP
+	oidcProviders map[string]*oidc.Provider
+	// oidcStates 授權請求的 state、nonce 與 PKCE verifier
+	oidcStates oidc.StateStore
+)
+
+// SetupOIDC 設定可用的外部 IdP 與授權請求狀態的儲存方式
+func SetupOIDC(providers map[string]*oidc.Provider, states oidc.StateStore) {
+	oidcProviders = providers
+	oidcStates = states
+}
+
+// GetOIDCProviders 列出可用的外部登入
+// @Summary 列出外部登入
+// @Description 列出已設定的 OpenID Connect 身分提供者及其登入網址
+// @Tags 外部登入
+// @Produce json
+// @Success 200 {array} OIDCProviderInfo "外部登入清單"
+// @Router /oidc/providers [get]
+func GetOIDCProviders(c *gin.Context) {
+	providers := make([]OIDCProviderInfo, 0, len(oidcProviders))
+	for name := range oidcProviders {
+		providers = append(providers, OIDCProviderInfo{Name: name, LoginURL: "/api/v1/oidc/" + name + "/login"})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	c.JSON(http.StatusOK, providers)
+}
+
+// OIDCLogin 導向外部 IdP 登入
+// @Summary 外部登入
+// @Description 產生 state、nonce 與 PKCE verifier 後導向身分提供者的授權頁面（authorization code + PKCE）
+// @Tags 外部登入
+// @Param provider path string true "身分提供者名稱"
+// @Success 302 "導向身分提供者"
+// @Failure 404 {object} map[string]string "身分提供者不存在"
+// @Failure 502 {object} map[string]string "無法取得身分提供者設定"
+// @Router /oidc/{provider}/login [get]
+func OIDCLogin(c *gin.Context) {
+	provider, ok := oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "身分提供者不存在"})
+		return
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	nonce, err := oidc.GenerateNonce()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "無法取得身分提供者設定"})
+		return
+	}
+
+	if err := oidcStates.Save(c.Request.Context(), state, oidc.AuthState{
+		Provider: provider.Name,
+		Verifier: verifier,
+		Nonce:    nonce,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 外部 IdP 登入回呼
+// @Summary 外部登入回呼
+// @Description 身分提供者導回後以授權碼換取並驗證 ID token。外部帳號已連結時直接登入；provider 開啟自動連結且 IdP 已驗證的 email 與既有會員相同時自動連結（管理員、工作人員與已啟用兩步驟驗證的會員除外）；provider 開啟自動建立時為新使用者建立會員
+// @Tags 外部登入
+// @Produce json
+// @Param provider path string true "身分提供者名稱"
+// @Param code query string true "授權碼"
+// @Param state query string true "登入時產生的 state"
+// @Success 200 {object} AuthResponse "登入成功"
+// @Success 202 {object} MFAChallengeResponse "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入"
+// @Failure 400 {object} map[string]string "state 無效或已過期，或身分提供者回傳錯誤"
+// @Failure 401 {object} map[string]string "ID token 驗證失敗"
+// @Failure 403 {object} map[string]string "email 網域不允許或外部帳號尚未連結會員"
+// @Failure 404 {object} map[string]string "身分提供者不存在"
+// @Failure 423 {object} map[string]string "帳號暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	provider, ok := oidcProviders[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "身分提供者不存在"})
+		return
+	}
+
+	if idpError := c.Query("error"); idpError != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "身分提供者回傳錯誤：" + idpError})
+		return
+	}
+
+	// state 只能使用一次，且必須屬於同一個 provider
+	state, err := oidcStates.Take(c.Request.Context(), c.Query("state"))
+	if err != nil || state.Provider != provider.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "登入請求無效或已過期，請重新登入"})
+		return
+	}
+
+	claims, err := provider.Authenticate(c.Request.Context(), c.Query("code"), state)
+	if err != nil {
+		switch {
+		case errors.Is(err, oidc.ErrDomainNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": "此 email 網域不允許登入"})
+		case errors.Is(err, oidc.ErrInvalidIDToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ID token 驗證失敗"})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "無法向身分提供者完成登入"})
+		}
+		return
+	}
+
+	member, err := services.NewMemberService(db).LoginWithIdentity(c.Request.Context(), services.ExternalIdentity{
+		Provider:      provider.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, services.IdentityLoginOptions{
+		AutoLink:      provider.AutoLink,
+		AutoProvision: provider.AutoProvision,
+	})
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrIdentityNotLinked):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// 外部登入不取代本服務的兩步驟驗證
+	if member.TOTPEnabledAt != nil {
+		newMFAChallengeResponse(c, member.ID, member.Email)
+		return
+	}
+
+	resp, err := newAuthResponse(c, member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"member_API/services"
+	"member_API/webauthn"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// passkeyRP 驗證 passkey 註冊與登入的 relying party（nil 表示停用）
+	passkeyRP *webauthn.RelyingParty
+	// passkeySessions 進行中的 passkey 註冊與登入
+	passkeySessions webauthn.SessionStore
+)
+
+// SetupWebAuthn 設定 passkey 的 relying party 與 ceremony 的儲存方式
+func SetupWebAuthn(rp *webauthn.RelyingParty, sessions webauthn.SessionStore) {
+	passkeyRP = rp
+	passkeySessions = sessions
+}
+
+// PasskeyCreationOptionsResponse is passed to navigator.credentials.create().
+type PasskeyCreationOptionsResponse struct {
+	PublicKey *webauthn.CreationOptions `json:"publicKey"`
+}
+
+// PasskeyRequestOptionsResponse is passed to navigator.credentials.get().
+type PasskeyRequestOptionsResponse struct {
+	PublicKey *webauthn.RequestOptions `json:"publicKey"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	Name string `json:"name" binding:"max=100" example:"MacBook Touch ID"`
+	// Credential is the PublicKeyCredential returned by navigator.credentials.create(), serialised with toJSON()
+	Credential webauthn.AttestationResponse `json:"credential"`
+}
+
+type BeginPasskeyLoginRequest struct {
+	// Email limits the login to this member's passkeys; omit it to let the authenticator offer its passkeys
+	Email string `json:"email" binding:"omitempty,email" example:"admin@example.com"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	// Credential is the PublicKeyCredential returned by navigator.credentials.get(), serialised with toJSON()
+	Credential webauthn.AssertionResponse `json:"credential"`
+}
+
+// passkeysEnabled 未設定 relying party 時回傳 404
+func passkeysEnabled(c *gin.Context) bool {
+	if passkeyRP == nil || passkeySessions == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未啟用 passkey"})
+		return false
+	}
+	return true
+}
+
+// takePasskeySession 以 clientDataJSON 中的 challenge 取回進行中的 ceremony，challenge 只能使用一次
+func takePasskeySession(c *gin.Context, clientDataJSON []byte) (*webauthn.SessionData, bool) {
+	challenge, err := webauthn.Challenge(clientDataJSON)
+	if err == nil {
+		var session *webauthn.SessionData
+		if session, err = passkeySessions.Take(c.Request.Context(), challenge); err == nil {
+			return session, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "passkey 請求無效或已過期，請重新開始"})
+	return nil, false
+}
+
+// BeginPasskeyRegistration 開始註冊 passkey
+// @Summary 開始註冊 passkey
+// @Description 產生 navigator.credentials.create() 的選項，需要使用者驗證（PIN 或生物辨識）。已註冊的認證器會被排除。不接受 API key 與模擬登入
+// @Tags Passkey
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} PasskeyCreationOptionsResponse "註冊選項"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "未啟用 passkey"
+// @Failure 409 {object} map[string]string "passkey 數量已達上限"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /passkeys/register/begin [post]
+func BeginPasskeyRegistration(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+	if !passkeysEnabled(c) {
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	options, session, err := services.NewPasskeyService(db).BeginRegistration(passkeyRP, memberID)
+	if err != nil {
+		if errors.Is(err, services.ErrPasskeyLimitReached) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := passkeySessions.Save(c.Request.Context(), *session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PasskeyCreationOptionsResponse{PublicKey: options})
+}
+
+// FinishPasskeyRegistration 完成註冊 passkey
+// @Summary 完成註冊 passkey
+// @Description 驗證認證器的回應（challenge、origin、RP ID 與使用者驗證）後保存 passkey。不接受 API key 與模擬登入
+// @Tags Passkey
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param passkey body FinishPasskeyRegistrationRequest true "名稱與 navigator.credentials.create() 的結果"
+// @Success 201 {object} models.WebAuthnCredential "註冊成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤、請求已過期或驗證失敗"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "未啟用 passkey"
+// @Failure 409 {object} map[string]string "此認證器已註冊或 passkey 數量已達上限"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /passkeys/register/finish [post]
+func FinishPasskeyRegistration(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+	if !passkeysEnabled(c) {
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, ok := takePasskeySession(c, req.Credential.Response.ClientDataJSON)
+	if !ok {
+		return
+	}
+
+	credential, err := services.NewPasskeyService(db).FinishRegistration(passkeyRP, session, memberID, req.Name, &req.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasskeyInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrPasskeyInvalid.Error()})
+		case errors.Is(err, services.ErrPasskeyExists), errors.Is(err, services.ErrPasskeyLimitReached):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, credential)
+}
+
+// ListPasskeys 列出 passkey
+// @Summary 列出 passkey
+// @Description 列出目前會員已註冊的 passkey，包含最後使用時間。不接受 API key 與模擬登入
+// @Tags Passkey
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebAuthnCredential "passkey 列表"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /passkeys [get]
+func ListPasskeys(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	credentials, err := services.NewPasskeyService(db).List(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// DeletePasskey 刪除 passkey
+// @Summary 刪除 passkey
+// @Description 刪除目前會員的 passkey，之後無法再以該認證器登入。不接受 API key 與模擬登入
+// @Tags Passkey
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "passkey ID" example(1)
+// @Success 200 {object} map[string]string "刪除成功"
+// @Failure 400 {object} map[string]string "無效的 passkey ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 404 {object} map[string]string "passkey 不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /passkeys/{id} [delete]
+func DeletePasskey(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的 passkey ID"})
+		return
+	}
+
+	if err := services.NewPasskeyService(db).Delete(memberID, uint(id)); err != nil {
+		if errors.Is(err, services.ErrPasskeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "passkey 已刪除"})
+}
+
+// BeginPasskeyLogin 開始以 passkey 登入
+// @Summary 開始以 passkey 登入
+// @Description 產生 navigator.credentials.get() 的選項。提供 email 時只允許該會員的 passkey，否則由認證器列出可用的 passkey
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param login body BeginPasskeyLoginRequest false "會員電子郵件（可省略）"
+// @Success 200 {object} PasskeyRequestOptionsResponse "登入選項"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 404 {object} map[string]string "未啟用 passkey"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /login/passkey/begin [post]
+func BeginPasskeyLogin(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+	if !passkeysEnabled(c) {
+		return
+	}
+
+	var req BeginPasskeyLoginRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if wait := loginThrottle.RetryAfter(c.ClientIP()); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	options, session, err := services.NewPasskeyService(db).BeginLogin(c.Request.Context(), passkeyRP, req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := passkeySessions.Save(c.Request.Context(), *session); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, PasskeyRequestOptionsResponse{PublicKey: options})
+}
+
+// FinishPasskeyLogin 以 passkey 登入
+// @Summary 以 passkey 登入
+// @Description 驗證認證器的簽章後返回與密碼登入相同的 JWT token、refresh token 和用戶信息。passkey 經過使用者驗證，視為通過兩步驟驗證，不需再輸入 TOTP 驗證碼
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param login body FinishPasskeyLoginRequest true "navigator.credentials.get() 的結果"
+// @Success 200 {object} AuthResponse "登入成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤或請求已過期"
+// @Failure 401 {object} map[string]string "passkey 驗證失敗"
+// @Failure 404 {object} map[string]string "未啟用 passkey"
+// @Failure 423 {object} map[string]string "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /login/passkey/finish [post]
+func FinishPasskeyLogin(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+	if !passkeysEnabled(c) {
+		return
+	}
+
+	var req FinishPasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	session, ok := takePasskeySession(c, req.Credential.Response.ClientDataJSON)
+	if !ok {
+		return
+	}
+
+	svc := services.NewPasskeyService(db)
+	member, err := svc.FinishLogin(c.Request.Context(), passkeyRP, session, &req.Credential)
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrPasskeyInvalid):
+			loginThrottle.Failure(clientIP)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrPasskeyInvalid.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	loginThrottle.Reset(clientIP)
+
+	resp, err := issueAuthResponse(c, member, services.NewSessionService(db).StartPasskey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
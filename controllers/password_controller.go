@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"member_API/auth"
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordPolicyResponse is returned when a password violates the password policy.
+type PasswordPolicyResponse struct {
+	Error      string                 `json:"error" example:"密碼長度至少需要 8 個字元"`
+	Violations []auth.PolicyViolation `json:"violations"`
+}
+
+// respondPasswordPolicyError 密碼不符合規則時回傳 400 與所有違反的規則
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, PasswordPolicyResponse{Error: policyErr.Error(), Violations: policyErr.Violations})
+	return true
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"q9C1bZ0x..."`
+	Password string `json:"password" binding:"required" example:"newpassword123"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"oldpassword123"`
+	NewPassword     string `json:"new_password" binding:"required" example:"newpassword123"`
+}
+
+// ChangePasswordResponse reports how many other devices were signed out.
+type ChangePasswordResponse struct {
+	Message         string `json:"message" example:"密碼已變更"`
+	RevokedSessions int    `json:"revoked_sessions" example:"2"`
+}
+
+// ForgotPassword 申請重設密碼
+// @Summary 申請重設密碼
+// @Description 寄送重設密碼連結到會員的電子郵件。無論該電子郵件是否已註冊都返回相同結果
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param forgot body ForgotPasswordRequest true "會員電子郵件"
+// @Success 200 {object} map[string]string "已受理"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewMemberService(db).RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "若該電子郵件已註冊，我們已寄出重設密碼信"})
+}
+
+// ResetPassword 重設密碼
+// @Summary 重設密碼
+// @Description 使用重設密碼信中的 token 設定新密碼。token 只能使用一次，成功後會撤銷該會員所有已簽發的 token
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param reset body ResetPasswordRequest true "重設密碼 token 與新密碼"
+// @Success 200 {object} map[string]string "重設成功"
+// @Failure 400 {object} PasswordPolicyResponse "請求參數錯誤、連結無效或已過期，或新密碼不符合密碼規則"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /password/reset [post]
+func ResetPassword(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewMemberService(db).ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrActionTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "密碼已重設，請使用新密碼登入"})
+}
+
+// ChangePassword 變更密碼
+// @Summary 變更密碼
+// @Description 以目前的密碼驗證後設定新密碼，目前裝置以外的所有登入工作階段會被登出，並寄送通知信。不接受 API key 與模擬登入
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param change body ChangePasswordRequest true "目前的密碼與新密碼"
+// @Success 200 {object} ChangePasswordResponse "變更成功"
+// @Failure 400 {object} PasswordPolicyResponse "請求參數錯誤、目前的密碼錯誤、尚未設定密碼，或新密碼不符合密碼規則"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不接受 API key 或模擬登入"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /password/change [post]
+func ChangePassword(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	revoked, err := services.NewMemberService(db).ChangePassword(c.Request.Context(), uint(claims.UserID), claims.SessionID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrCurrentPasswordIncorrect) || errors.Is(err, services.ErrPasswordNotSet) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ChangePasswordResponse{Message: "密碼已變更", RevokedSessions: revoked})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -139,7 +140,7 @@ func GetProductByID(c *gin.Context) {
 	svc := services.NewProductService(productDB)
 	product, err := svc.GetProductByID(uint(productID))
 	if err != nil {
-		if err.Error() == "產品不存在" {
+		if errors.Is(err, services.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 			return
 		}
@@ -170,6 +171,7 @@ func GetProductByID(c *gin.Context) {
 // @Success 201 {object} map[string]ProductResponse "創建成功"
 // @Failure 400 {object} map[string]string "請求參數錯誤"
 // @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "email 尚未驗證（啟用 REQUIRE_VERIFIED_EMAIL 時）"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /product [post]
 func CreateProduct(c *gin.Context) {
@@ -185,13 +187,7 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	// 獲取當前用戶 ID（從 JWT token 中）
-	userID, exists := c.Get("user_id")
-	var creatorID uint
-	if exists {
-		if id, ok := userID.(uint); ok {
-			creatorID = id
-		}
-	}
+	creatorID := currentUserID(c)
 
 	// 使用 Service 層
 	svc := services.NewProductService(productDB)
@@ -223,7 +219,7 @@ func CreateProduct(c *gin.Context) {
 
 // UpdateProduct updates an existing product in the database.
 // @Summary 更新產品
-// @Description 根據產品 ID 更新產品信息，需要 JWT 認證
+// @Description 根據產品 ID 更新產品信息，需要 JWT 認證；僅產品建立者或具備 product:update 權限者可更新
 // @Tags 產品
 // @Accept json
 // @Produce json
@@ -233,6 +229,7 @@ func CreateProduct(c *gin.Context) {
 // @Success 200 {object} map[string]ProductResponse "更新成功"
 // @Failure 400 {object} map[string]string "請求參數錯誤"
 // @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "不是產品建立者且權限不足，或 email 尚未驗證"
 // @Failure 404 {object} map[string]string "產品不存在"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /product/{id} [put]
@@ -254,15 +251,6 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// 獲取當前用戶 ID
-	userID, exists := c.Get("user_id")
-	var modifierID uint
-	if exists {
-		if id, ok := userID.(uint); ok {
-			modifierID = id
-		}
-	}
-
 	// 構建更新欄位
 	updates := make(map[string]interface{})
 	if req.ProductName != nil {
@@ -283,12 +271,16 @@ func UpdateProduct(c *gin.Context) {
 
 	// 使用 Service 層
 	svc := services.NewProductService(productDB)
-	product, err := svc.UpdateProduct(uint(productID), updates, modifierID)
+	product, err := svc.UpdateProduct(currentIdentity(c), uint(productID), updates)
 	if err != nil {
-		if err.Error() == "產品不存在" {
+		if errors.Is(err, services.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 			return
 		}
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -308,7 +300,7 @@ func UpdateProduct(c *gin.Context) {
 
 // DeleteProduct soft deletes a product by ID from the database.
 // @Summary 刪除產品
-// @Description 根據產品 ID 軟刪除產品，需要 JWT 認證
+// @Description 根據產品 ID 軟刪除產品，需要 JWT 認證；僅產品建立者或具備 product:delete 權限者可刪除
 // @Tags 產品
 // @Accept json
 // @Produce json
@@ -317,6 +309,7 @@ func UpdateProduct(c *gin.Context) {
 // @Success 200 {object} map[string]string "刪除成功"
 // @Failure 400 {object} map[string]string "無效的產品 ID"
 // @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足或 email 尚未驗證"
 // @Failure 404 {object} map[string]string "產品不存在"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /product/{id} [delete]
@@ -332,22 +325,17 @@ func DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	// 獲取當前用戶 ID
-	userID, exists := c.Get("user_id")
-	var deleterID uint
-	if exists {
-		if id, ok := userID.(uint); ok {
-			deleterID = id
-		}
-	}
-
 	// 使用 Service 層
 	svc := services.NewProductService(productDB)
-	if err := svc.DeleteProduct(uint(productID), deleterID); err != nil {
-		if err.Error() == "產品不存在或已被刪除" {
+	if err := svc.DeleteProduct(currentIdentity(c), uint(productID)); err != nil {
+		if errors.Is(err, services.ErrProductNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
 			return
 		}
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionResponse describes a device the member is logged in on.
+type SessionResponse struct {
+	ID         uint      `json:"id" example:"12"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"`
+	IP         string    `json:"ip" example:"203.0.113.9"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Passkey marks sessions that were logged in with a passkey
+	Passkey bool `json:"passkey" example:"false"`
+	// Current marks the session the request was made from
+	Current bool `json:"current" example:"true"`
+}
+
+// RevokeSessionsResponse reports how many sessions were revoked.
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked" example:"3"`
+}
+
+// ListSessions 列出登入中的裝置
+// @Summary 列出登入中的裝置
+// @Description 列出目前會員所有有效的登入工作階段（裝置），包含 User-Agent、IP、登入時間與最後使用時間
+// @Tags 工作階段
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} SessionResponse "工作階段列表"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /sessions [get]
+func ListSessions(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	sessions, err := services.NewSessionService(db).List(uint(claims.UserID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreationTime,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Passkey:    session.Passkey,
+			Current:    session.ID == claims.SessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeSession 登出指定裝置
+// @Summary 登出指定裝置
+// @Description 撤銷目前會員的單一工作階段，該裝置的 access token 與 refresh token 立即失效
+// @Tags 工作階段
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "工作階段 ID" example(12)
+// @Success 200 {object} map[string]string "撤銷成功"
+// @Failure 400 {object} map[string]string "無效的工作階段 ID"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 404 {object} map[string]string "工作階段不存在"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, strconv.IntSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的工作階段 ID"})
+		return
+	}
+
+	if err := services.NewSessionService(db).Revoke(c.Request.Context(), memberID, uint(sessionID)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "工作階段已撤銷"})
+}
+
+// RevokeAllSessions 登出所有裝置
+// @Summary 登出所有裝置
+// @Description 撤銷目前會員所有的工作階段。except_current=true 時保留發出此請求的工作階段（登出其他裝置）
+// @Tags 工作階段
+// @Produce json
+// @Security BearerAuth
+// @Param except_current query bool false "保留目前的工作階段"
+// @Success 200 {object} RevokeSessionsResponse "撤銷成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /sessions [delete]
+func RevokeAllSessions(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	claims := currentClaims(c)
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var except uint
+	if value := c.Query("except_current"); value != "" {
+		keep, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "except_current 必須為布林值"})
+			return
+		}
+		if keep {
+			except = claims.SessionID
+		}
+	}
+
+	revoked, err := services.NewSessionService(db).RevokeAll(c.Request.Context(), uint(claims.UserID), except)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, RevokeSessionsResponse{Revoked: revoked})
+}
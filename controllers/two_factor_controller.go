@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"member_API/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAChallengeResponse is returned by Login when the member has two-factor authentication enabled.
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresIn   int    `json:"expires_in" example:"300"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/Member%20API:user@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Member+API"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TOTPConfirmResponse contains the one-time recovery codes and fresh tokens issued after enabling 2FA.
+type TOTPConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"abcd-efgh-ijkl-mnop"`
+	AuthResponse
+}
+
+type DisableTOTPRequest struct {
+	// Password 尚未設定密碼（以外部帳號建立）的會員可省略
+	Password string `json:"password" example:"password123"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// newMFAChallengeResponse 簽發兩步驟登入第二階段使用的 token
+func newMFAChallengeResponse(c *gin.Context, memberID uint, email string) {
+	token, err := services.IssueMFAChallenge(memberID, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+	c.JSON(http.StatusAccepted, MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(services.MFAChallengeTTL.Seconds()),
+	})
+}
+
+// LoginMFA 兩步驟登入第二階段
+// @Summary 兩步驟驗證登入
+// @Description 使用登入時取得的 mfa_token 與驗證器 App 的 6 位數驗證碼（或一組復原碼）完成登入。錯誤的驗證碼會計入登入失敗次數
+// @Tags 認證
+// @Accept json
+// @Produce json
+// @Param login body MFALoginRequest true "mfa_token 與驗證碼"
+// @Success 200 {object} AuthResponse "登入成功"
+// @Failure 400 {object} map[string]string "請求參數錯誤"
+// @Failure 401 {object} map[string]string "驗證碼錯誤或 mfa_token 已過期"
+// @Failure 423 {object} map[string]string "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /login/mfa [post]
+func LoginMFA(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "登入嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	member, err := services.NewMemberService(db).CompleteMFAChallenge(c.Request.Context(), req.MFAToken, req.Code, accountLockout)
+	if err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			loginThrottle.Failure(clientIP)
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrInvalidMFACode):
+			loginThrottle.Failure(clientIP)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMFAChallengeInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	loginThrottle.Reset(clientIP)
+
+	resp, err := newAuthResponse(c, member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// EnrollTOTP 申請兩步驟驗證
+// @Summary 申請兩步驟驗證
+// @Description 產生新的 TOTP 金鑰與 otpauth URI（可轉為 QR code 供驗證器 App 掃描），需再呼叫確認端點才會啟用
+// @Tags 兩步驟驗證
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TOTPEnrollResponse "金鑰已產生"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 409 {object} map[string]string "已啟用兩步驟驗證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /2fa/totp/enroll [post]
+func EnrollTOTP(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	secret, uri, err := services.NewMemberService(db).EnrollTOTP(c.Request.Context(), memberID)
+	if err != nil {
+		if errors.Is(err, services.ErrTOTPAlreadyEnabled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPEnrollResponse{Secret: secret, OtpauthURI: uri})
+}
+
+// ConfirmTOTP 確認並啟用兩步驟驗證
+// @Summary 啟用兩步驟驗證
+// @Description 以驗證器 App 顯示的驗證碼確認金鑰並啟用兩步驟驗證。回傳只會顯示一次的復原碼；先前簽發的所有 token 都會被撤銷，並回傳新的 token
+// @Tags 兩步驟驗證
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param confirm body TOTPCodeRequest true "驗證碼"
+// @Success 200 {object} TOTPConfirmResponse "已啟用"
+// @Failure 400 {object} map[string]string "請求參數錯誤、驗證碼錯誤或尚未申請"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 409 {object} map[string]string "已啟用兩步驟驗證"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /2fa/totp/confirm [post]
+func ConfirmTOTP(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := services.NewMemberService(db)
+	codes, err := svc.ConfirmTOTP(c.Request.Context(), memberID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTOTPAlreadyEnabled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	member, err := svc.GetMemberByID(memberID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := newAuthResponse(c, member)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token 生成失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPConfirmResponse{RecoveryCodes: codes, AuthResponse: *resp})
+}
+
+// DisableTOTP 停用兩步驟驗證
+// @Summary 停用兩步驟驗證
+// @Description 以目前密碼加上驗證碼（或一組復原碼）停用兩步驟驗證，剩餘的復原碼會一併刪除。尚未設定密碼（以外部帳號建立）的會員只需要驗證碼或復原碼。錯誤的密碼或驗證碼會計入登入失敗次數
+// @Tags 兩步驟驗證
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param disable body DisableTOTPRequest true "密碼與驗證碼"
+// @Success 200 {object} map[string]string "已停用"
+// @Failure 400 {object} map[string]string "請求參數錯誤、密碼或驗證碼錯誤，或尚未啟用"
+// @Failure 401 {object} map[string]string "未認證"
+// @Failure 423 {object} map[string]string "帳號因連續驗證失敗暫時鎖定，Retry-After 標頭為需等待的秒數"
+// @Failure 429 {object} map[string]string "來源 IP 驗證失敗次數過多，Retry-After 標頭為需等待的秒數"
+// @Failure 500 {object} map[string]string "服務器錯誤"
+// @Router /2fa/totp/disable [post]
+func DisableTOTP(c *gin.Context) {
+	if db == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據庫連接未配置"})
+		return
+	}
+
+	memberID := currentUserID(c)
+	if memberID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未認證"})
+		return
+	}
+
+	var req DisableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	if wait := loginThrottle.RetryAfter(clientIP); wait > 0 {
+		setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "驗證嘗試次數過多，請稍後再試"})
+		return
+	}
+
+	if err := services.NewMemberService(db).DisableTOTP(c.Request.Context(), memberID, req.Password, req.Code, accountLockout); err != nil {
+		var locked *services.AccountLockedError
+		switch {
+		case errors.As(err, &locked):
+			loginThrottle.Failure(clientIP)
+			setRetryAfter(c, time.Until(locked.Until))
+			c.JSON(http.StatusLocked, gin.H{"error": locked.Error()})
+		case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrInvalidMFACode):
+			loginThrottle.Failure(clientIP)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	loginThrottle.Reset(clientIP)
+
+	c.JSON(http.StatusOK, gin.H{"message": "已停用兩步驟驗證"})
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"member_API/auth"
 	"member_API/models"
+	"member_API/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,6 +27,35 @@ type User struct {
 	Email string `json:"email" example:"user@example.com"`
 }
 
+// currentUserID returns the authenticated member ID stored by auth.AuthMiddleware.
+func currentUserID(c *gin.Context) uint {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0
+	}
+	id, ok := value.(int64)
+	if !ok || id <= 0 {
+		return 0
+	}
+	return uint(id)
+}
+
+// currentIdentity returns the authenticated identity stored by auth.AuthMiddleware, or nil.
+func currentIdentity(c *gin.Context) *auth.Identity {
+	identity, _ := auth.IdentityFromContext(c.Request.Context())
+	return identity
+}
+
+// currentClaims returns the validated token claims stored by auth.AuthMiddleware.
+func currentClaims(c *gin.Context) *auth.Claims {
+	value, exists := c.Get("token_claims")
+	if !exists {
+		return nil
+	}
+	claims, _ := value.(*auth.Claims)
+	return claims
+}
+
 // GetUsers returns a small collection of users from the database.
 // @Summary 獲取所有會員
 // @Description 獲取會員列表，最多返回 50 條記錄，需要 JWT 認證
@@ -105,9 +136,9 @@ func GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user": User{ID: int64(member.ID), Name: member.Name, Email: member.Email}})
 }
 
-// DeleteUserByID deletes a user by ID from the database.
+// DeleteUserByID soft deletes a user by ID from the database.
 // @Summary 刪除會員
-// @Description 根據會員 ID 刪除會員，需要 JWT 認證
+// @Description 根據會員 ID 軟刪除會員，需要 JWT 認證及 member:delete 權限。會員的 token、工作階段與 API key 會一併撤銷
 // @Tags 用戶
 // @Accept json
 // @Produce json
@@ -116,6 +147,8 @@ func GetUserByID(c *gin.Context) {
 // @Success 200 {object} map[string]string "刪除成功"
 // @Failure 400 {object} map[string]string "無效的會員 ID"
 // @Failure 401 {object} map[string]string "未認證"
+// @Failure 403 {object} map[string]string "權限不足"
+// @Failure 404 {object} map[string]string "會員不存在"
 // @Failure 500 {object} map[string]string "服務器錯誤"
 // @Router /user/{id} [delete]
 func DeleteUserByID(c *gin.Context) {
@@ -130,8 +163,16 @@ func DeleteUserByID(c *gin.Context) {
 		return
 	}
 
-	if err := db.WithContext(c.Request.Context()).Delete(&models.Member{}, memberID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	svc := services.NewMemberService(db.WithContext(c.Request.Context()))
+	if err := svc.DeleteMember(currentIdentity(c), uint(memberID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrMemberNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -25,35 +25,34 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
+        "/.well-known/jwks.json": {
             "get": {
-                "description": "檢查服務器狀態和數據庫連接狀態",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "以 JWKS 格式公開目前用於驗證 token 的公鑰（包含輪替重疊期內的舊金鑰）。使用 HS256 共享密鑰時 keys 為空陣列",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "系統"
+                    "認證"
                 ],
-                "summary": "健康檢查",
+                "summary": "獲取 JWT 驗證公鑰",
                 "responses": {
                     "200": {
-                        "description": "服務正常",
+                        "description": "JWKS",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/auth.JWKSet"
                         }
                     }
                 }
             }
         },
-        "/login": {
+        "/2fa/totp/confirm": {
             "post": {
-                "description": "用戶登入，驗證郵件和密碼後返回 JWT token 和用戶信息",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以驗證器 App 顯示的驗證碼確認金鑰並啟用兩步驟驗證。回傳只會顯示一次的復原碼；先前簽發的所有 token 都會被撤銷，並回傳新的 token",
                 "consumes": [
                     "application/json"
                 ],
@@ -61,29 +60,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "認證"
+                    "兩步驟驗證"
                 ],
-                "summary": "用戶登入",
+                "summary": "啟用兩步驟驗證",
                 "parameters": [
                     {
-                        "description": "登入信息",
-                        "name": "login",
+                        "description": "驗證碼",
+                        "name": "confirm",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.LoginRequest"
+                            "$ref": "#/definitions/controllers.TOTPCodeRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "登入成功",
+                        "description": "已啟用",
                         "schema": {
-                            "$ref": "#/definitions/controllers.AuthResponse"
+                            "$ref": "#/definitions/controllers.TOTPConfirmResponse"
                         }
                     },
                     "400": {
-                        "description": "請求參數錯誤",
+                        "description": "請求參數錯誤、驗證碼錯誤或尚未申請",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -92,7 +91,16 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "電子郵件或密碼錯誤",
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "已啟用兩步驟驗證",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -112,14 +120,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/product": {
+        "/2fa/totp/disable": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "創建新產品，需要 JWT 認證",
+                "description": "以目前密碼加上驗證碼（或一組復原碼）停用兩步驟驗證，剩餘的復原碼會一併刪除。尚未設定密碼（以外部帳號建立）的會員只需要驗證碼或復原碼。錯誤的密碼或驗證碼會計入登入失敗次數",
                 "consumes": [
                     "application/json"
                 ],
@@ -127,32 +135,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "產品"
+                    "兩步驟驗證"
                 ],
-                "summary": "創建產品",
+                "summary": "停用兩步驟驗證",
                 "parameters": [
                     {
-                        "description": "產品信息",
-                        "name": "product",
+                        "description": "密碼與驗證碼",
+                        "name": "disable",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.CreateProductRequest"
+                            "$ref": "#/definitions/controllers.DisableTOTPRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "創建成功",
+                    "200": {
+                        "description": "已停用",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/controllers.ProductResponse"
+                                "type": "string"
                             }
                         }
                     },
                     "400": {
-                        "description": "請求參數錯誤",
+                        "description": "請求參數錯誤、密碼或驗證碼錯誤，或尚未啟用",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -169,6 +177,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "423": {
+                        "description": "帳號因連續驗證失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 驗證失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "服務器錯誤",
                         "schema": {
@@ -181,52 +207,116 @@ const docTemplate = `{
                 }
             }
         },
-        "/product/{id}": {
-            "get": {
+        "/2fa/totp/enroll": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據產品 ID 獲取單個產品的詳細信息，需要 JWT 認證",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "產生新的 TOTP 金鑰與 otpauth URI（可轉為 QR code 供驗證器 App 掃描），需再呼叫確認端點才會啟用",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "產品"
-                ],
-                "summary": "根據 ID 獲取產品",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "example": 1,
-                        "description": "產品 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "兩步驟驗證"
                 ],
+                "summary": "申請兩步驟驗證",
                 "responses": {
                     "200": {
-                        "description": "獲取成功",
+                        "description": "金鑰已產生",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TOTPEnrollResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/controllers.ProductResponse"
+                                "type": "string"
                             }
                         }
                     },
-                    "400": {
-                        "description": "無效的產品 ID",
+                    "409": {
+                        "description": "已啟用兩步驟驗證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    }
+                }
+            }
+        },
+        "/admin/audit-logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "依動作、操作者或目標會員查詢稽核紀錄（新到舊），僅限管理員",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "查詢稽核紀錄",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "動作，例如 impersonation.start",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "操作者會員 ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目標會員 ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "限制返回數量",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "稽核紀錄",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuditLogsResponse"
+                        }
                     },
                     "401": {
                         "description": "未認證",
@@ -237,8 +327,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "產品不存在",
+                    "403": {
+                        "description": "權限不足",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -256,14 +346,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/members/{id}/impersonate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據產品 ID 更新產品信息，需要 JWT 認證",
+                "description": "簽發代表指定會員的短期 token（15 分鐘），供客服重現會員看到的畫面，僅限管理員且不接受 API key。\ntoken 帶有 act claim 記錄實際操作的管理員，只能執行讀取操作；不可模擬自己或其他管理員。開始與結束皆寫入稽核紀錄",
                 "consumes": [
                     "application/json"
                 ],
@@ -271,40 +363,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "產品"
+                    "管理"
                 ],
-                "summary": "更新產品",
+                "summary": "模擬會員登入",
                 "parameters": [
                     {
                         "type": "integer",
-                        "example": 1,
-                        "description": "產品 ID",
+                        "example": 2,
+                        "description": "會員 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "要更新的產品信息",
-                        "name": "product",
+                        "description": "模擬原因",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.UpdateProductRequest"
+                            "$ref": "#/definitions/controllers.ImpersonateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "模擬 token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "$ref": "#/definitions/controllers.ProductResponse"
-                            }
+                            "$ref": "#/definitions/controllers.ImpersonationResponse"
                         }
                     },
                     "400": {
-                        "description": "請求參數錯誤",
+                        "description": "請求參數錯誤或不可模擬自己",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -321,8 +410,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "權限不足或目標為管理員",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
-                        "description": "產品不存在",
+                        "description": "會員不存在",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -340,14 +438,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/members/{id}/notifications": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據產品 ID 軟刪除產品，需要 JWT 認證",
+                "description": "將通知排入佇列，由背景 worker 透過會員符合條件的每個管道（站內收件匣、已驗證的 email、webhook）傳送，僅限管理員。各管道分別重試，個別管道失敗不影響其他管道。會員關閉的通知類型不會傳送；會員的勿擾時段內，email 與 webhook 延後到時段結束才傳送，urgent 為 true 時立即傳送",
                 "consumes": [
                     "application/json"
                 ],
@@ -355,22 +455,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "產品"
+                    "管理"
                 ],
-                "summary": "刪除產品",
+                "summary": "傳送通知給會員",
                 "parameters": [
                     {
                         "type": "integer",
                         "example": 1,
-                        "description": "產品 ID",
+                        "description": "會員 ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "通知內容",
+                        "name": "notification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SendNotificationRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "刪除成功",
+                    "202": {
+                        "description": "已排入佇列",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SendNotificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -378,8 +493,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "無效的產品 ID",
+                    "401": {
+                        "description": "未認證",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -387,8 +502,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "未認證",
+                    "403": {
+                        "description": "權限不足",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -397,7 +512,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "產品不存在",
+                        "description": "會員不存在",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -417,14 +532,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
-            "get": {
+        "/admin/members/{id}/revoke-tokens": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "獲取產品列表，最多返回 100 條記錄，需要 JWT 認證",
+                "description": "撤銷指定會員目前所有的 access token 與 refresh token，強制其重新登入，僅限管理員",
                 "consumes": [
                     "application/json"
                 ],
@@ -432,34 +547,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "產品"
+                    "管理"
                 ],
-                "summary": "獲取所有產品",
+                "summary": "撤銷會員所有 token",
                 "parameters": [
                     {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 50,
-                        "description": "限制返回數量",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "minimum": 0,
                         "type": "integer",
-                        "default": 0,
-                        "description": "偏移量",
-                        "name": "offset",
-                        "in": "query"
+                        "example": 1,
+                        "description": "會員 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "獲取成功",
+                        "description": "撤銷成功",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的會員 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "401": {
@@ -471,26 +588,44 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "服務器錯誤",
+                    "403": {
+                        "description": "權限不足",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
         },
-        "/profile": {
+        "/admin/members/{id}/roles": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "獲取當前登入用戶的詳細信息，需要 JWT 認證",
+                "description": "獲取指定會員的角色列表，僅限管理員",
                 "consumes": [
                     "application/json"
                 ],
@@ -498,16 +633,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用戶"
+                    "管理"
+                ],
+                "summary": "獲取會員角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "會員 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "獲取當前用戶信息",
                 "responses": {
                     "200": {
                         "description": "獲取成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的會員 ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/controllers.User"
+                                "type": "string"
                             }
                         }
                     },
@@ -520,8 +671,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
-                        "description": "用戶不存在",
+                        "description": "會員不存在",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -539,11 +699,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/register": {
+            },
             "post": {
-                "description": "註冊新用戶，返回 JWT token 和用戶信息",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "授予指定會員角色（admin 或 staff），僅限管理員。會員現有的 token 會一併撤銷，重新登入後生效",
                 "consumes": [
                     "application/json"
                 ],
@@ -551,29 +714,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "認證"
+                    "管理"
                 ],
-                "summary": "用戶註冊",
+                "summary": "授予會員角色",
                 "parameters": [
                     {
-                        "description": "註冊信息",
-                        "name": "register",
+                        "type": "integer",
+                        "example": 1,
+                        "description": "會員 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "角色",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.RegisterRequest"
+                            "$ref": "#/definitions/controllers.RoleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "註冊成功",
+                    "200": {
+                        "description": "授予成功",
                         "schema": {
-                            "$ref": "#/definitions/controllers.AuthResponse"
+                            "$ref": "#/definitions/controllers.RolesResponse"
                         }
                     },
                     "400": {
-                        "description": "請求參數錯誤",
+                        "description": "請求參數錯誤或無效的角色",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -581,8 +752,26 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "409": {
-                        "description": "該電子郵件已被註冊",
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -602,14 +791,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/{id}": {
-            "get": {
+        "/admin/members/{id}/roles/{role}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據會員 ID 獲取單個會員的詳細信息，需要 JWT 認證",
+                "description": "撤銷指定會員的角色，僅限管理員。會員現有的 token 會一併撤銷，重新登入後生效",
                 "consumes": [
                     "application/json"
                 ],
@@ -617,9 +806,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用戶"
+                    "管理"
                 ],
-                "summary": "根據 ID 獲取會員",
+                "summary": "撤銷會員角色",
                 "parameters": [
                     {
                         "type": "integer",
@@ -628,15 +817,29 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "staff",
+                        "description": "角色",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "獲取成功",
+                        "description": "撤銷成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RolesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的角色",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/controllers.User"
+                                "type": "string"
                             }
                         }
                     },
@@ -649,6 +852,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "404": {
                         "description": "會員不存在",
                         "schema": {
@@ -668,14 +880,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/members/{id}/unlock": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "根據會員 ID 刪除會員，需要 JWT 認證",
+                "description": "清除指定會員的登入失敗次數並解除暫時鎖定，僅限管理員",
                 "consumes": [
                     "application/json"
                 ],
@@ -683,9 +897,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用戶"
+                    "管理"
                 ],
-                "summary": "刪除會員",
+                "summary": "解除會員登入鎖定",
                 "parameters": [
                     {
                         "type": "integer",
@@ -698,7 +912,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "刪除成功",
+                        "description": "解除成功",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -724,6 +938,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "服務器錯誤",
                         "schema": {
@@ -736,14 +968,66 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/admin/notification-templates": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "獲取會員列表，最多返回 50 條記錄，需要 JWT 認證",
+                "description": "列出每個事件、管道與語系目前使用的範本（內建或自訂），以及各事件範本可使用的變數與範例資料，僅限管理員",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "列出通知範本",
+                "responses": {
+                    "200": {
+                        "description": "範本列表",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationTemplatesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/notification-templates/preview": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以事件的範例資料渲染範本，僅限管理員。提供 template 時預覽尚未儲存的範本（同樣會檢查語法與變數），否則預覽目前使用的範本；data 可取代範例資料中的變數",
                 "consumes": [
                     "application/json"
                 ],
@@ -751,19 +1035,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用戶"
+                    "管理"
+                ],
+                "summary": "預覽通知範本",
+                "parameters": [
+                    {
+                        "description": "預覽條件",
+                        "name": "preview",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PreviewNotificationTemplateRequest"
+                        }
+                    }
                 ],
-                "summary": "獲取所有會員",
                 "responses": {
                     "200": {
-                        "description": "獲取成功",
+                        "description": "渲染結果",
+                        "schema": {
+                            "$ref": "#/definitions/notify.Rendered"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、範本語法錯誤、引用未知的變數或渲染失敗",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/controllers.User"
-                                }
+                                "type": "string"
                             }
                         }
                     },
@@ -776,6 +1074,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "事件不支援範本、管道或語系",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "服務器錯誤",
                         "schema": {
@@ -787,166 +1103,5891 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.AuthResponse": {
-            "type": "object",
-            "properties": {
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
-                },
-                "user": {
-                    "$ref": "#/definitions/controllers.User"
-                }
-            }
         },
-        "controllers.CreateProductRequest": {
-            "type": "object",
-            "required": [
-                "product_name",
-                "product_price",
-                "product_stock"
-            ],
-            "properties": {
-                "product_description": {
-                    "type": "string",
-                    "example": "最新款 iPhone"
-                },
-                "product_image": {
-                    "type": "string",
-                    "example": "https://example.com/image.jpg"
-                },
+        "/admin/notification-templates/{type}/{channel}/{locale}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以自訂範本取代內建範本，僅限管理員。subject 與 text 使用 text/template 語法，html 使用 html/template 語法（只用於 email，可留空；其他管道不可提供），引用事件沒有提供的變數時拒絕儲存",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "自訂通知範本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "account.password_changed",
+                        "description": "事件類型",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "email",
+                        "description": "管道",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "zh-TW",
+                        "description": "語系",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "範本",
+                        "name": "template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/notify.Template"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "儲存成功",
+                        "schema": {
+                            "$ref": "#/definitions/services.NotificationTemplateEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、範本語法錯誤或引用未知的變數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "事件不支援範本、管道或語系",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除自訂範本，改回使用內建範本，僅限管理員",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "還原內建通知範本",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "account.password_changed",
+                        "description": "事件類型",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "email",
+                        "description": "管道",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "zh-TW",
+                        "description": "語系",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已還原",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "沒有自訂範本，或事件不支援範本、管道或語系",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出目前會員的所有 API key（不含明文），包含最後使用時間與來源 IP",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "列出 API key",
+                "responses": {
+                    "200": {
+                        "description": "API key 列表",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不可使用 API key 管理 API key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "建立供程式使用的 API key，以 \"Authorization: ApiKey {key}\" 呼叫 API。明文只會在此回應中顯示一次；權限為會員角色與 scope 的交集（read 允許讀取、write 允許寫入、admin 允許管理端點，或指定單一權限如 member:read）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "建立 API key",
+                "parameters": [
+                    {
+                        "description": "名稱、scope 與有效天數",
+                        "name": "apiKey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "建立成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或無效的 scope",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不可使用 API key 管理 API key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "API key 數量已達上限",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "撤銷目前會員的 API key，撤銷後立即失效",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Key"
+                ],
+                "summary": "撤銷 API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "撤銷成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的 API key ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不可使用 API key 管理 API key",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "API key 不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/email/change": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以目前的密碼驗證後，寄送確認連結到新的電子郵件並通知原信箱；點擊確認連結後才會變更。不接受 API key 與模擬登入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "申請變更電子郵件",
+                "parameters": [
+                    {
+                        "description": "目前的密碼與新的電子郵件",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "已寄出確認信",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、目前的密碼錯誤或新 email 與目前相同",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "email 已被使用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/email/change/confirm": {
+            "post": {
+                "description": "使用寄到新信箱的確認連結中的 token 完成變更，新的電子郵件視為已驗證。變更後需以 refresh token 換發新的 access token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "確認變更電子郵件",
+                "parameters": [
+                    {
+                        "description": "確認信中的 token",
+                        "name": "confirm",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ConfirmEmailChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "變更成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或連結無效、已過期",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "email 已被使用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/email/verify": {
+            "post": {
+                "description": "使用驗證信中的 token 完成電子郵件驗證。驗證後需以 refresh token 換發新的 access token，才能使用需要已驗證 email 的功能",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "驗證電子郵件",
+                "parameters": [
+                    {
+                        "description": "驗證信中的 token",
+                        "name": "verify",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "驗證成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或連結無效、已過期",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/email/verify/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "重新寄送電子郵件驗證信給目前登入的會員",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "重新寄送驗證信",
+                "responses": {
+                    "200": {
+                        "description": "已寄出",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "email 已完成驗證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "檢查服務器狀態和數據庫連接狀態",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "系統"
+                ],
+                "summary": "健康檢查",
+                "responses": {
+                    "200": {
+                        "description": "服務正常",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/impersonation/stop": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以模擬登入 token 呼叫，立即撤銷該 token 並寫入稽核紀錄",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "結束模擬登入",
+                "responses": {
+                    "200": {
+                        "description": "已結束",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "目前不是模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "用戶登入，驗證郵件和密碼後返回 JWT token、refresh token 和用戶信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "用戶登入",
+                "parameters": [
+                    {
+                        "description": "登入信息",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "電子郵件或密碼錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/link": {
+            "post": {
+                "description": "寄送 15 分鐘內有效、只能使用一次的登入連結到會員的電子郵件，只寄給已選擇使用登入連結的會員。無論該電子郵件是否已註冊或是否已選擇使用都返回相同結果。需設定 MAGIC_LINK_LOGIN_ENABLED",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "申請免密碼登入連結",
+                "parameters": [
+                    {
+                        "description": "會員電子郵件",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MagicLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已受理",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/link/verify": {
+            "post": {
+                "description": "使用登入連結中的 token 登入，返回與密碼登入相同的 JWT token、refresh token 和用戶信息；連結使用後即失效，且視為已驗證電子郵件；會員已停用登入連結時連結無效。已啟用兩步驟驗證的會員需再以 mfa_token 呼叫 /login/mfa。需設定 MAGIC_LINK_LOGIN_ENABLED",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "以登入連結登入",
+                "parameters": [
+                    {
+                        "description": "登入連結中的 token",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MagicLinkLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "連結無效、已過期或已使用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa": {
+            "post": {
+                "description": "使用登入時取得的 mfa_token 與驗證器 App 的 6 位數驗證碼（或一組復原碼）完成登入。錯誤的驗證碼會計入登入失敗次數",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "兩步驟驗證登入",
+                "parameters": [
+                    {
+                        "description": "mfa_token 與驗證碼",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MFALoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "驗證碼錯誤或 mfa_token 已過期",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/passkey/begin": {
+            "post": {
+                "description": "產生 navigator.credentials.get() 的選項。提供 email 時只允許該會員的 passkey，否則由認證器列出可用的 passkey",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "開始以 passkey 登入",
+                "parameters": [
+                    {
+                        "description": "會員電子郵件（可省略）",
+                        "name": "login",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.BeginPasskeyLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入選項",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PasskeyRequestOptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "未啟用 passkey",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/login/passkey/finish": {
+            "post": {
+                "description": "驗證認證器的簽章後返回與密碼登入相同的 JWT token、refresh token 和用戶信息。passkey 經過使用者驗證，視為通過兩步驟驗證，不需再輸入 TOTP 驗證碼",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "以 passkey 登入",
+                "parameters": [
+                    {
+                        "description": "navigator.credentials.get() 的結果",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.FinishPasskeyLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或請求已過期",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "passkey 驗證失敗",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "未啟用 passkey",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號因連續登入失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 登入失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "撤銷目前使用的 access token 並結束其工作階段（該登入的 refresh token 一併失效）；若提供 refresh token，其所屬登入也會一併撤銷",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "用戶登出",
+                "parameters": [
+                    {
+                        "description": "要一併撤銷的 refresh token",
+                        "name": "logout",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登出成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notification-preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取得目前會員每種通知類型在各管道（inbox、email、webhook）是否接收、語系、時區與勿擾時段。mandatory 的類型（帳號安全通知）無法關閉；default_enabled 為 false 的類型需要會員選擇接收",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "取得通知偏好",
+                "responses": {
+                    "200": {
+                        "description": "通知偏好",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationPreferencesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "變更目前會員的語系、時區，以及通知類型在各管道是否接收；只變更列出的類型與管道。關閉 mandatory 的類型時拒絕整個請求",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "變更通知偏好",
+                "parameters": [
+                    {
+                        "description": "通知偏好",
+                        "name": "preferences",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateNotificationPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "變更後的通知偏好",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationPreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、未知的類型或管道、無法關閉的類型、不支援的語系或無效的時區",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notification-preferences/quiet-hours": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "設定目前會員的勿擾時段（會員時區的 HH:MM，結束早於開始表示跨越午夜）。時段內非緊急的 email 與 webhook 通知延後到時段結束才傳送，站內收件匣不受影響",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "設定勿擾時段",
+                "parameters": [
+                    {
+                        "description": "勿擾時段",
+                        "name": "quiet_hours",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.QuietHoursRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "變更後的通知偏好",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationPreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、時間格式錯誤或無效的時區",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取消目前會員的勿擾時段，之後的通知立即傳送",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "取消勿擾時段",
+                "responses": {
+                    "200": {
+                        "description": "變更後的通知偏好",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationPreferencesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出目前會員的通知（新到舊）。預設只列出收件匣中（未封存）的通知，archived=true 列出已封存的通知",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "列出通知",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "true 只列出未讀，false 只列出已讀",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "true 只列出已封存的通知",
+                        "name": "archived",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "通知類型，例如 account.password_changed",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "限制返回數量",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "通知列表",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.NotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將目前會員所有未讀的通知（包含已封存的）標記為已讀",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "全部標記為已讀",
+                "responses": {
+                    "200": {
+                        "description": "更新的數量",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MarkAllReadResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/unread-count": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "回傳收件匣中（未封存）的未讀通知數量，供顯示通知徽章",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "未讀通知數量",
+                "responses": {
+                    "200": {
+                        "description": "未讀數量",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UnreadCountResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取得目前會員的單一通知，不會自動標記為已讀",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "取得通知",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "通知",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除目前會員的通知（軟刪除）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "刪除通知",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "刪除成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}/archive": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將目前會員的通知移出收件匣，可透過 archived=true 查詢",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "封存通知",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新後的通知",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將已封存的通知移回收件匣",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "取消封存通知",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新後的通知",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將目前會員的通知標記為已讀，已讀的通知不做任何變更",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "標記通知為已讀",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新後的通知",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "將目前會員的通知標記為未讀，未讀的通知不做任何變更",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "通知"
+                ],
+                "summary": "標記通知為未讀",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "通知 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新後的通知",
+                        "schema": {
+                            "$ref": "#/definitions/models.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的通知 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/providers": {
+            "get": {
+                "description": "列出已設定的 OpenID Connect 身分提供者及其登入網址",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "外部登入"
+                ],
+                "summary": "列出外部登入",
+                "responses": {
+                    "200": {
+                        "description": "外部登入清單",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.OIDCProviderInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/{provider}/callback": {
+            "get": {
+                "description": "身分提供者導回後以授權碼換取並驗證 ID token。外部帳號已連結時直接登入；provider 開啟自動連結且 IdP 已驗證的 email 與既有會員相同時自動連結（管理員、工作人員與已啟用兩步驟驗證的會員除外）；provider 開啟自動建立時為新使用者建立會員",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "外部登入"
+                ],
+                "summary": "外部登入回呼",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "身分提供者名稱",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "授權碼",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "登入時產生的 state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登入成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "已啟用兩步驟驗證，需以 mfa_token 呼叫 /login/mfa 完成登入",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MFAChallengeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "state 無效或已過期，或身分提供者回傳錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "ID token 驗證失敗",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "email 網域不允許或外部帳號尚未連結會員",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "身分提供者不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/{provider}/login": {
+            "get": {
+                "description": "產生 state、nonce 與 PKCE verifier 後導向身分提供者的授權頁面（authorization code + PKCE）",
+                "tags": [
+                    "外部登入"
+                ],
+                "summary": "外部登入",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "身分提供者名稱",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "導向身分提供者"
+                    },
+                    "404": {
+                        "description": "身分提供者不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "502": {
+                        "description": "無法取得身分提供者設定",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出目前會員已註冊的 passkey，包含最後使用時間。不接受 API key 與模擬登入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passkey"
+                ],
+                "summary": "列出 passkey",
+                "responses": {
+                    "200": {
+                        "description": "passkey 列表",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebAuthnCredential"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/register/begin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "產生 navigator.credentials.create() 的選項，需要使用者驗證（PIN 或生物辨識）。已註冊的認證器會被排除。不接受 API key 與模擬登入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passkey"
+                ],
+                "summary": "開始註冊 passkey",
+                "responses": {
+                    "200": {
+                        "description": "註冊選項",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PasskeyCreationOptionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "未啟用 passkey",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "passkey 數量已達上限",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/register/finish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "驗證認證器的回應（challenge、origin、RP ID 與使用者驗證）後保存 passkey。不接受 API key 與模擬登入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passkey"
+                ],
+                "summary": "完成註冊 passkey",
+                "parameters": [
+                    {
+                        "description": "名稱與 navigator.credentials.create() 的結果",
+                        "name": "passkey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.FinishPasskeyRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "註冊成功",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebAuthnCredential"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、請求已過期或驗證失敗",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "未啟用 passkey",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "此認證器已註冊或 passkey 數量已達上限",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/passkeys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "刪除目前會員的 passkey，之後無法再以該認證器登入。不接受 API key 與模擬登入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Passkey"
+                ],
+                "summary": "刪除 passkey",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "passkey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "刪除成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的 passkey ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "passkey 不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/password/change": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以目前的密碼驗證後設定新密碼，目前裝置以外的所有登入工作階段會被登出，並寄送通知信。不接受 API key 與模擬登入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "變更密碼",
+                "parameters": [
+                    {
+                        "description": "目前的密碼與新密碼",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "變更成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ChangePasswordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、目前的密碼錯誤、尚未設定密碼，或新密碼不符合密碼規則",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PasswordPolicyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "寄送重設密碼連結到會員的電子郵件。無論該電子郵件是否已註冊都返回相同結果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "申請重設密碼",
+                "parameters": [
+                    {
+                        "description": "會員電子郵件",
+                        "name": "forgot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已受理",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "使用重設密碼信中的 token 設定新密碼。token 只能使用一次，成功後會撤銷該會員所有已簽發的 token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "重設密碼",
+                "parameters": [
+                    {
+                        "description": "重設密碼 token 與新密碼",
+                        "name": "reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "重設成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤、連結無效或已過期，或新密碼不符合密碼規則",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PasswordPolicyResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/product": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "創建新產品，需要 JWT 認證",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "產品"
+                ],
+                "summary": "創建產品",
+                "parameters": [
+                    {
+                        "description": "產品信息",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "創建成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.ProductResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "email 尚未驗證（啟用 REQUIRE_VERIFIED_EMAIL 時）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據產品 ID 獲取單個產品的詳細信息，需要 JWT 認證",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "產品"
+                ],
+                "summary": "根據 ID 獲取產品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "產品 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "獲取成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.ProductResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的產品 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "產品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據產品 ID 更新產品信息，需要 JWT 認證；僅產品建立者或具備 product:update 權限者可更新",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "產品"
+                ],
+                "summary": "更新產品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "產品 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要更新的產品信息",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UpdateProductRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.ProductResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不是產品建立者且權限不足，或 email 尚未驗證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "產品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據產品 ID 軟刪除產品，需要 JWT 認證；僅產品建立者或具備 product:delete 權限者可刪除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "產品"
+                ],
+                "summary": "刪除產品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "產品 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "刪除成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的產品 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足或 email 尚未驗證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "產品不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取產品列表，最多返回 100 條記錄，需要 JWT 認證",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "產品"
+                ],
+                "summary": "獲取所有產品",
+                "parameters": [
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "限制返回數量",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "獲取成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取當前登入用戶的詳細信息，需要 JWT 認證。以模擬登入 token 呼叫時，回應包含 impersonation 欄位（實際操作的管理員與到期時間），供前端顯示提示橫幅；已申請刪除帳號時包含 deletion_scheduled_at",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "獲取當前用戶信息",
+                "responses": {
+                    "200": {
+                        "description": "獲取成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "用戶不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/deletion": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以目前的密碼驗證後申請刪除帳號，並寄送通知信。錯誤的密碼會計入登入失敗次數。緩衝期（ACCOUNT_DELETION_GRACE_PERIOD）內可取消；到期後個人資料會被匿名化且無法復原，建立的產品與稽核紀錄保留。不接受 API key 與模擬登入",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "申請刪除帳號",
+                "parameters": [
+                    {
+                        "description": "目前的密碼",
+                        "name": "deletion",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.DeleteAccountRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "已排定刪除時間（scheduled_at）",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或目前的密碼錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "已申請刪除帳號",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "帳號因連續驗證失敗暫時鎖定，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "來源 IP 驗證失敗次數過多，Retry-After 標頭為需等待的秒數",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "在緩衝期內取消刪除帳號的申請。不接受 API key 與模擬登入",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "取消刪除帳號",
+                "responses": {
+                    "200": {
+                        "description": "已取消",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "沒有待處理的刪除申請",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "匯出我們保存的所有與目前會員相關的資料：基本資料、角色、連結的外部帳號、登入裝置、API key（不含 key 本身）、建立的產品與相關稽核紀錄。format=zip 時每個區塊為一個 JSON 檔案。不接受 API key 與模擬登入",
+                "produces": [
+                    "application/json",
+                    "application/zip"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "匯出個人資料",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "description": "json（預設）或 zip",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "匯出內容",
+                        "schema": {
+                            "$ref": "#/definitions/services.MemberExport"
+                        }
+                    },
+                    "400": {
+                        "description": "無效的格式",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "用戶不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/profile/magic-link": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "由會員自行選擇是否允許以寄到信箱的登入連結免密碼登入，預設不允許。停用後尚未使用的連結隨即失效。不接受 API key 與模擬登入。需設定 MAGIC_LINK_LOGIN_ENABLED",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "設定免密碼登入連結",
+                "parameters": [
+                    {
+                        "description": "是否允許",
+                        "name": "setting",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MagicLinkSettingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "設定成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MagicLinkSettingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "不接受 API key 或模擬登入",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "用戶不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "註冊新用戶，返回 JWT token、refresh token 和用戶信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "用戶註冊",
+                "parameters": [
+                    {
+                        "description": "註冊信息",
+                        "name": "register",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "註冊成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤或密碼不符合密碼規則",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PasswordPolicyResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "該電子郵件已被註冊",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出目前會員所有有效的登入工作階段（裝置），包含 User-Agent、IP、登入時間與最後使用時間",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作階段"
+                ],
+                "summary": "列出登入中的裝置",
+                "responses": {
+                    "200": {
+                        "description": "工作階段列表",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.SessionResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "撤銷目前會員所有的工作階段。except_current=true 時保留發出此請求的工作階段（登出其他裝置）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作階段"
+                ],
+                "summary": "登出所有裝置",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "保留目前的工作階段",
+                        "name": "except_current",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "撤銷成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RevokeSessionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "撤銷目前會員的單一工作階段，該裝置的 access token 與 refresh token 立即失效",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "工作階段"
+                ],
+                "summary": "登出指定裝置",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 12,
+                        "description": "工作階段 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "撤銷成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的工作階段 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "工作階段不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "使用 refresh token 換發新的 access token 與 refresh token（舊的 refresh token 隨即失效）。重複使用已換發過的 refresh token 會撤銷該登入的所有 refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "認證"
+                ],
+                "summary": "換發 token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "refresh",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "換發成功",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "請求參數錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "refresh token 無效、過期或已被重複使用",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據會員 ID 獲取單個會員的詳細信息，需要 JWT 認證",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "根據 ID 獲取會員",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "會員 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "獲取成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/controllers.User"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "根據會員 ID 軟刪除會員，需要 JWT 認證及 member:delete 權限。會員的 token、工作階段與 API key 會一併撤銷",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "刪除會員",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "會員 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "刪除成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "無效的會員 ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "權限不足",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "會員不存在",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "獲取會員列表，最多返回 50 條記錄，需要 JWT 認證",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用戶"
+                ],
+                "summary": "獲取所有會員",
+                "responses": {
+                    "200": {
+                        "description": "獲取成功",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/controllers.User"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "未認證",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "服務器錯誤",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.JWK"
+                    }
+                }
+            }
+        },
+        "auth.PolicyViolation": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "too_short"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "密碼長度至少需要 8 個字元"
+                }
+            }
+        },
+        "controllers.AuditLogsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditLog"
+                    }
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "controllers.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/controllers.User"
+                }
+            }
+        },
+        "controllers.BeginPasskeyLoginRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email limits the login to this member's passkeys; omit it to let the authenticator offer its passkeys",
+                    "type": "string",
+                    "example": "admin@example.com"
+                }
+            }
+        },
+        "controllers.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "new_email",
+                "password"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string",
+                    "example": "new@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "controllers.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "oldpassword123"
+                },
+                "new_password": {
+                    "type": "string",
+                    "example": "newpassword123"
+                }
+            }
+        },
+        "controllers.ChangePasswordResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "密碼已變更"
+                },
+                "revoked_sessions": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "controllers.ConfirmEmailChangeRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "q9C1bZ0x..."
+                }
+            }
+        },
+        "controllers.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "ExpiresInDays omitted or 0 means the key never expires",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0,
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "nightly-export"
+                },
+                "scopes": {
+                    "description": "Scopes: read, write, admin, or a permission such as member:read",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read"
+                    ]
+                }
+            }
+        },
+        "controllers.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/models.APIKey"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "mk_1a2b3c4d_3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+                }
+            }
+        },
+        "controllers.CreateProductRequest": {
+            "type": "object",
+            "required": [
+                "product_name",
+                "product_price",
+                "product_stock"
+            ],
+            "properties": {
+                "product_description": {
+                    "type": "string",
+                    "example": "最新款 iPhone"
+                },
+                "product_image": {
+                    "type": "string",
+                    "example": "https://example.com/image.jpg"
+                },
+                "product_name": {
+                    "type": "string",
+                    "example": "iPhone 15 Pro"
+                },
+                "product_price": {
+                    "type": "number",
+                    "example": 35900
+                },
+                "product_stock": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 100
+                }
+            }
+        },
+        "controllers.DeleteAccountRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "description": "Password 以外部帳號建立、尚未設定密碼的會員可省略",
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "controllers.DisableTOTPRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "description": "Password 尚未設定密碼（以外部帳號建立）的會員可省略",
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "controllers.FinishPasskeyLoginRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "description": "Credential is the PublicKeyCredential returned by navigator.credentials.get(), serialised with toJSON()",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webauthn.AssertionResponse"
+                        }
+                    ]
+                }
+            }
+        },
+        "controllers.FinishPasskeyRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "description": "Credential is the PublicKeyCredential returned by navigator.credentials.create(), serialised with toJSON()",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/webauthn.AttestationResponse"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "MacBook Touch ID"
+                }
+            }
+        },
+        "controllers.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "controllers.ImpersonateRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Reason is recorded in the audit log, e.g. the support ticket being investigated",
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Ticket #1234: member cannot see their orders"
+                }
+            }
+        },
+        "controllers.ImpersonationResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/controllers.User"
+                }
+            }
+        },
+        "controllers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "password123"
+                }
+            }
+        },
+        "controllers.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+                }
+            }
+        },
+        "controllers.MFAChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "mfa_required": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "controllers.MFALoginRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "controllers.MagicLinkLoginRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "controllers.MagicLinkRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "controllers.MagicLinkSettingRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "controllers.MagicLinkSettingResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "enabled_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.MarkAllReadResponse": {
+            "type": "object",
+            "properties": {
+                "updated": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "controllers.NotificationPreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "example": "zh-TW"
+                },
+                "locales": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "zh-TW",
+                        "en"
+                    ]
+                },
+                "quiet_hours_end": {
+                    "type": "string",
+                    "example": "07:00"
+                },
+                "quiet_hours_start": {
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Taipei"
+                },
+                "types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.NotificationTypePreference"
+                    }
+                }
+            }
+        },
+        "controllers.NotificationTemplatesResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.NotificationEvent"
+                    }
+                },
+                "locales": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "zh-TW",
+                        "en"
+                    ]
+                },
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.NotificationTemplateEntry"
+                    }
+                }
+            }
+        },
+        "controllers.NotificationsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Notification"
+                    }
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "unread": {
+                    "description": "Unread is the number of unread notifications still in the inbox, regardless of the filters",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "controllers.OIDCProviderInfo": {
+            "type": "object",
+            "properties": {
+                "login_url": {
+                    "type": "string",
+                    "example": "/api/v1/oidc/corp/login"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "corp"
+                }
+            }
+        },
+        "controllers.PasskeyCreationOptionsResponse": {
+            "type": "object",
+            "properties": {
+                "publicKey": {
+                    "$ref": "#/definitions/webauthn.CreationOptions"
+                }
+            }
+        },
+        "controllers.PasskeyRequestOptionsResponse": {
+            "type": "object",
+            "properties": {
+                "publicKey": {
+                    "$ref": "#/definitions/webauthn.RequestOptions"
+                }
+            }
+        },
+        "controllers.PasswordPolicyResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "密碼長度至少需要 8 個字元"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.PolicyViolation"
+                    }
+                }
+            }
+        },
+        "controllers.PreviewNotificationTemplateRequest": {
+            "type": "object",
+            "required": [
+                "channel",
+                "locale",
+                "type"
+            ],
+            "properties": {
+                "channel": {
+                    "type": "string",
+                    "example": "email"
+                },
+                "data": {
+                    "description": "Data overrides the event's sample data",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "locale": {
+                    "type": "string",
+                    "example": "zh-TW"
+                },
+                "template": {
+                    "description": "Template is rendered instead of the current template when provided",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/notify.Template"
+                        }
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "example": "account.password_changed"
+                }
+            }
+        },
+        "controllers.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "product_description": {
+                    "type": "string",
+                    "example": "最新款 iPhone"
+                },
+                "product_image": {
+                    "type": "string",
+                    "example": "https://example.com/image.jpg"
+                },
+                "product_name": {
+                    "type": "string",
+                    "example": "iPhone 15 Pro"
+                },
+                "product_price": {
+                    "type": "number",
+                    "example": 35900
+                },
+                "product_stock": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "controllers.QuietHoursRequest": {
+            "type": "object",
+            "required": [
+                "end",
+                "start"
+            ],
+            "properties": {
+                "end": {
+                    "type": "string",
+                    "example": "07:00"
+                },
+                "start": {
+                    "description": "Start and End are HH:MM in the member's timezone; End before Start spans midnight",
+                    "type": "string",
+                    "example": "22:00"
+                },
+                "timezone": {
+                    "description": "Timezone is an IANA time zone name; unchanged when empty",
+                    "type": "string",
+                    "example": "Asia/Taipei"
+                }
+            }
+        },
+        "controllers.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+                }
+            }
+        },
+        "controllers.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "locale": {
+                    "description": "Locale selects the language of emails and notifications; defaults to the Accept-Language header",
+                    "type": "string",
+                    "enum": [
+                        "zh-TW",
+                        "en"
+                    ],
+                    "example": "zh-TW"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "correct-horse-battery"
+                }
+            }
+        },
+        "controllers.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q9C1bZ0x..."
+                }
+            }
+        },
+        "controllers.RevokeSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "controllers.RoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "staff"
+                }
+            }
+        },
+        "controllers.RolesResponse": {
+            "type": "object",
+            "properties": {
+                "member_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "admin",
+                        "member"
+                    ]
+                }
+            }
+        },
+        "controllers.SendNotificationRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string",
+                    "maxLength": 10000,
+                    "example": "系統將於今晚 23:00 進行維護"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "系統維護通知"
+                },
+                "type": {
+                    "description": "Type names the event; defaults to admin.message",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "admin.message"
+                },
+                "urgent": {
+                    "description": "Urgent notifications are delivered immediately even during the member's quiet hours",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "controllers.SendNotificationResponse": {
+            "type": "object",
+            "properties": {
+                "message_id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015"
+                }
+            }
+        },
+        "controllers.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current marks the session the request was made from",
+                    "type": "boolean",
+                    "example": true
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.9"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "passkey": {
+                    "description": "Passkey marks sessions that were logged in with a passkey",
+                    "type": "boolean",
+                    "example": false
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"
+                }
+            }
+        },
+        "controllers.TOTPCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "controllers.TOTPConfirmResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abcd-efgh-ijkl-mnop"
+                    ]
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "3q2-7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/controllers.User"
+                }
+            }
+        },
+        "controllers.TOTPEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Member%20API:user@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP\u0026issuer=Member+API"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "controllers.UnreadCountResponse": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "controllers.UpdateNotificationPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "locale": {
+                    "description": "Locale of notifications and emails; unchanged when empty",
+                    "type": "string",
+                    "example": "en"
+                },
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.NotificationPreferenceUpdate"
+                    }
+                },
+                "timezone": {
+                    "description": "Timezone is an IANA time zone name used for quiet hours; unchanged when empty",
+                    "type": "string",
+                    "example": "Asia/Taipei"
+                }
+            }
+        },
+        "controllers.UpdateProductRequest": {
+            "type": "object",
+            "properties": {
+                "product_description": {
+                    "type": "string",
+                    "example": "更新的描述"
+                },
+                "product_image": {
+                    "type": "string",
+                    "example": "https://example.com/new-image.jpg"
+                },
+                "product_name": {
+                    "type": "string",
+                    "example": "iPhone 15 Pro Max"
+                },
+                "product_price": {
+                    "type": "number",
+                    "example": 42900
+                },
+                "product_stock": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "controllers.User": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "example": "張三"
+                }
+            }
+        },
+        "controllers.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sort": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "sort": {
+                    "type": "integer"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "type": "string"
+                },
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "sort": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationPreference": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "sort": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Product": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "product_description": {
+                    "type": "string"
+                },
+                "product_image": {
+                    "type": "string"
+                },
                 "product_name": {
+                    "type": "string"
+                },
+                "product_price": {
+                    "type": "number"
+                },
+                "product_stock": {
+                    "type": "integer"
+                },
+                "sort": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebAuthnCredential": {
+            "type": "object",
+            "properties": {
+                "aaguid": {
+                    "description": "AAGUID identifies the authenticator model; all zeros when not disclosed.",
+                    "type": "string"
+                },
+                "algorithm": {
+                    "type": "integer"
+                },
+                "backup_eligible": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_modification_time": {
+                    "type": "string"
+                },
+                "last_modifier_id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sort": {
+                    "type": "integer"
+                },
+                "transports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "notify.Rendered": {
+            "type": "object",
+            "properties": {
+                "html": {
+                    "type": "string",
+                    "example": "\u003cp\u003e張三 您好：\u003c/p\u003e"
+                },
+                "subject": {
+                    "type": "string",
+                    "example": "您的密碼已變更"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "張三 您好：您的帳號密碼已於 2025-01-01 12:00 變更。"
+                }
+            }
+        },
+        "notify.Template": {
+            "type": "object",
+            "properties": {
+                "html": {
+                    "type": "string"
+                },
+                "subject": {
                     "type": "string",
-                    "example": "iPhone 15 Pro"
+                    "example": "您的密碼已變更"
                 },
-                "product_price": {
-                    "type": "number",
-                    "example": 35900
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ExportAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
                 },
-                "product_stock": {
-                    "type": "integer",
-                    "minimum": 0,
-                    "example": 100
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "controllers.LoginRequest": {
+        "services.ExportIdentity": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
                 "email": {
-                    "type": "string",
-                    "example": "user@example.com"
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
-                    "example": "password123"
+                "provider": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
                 }
             }
         },
-        "controllers.ProductResponse": {
+        "services.ExportPasskey": {
+            "type": "object",
+            "properties": {
+                "aaguid": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "transports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.ExportProfile": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deletion_scheduled_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verified_at": {
+                    "type": "string"
+                },
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "integer"
                 },
-                "product_description": {
-                    "type": "string",
-                    "example": "最新款 iPhone"
+                "locale": {
+                    "type": "string"
                 },
-                "product_image": {
-                    "type": "string",
-                    "example": "https://example.com/image.jpg"
+                "magic_link_enabled_at": {
+                    "type": "string"
                 },
-                "product_name": {
+                "name": {
+                    "type": "string"
+                },
+                "quiet_hours_end": {
+                    "type": "string"
+                },
+                "quiet_hours_start": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "totp_enabled_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ExportSession": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.MemberExport": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ExportAPIKey"
+                    }
+                },
+                "audit_logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditLog"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ExportIdentity"
+                    }
+                },
+                "notification_preferences": {
+                    "description": "NotificationPreferences 只包含會員變更過的類型與管道",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NotificationPreference"
+                    }
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Notification"
+                    }
+                },
+                "passkeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ExportPasskey"
+                    }
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                },
+                "profile": {
+                    "$ref": "#/definitions/services.ExportProfile"
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ExportSession"
+                    }
+                }
+            }
+        },
+        "services.NotificationChannelPreference": {
+            "type": "object",
+            "properties": {
+                "channel": {
                     "type": "string",
-                    "example": "iPhone 15 Pro"
+                    "example": "email"
                 },
-                "product_price": {
-                    "type": "number",
-                    "example": 35900
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "services.NotificationEvent": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "email"
+                    ]
                 },
-                "product_stock": {
-                    "type": "integer",
-                    "example": 100
+                "sample": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "type": "string",
+                    "example": "account.password_changed"
                 }
             }
         },
-        "controllers.RegisterRequest": {
+        "services.NotificationPreferenceUpdate": {
             "type": "object",
             "required": [
-                "email",
-                "name",
-                "password"
+                "channel",
+                "type"
             ],
             "properties": {
-                "email": {
+                "channel": {
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "email"
                 },
-                "name": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "type": {
                     "type": "string",
-                    "example": "張三"
+                    "example": "announcement"
+                }
+            }
+        },
+        "services.NotificationTemplateEntry": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "type": "string"
                 },
-                "password": {
+                "html": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "overridden": {
+                    "type": "boolean"
+                },
+                "subject": {
                     "type": "string",
-                    "minLength": 6,
-                    "example": "password123"
+                    "example": "您的密碼已變更"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "controllers.UpdateProductRequest": {
+        "services.NotificationTypePreference": {
             "type": "object",
             "properties": {
-                "product_description": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.NotificationChannelPreference"
+                    }
+                },
+                "default_enabled": {
+                    "description": "DefaultEnabled 會員未設定時是否接收；false 表示需要會員選擇接收（opt-in）",
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
                     "type": "string",
-                    "example": "更新的描述"
+                    "example": "管理員訊息"
                 },
-                "product_image": {
+                "mandatory": {
+                    "description": "Mandatory 的通知無法關閉",
+                    "type": "boolean",
+                    "example": false
+                },
+                "type": {
                     "type": "string",
-                    "example": "https://example.com/new-image.jpg"
+                    "example": "admin.message"
+                }
+            }
+        },
+        "webauthn.AssertionResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
                 },
-                "product_name": {
+                "rawId": {
+                    "type": "string"
+                },
+                "response": {
+                    "$ref": "#/definitions/webauthn.AuthenticatorAssertionResponse"
+                },
+                "type": {
                     "type": "string",
-                    "example": "iPhone 15 Pro Max"
+                    "example": "public-key"
+                }
+            }
+        },
+        "webauthn.AttestationResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
                 },
-                "product_price": {
-                    "type": "number",
-                    "example": 42900
+                "rawId": {
+                    "type": "string"
                 },
-                "product_stock": {
-                    "type": "integer",
-                    "example": 50
+                "response": {
+                    "$ref": "#/definitions/webauthn.AuthenticatorAttestationResponse"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "public-key"
                 }
             }
         },
-        "controllers.User": {
+        "webauthn.AuthenticatorAssertionResponse": {
             "type": "object",
             "properties": {
-                "email": {
+                "authenticatorData": {
+                    "type": "string"
+                },
+                "clientDataJSON": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "userHandle": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.AuthenticatorAttestationResponse": {
+            "type": "object",
+            "properties": {
+                "attestationObject": {
+                    "type": "string"
+                },
+                "clientDataJSON": {
+                    "type": "string"
+                },
+                "transports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "webauthn.CreationOptions": {
+            "type": "object",
+            "properties": {
+                "attestation": {
+                    "type": "string"
+                },
+                "authenticatorSelection": {
+                    "$ref": "#/definitions/webauthn.authenticatorSelection"
+                },
+                "challenge": {
+                    "type": "string"
+                },
+                "excludeCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webauthn.CredentialDescriptor"
+                    }
+                },
+                "pubKeyCredParams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webauthn.credentialParameter"
+                    }
+                },
+                "rp": {
+                    "$ref": "#/definitions/webauthn.rpEntity"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/webauthn.userEntity"
+                }
+            }
+        },
+        "webauthn.CredentialDescriptor": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "3q2-7w"
+                },
+                "transports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "public-key"
+                }
+            }
+        },
+        "webauthn.RequestOptions": {
+            "type": "object",
+            "properties": {
+                "allowCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webauthn.CredentialDescriptor"
+                    }
+                },
+                "challenge": {
+                    "type": "string"
+                },
+                "rpId": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "type": "integer"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.authenticatorSelection": {
+            "type": "object",
+            "properties": {
+                "residentKey": {
+                    "type": "string"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.credentialParameter": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "integer"
                 },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.rpEntity": {
+            "type": "object",
+            "properties": {
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "string"
                 },
                 "name": {
-                    "type": "string",
-                    "example": "張三"
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.userEntity": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "API key 認證（供程式使用），格式：ApiKey {key}",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "BearerAuth": {
             "description": "JWT 認證，格式：Bearer {token}",
             "type": "apiKey",
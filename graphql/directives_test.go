@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"member_API/auth"
+	"member_API/graphql/model"
+)
+
+// withOperation returns a context resolving the named field of the given operation type
+func withOperation(ctx context.Context, op ast.Operation, field string) context.Context {
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Operation: op},
+	})
+	return graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Field: graphql.CollectedField{Field: &ast.Field{Name: field}},
+	})
+}
+
+// errorCode returns the extensions code of a GraphQL error, or "" when err is nil
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	gqlErr, ok := err.(*gqlerror.Error)
+	if !ok {
+		return "UNKNOWN"
+	}
+	code, _ := gqlErr.Extensions["code"].(string)
+	return code
+}
+
+func TestDirectives(t *testing.T) {
+	member := &auth.Identity{Claims: &auth.Claims{UserID: 1, Roles: []string{auth.RoleMember}}}
+	verified := &auth.Identity{Claims: &auth.Claims{UserID: 1, Roles: []string{auth.RoleMember}, EmailVerified: true}}
+	staff := &auth.Identity{Claims: &auth.Claims{UserID: 2, Roles: []string{auth.RoleStaff}, EmailVerified: true}}
+	admin := &auth.Identity{Claims: &auth.Claims{UserID: 3, Roles: []string{auth.RoleAdmin}, EmailVerified: true}}
+	adminMFA := &auth.Identity{Claims: &auth.Claims{UserID: 3, Roles: []string{auth.RoleAdmin}, EmailVerified: true, MFA: true}}
+	readKey := &auth.Identity{Claims: &auth.Claims{UserID: 4, Roles: []string{auth.RoleAdmin}}, APIKeyID: 1, Scopes: []string{auth.ScopeRead}}
+	impersonated := &auth.Identity{Claims: &auth.Claims{UserID: 1, Roles: []string{auth.RoleMember}, Actor: &auth.Actor{UserID: 3}}}
+	writeKey := &auth.Identity{Claims: &auth.Claims{UserID: 4, Roles: []string{auth.RoleAdmin}}, APIKeyID: 2, Scopes: []string{auth.ScopeRead, auth.ScopeWrite, string(auth.PermProductDelete)}}
+
+	type directive func(DirectiveRoot, context.Context, graphql.Resolver) (any, error)
+	authOnly := func(d DirectiveRoot, ctx context.Context, next graphql.Resolver) (any, error) {
+		return d.Auth(ctx, nil, next)
+	}
+	hasRole := func(roles ...model.Role) directive {
+		return func(d DirectiveRoot, ctx context.Context, next graphql.Resolver) (any, error) {
+			return d.HasRole(ctx, nil, next, roles)
+		}
+	}
+	hasPermission := func(permission model.Permission) directive {
+		return func(d DirectiveRoot, ctx context.Context, next graphql.Resolver) (any, error) {
+			return d.HasPermission(ctx, nil, next, permission)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		identity  *auth.Identity
+		operation ast.Operation
+		field     string
+		directive directive
+		wantCode  string
+	}{
+		{name: "未登入查詢", operation: ast.Query, directive: authOnly, wantCode: "UNAUTHENTICATED"},
+		{name: "未登入的角色檢查", operation: ast.Mutation, directive: hasRole(model.RoleAdmin), wantCode: "UNAUTHENTICATED"},
+		{name: "會員查詢", identity: member, operation: ast.Query, directive: authOnly},
+		{name: "會員寫入", identity: member, operation: ast.Mutation, directive: authOnly},
+		{name: "要求驗證 email 時未驗證不可寫入產品", opts: Options{RequireVerifiedEmail: true}, identity: member, operation: ast.Mutation, field: "createProduct", directive: authOnly, wantCode: "FORBIDDEN"},
+		{name: "要求驗證 email 時未驗證仍可執行其他 mutation", opts: Options{RequireVerifiedEmail: true}, identity: member, operation: ast.Mutation, field: "updateMember", directive: authOnly},
+		{name: "要求驗證 email 時仍可查詢", opts: Options{RequireVerifiedEmail: true}, identity: member, operation: ast.Query, field: "products", directive: authOnly},
+		{name: "要求驗證 email 時已驗證可寫入產品", opts: Options{RequireVerifiedEmail: true}, identity: verified, operation: ast.Mutation, field: "deleteProduct", directive: authOnly},
+		{name: "會員不是管理員", identity: member, operation: ast.Mutation, directive: hasRole(model.RoleAdmin), wantCode: "FORBIDDEN"},
+		{name: "管理員", identity: admin, operation: ast.Mutation, directive: hasRole(model.RoleAdmin)},
+		{name: "要求兩步驟驗證的管理員", opts: Options{RequireAdminMFA: true}, identity: admin, operation: ast.Mutation, directive: hasRole(model.RoleAdmin), wantCode: "FORBIDDEN"},
+		{name: "已通過兩步驟驗證的管理員", opts: Options{RequireAdminMFA: true}, identity: adminMFA, operation: ast.Mutation, directive: hasRole(model.RoleAdmin)},
+		{name: "任一角色即可", identity: staff, operation: ast.Mutation, directive: hasRole(model.RoleAdmin, model.RoleStaff)},
+		{name: "staff 可刪除產品", identity: staff, operation: ast.Mutation, directive: hasPermission(model.PermissionProductDelete)},
+		{name: "staff 不可刪除會員", identity: staff, operation: ast.Mutation, directive: hasPermission(model.PermissionMemberDelete), wantCode: "FORBIDDEN"},
+		{name: "模擬登入可查詢", identity: impersonated, operation: ast.Query, directive: authOnly},
+		{name: "模擬登入不可寫入", identity: impersonated, operation: ast.Mutation, directive: authOnly, wantCode: "FORBIDDEN"},
+		{name: "read key 可查詢", identity: readKey, operation: ast.Query, directive: authOnly},
+		{name: "read key 不可寫入", identity: readKey, operation: ast.Mutation, directive: authOnly, wantCode: "FORBIDDEN"},
+		{name: "write key 可寫入", identity: writeKey, operation: ast.Mutation, directive: authOnly},
+		{name: "API key 的管理員角色需要 admin scope", identity: writeKey, operation: ast.Mutation, directive: hasRole(model.RoleAdmin), wantCode: "FORBIDDEN"},
+		{name: "API key 權限為角色與 scope 的交集", identity: writeKey, operation: ast.Mutation, directive: hasPermission(model.PermissionMemberDelete), wantCode: "FORBIDDEN"},
+		{name: "API key 具備權限 scope", identity: writeKey, operation: ast.Mutation, directive: hasPermission(model.PermissionProductDelete)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withOperation(context.Background(), tt.operation, tt.field)
+			if tt.identity != nil {
+				ctx = auth.WithIdentity(ctx, tt.identity)
+			}
+
+			called := false
+			next := func(ctx context.Context) (any, error) {
+				called = true
+				return "ok", nil
+			}
+
+			res, err := tt.directive(newDirectives(tt.opts), ctx, next)
+			assert.Equal(t, tt.wantCode, errorCode(err), "err = %v", err)
+			assert.Equal(t, tt.wantCode == "", called)
+			if tt.wantCode == "" {
+				assert.Equal(t, "ok", res)
+			}
+		})
+	}
+}
+
+func TestGetUserIDFromContext(t *testing.T) {
+	assert.Equal(t, uint(0), getUserIDFromContext(context.Background()))
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Claims: &auth.Claims{UserID: 42}})
+	assert.Equal(t, uint(42), getUserIDFromContext(ctx))
+}
@@ -2,10 +2,15 @@ package graphql
 
 import (
 	"context"
+	"errors"
+	"member_API/auth"
 	"member_API/graphql/model"
 	"member_API/models"
+	"member_API/services"
 	"strconv"
 	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // dbToModel converts DB Member to GraphQL model
@@ -51,6 +56,51 @@ func productDBToModel(p models.Product) *model.Product {
 	}
 }
 
+// notificationDBToModel converts DB Notification to GraphQL model
+func notificationDBToModel(n models.Notification) *model.Notification {
+	var created *string
+	if !n.CreationTime.IsZero() {
+		s := formatTime(n.CreationTime)
+		created = &s
+	}
+	return &model.Notification{
+		ID:         formatID(n.ID),
+		Type:       n.Type,
+		Title:      n.Title,
+		Body:       n.Body,
+		Payload:    n.Payload,
+		ReadAt:     timePtr(n.ReadAt),
+		ArchivedAt: timePtr(n.ArchivedAt),
+		CreatedAt:  created,
+	}
+}
+
+// notificationPreferencesToModel converts notification preferences to GraphQL model
+func notificationPreferencesToModel(p *services.NotificationPreferences) *model.NotificationPreferences {
+	prefs := &model.NotificationPreferences{
+		Locale:   p.Locale,
+		Timezone: p.Timezone,
+		Types:    make([]*model.NotificationTypePreference, 0, len(p.Types)),
+	}
+	if p.QuietHoursStart != "" {
+		prefs.QuietHours = &model.QuietHours{Start: p.QuietHoursStart, End: p.QuietHoursEnd}
+	}
+	for _, t := range p.Types {
+		pref := &model.NotificationTypePreference{
+			Type:           t.Type,
+			Description:    t.Description,
+			Mandatory:      t.Mandatory,
+			DefaultEnabled: t.DefaultEnabled,
+			Channels:       make([]*model.NotificationChannelPreference, 0, len(t.Channels)),
+		}
+		for _, c := range t.Channels {
+			pref.Channels = append(pref.Channels, &model.NotificationChannelPreference{Channel: c.Channel, Enabled: c.Enabled})
+		}
+		prefs.Types = append(prefs.Types, pref)
+	}
+	return prefs
+}
+
 // formatTime formats time to RFC3339 string
 func formatTime(t time.Time) string {
 	return t.UTC().Format(time.RFC3339)
@@ -61,13 +111,28 @@ func formatID(id uint) string {
 	return strconv.FormatUint(uint64(id), 10)
 }
 
-// getUserIDFromContext extracts user ID from context
+// currentIdentity returns the authenticated caller, or nil for anonymous requests
+func currentIdentity(ctx context.Context) *auth.Identity {
+	identity, _ := auth.IdentityFromContext(ctx)
+	return identity
+}
+
+// getUserIDFromContext returns the authenticated member's ID, or 0 for anonymous requests
 func getUserIDFromContext(ctx context.Context) uint {
-	userID, ok := ctx.Value("user_id").(int64)
-	if !ok || userID <= 0 {
+	identity, ok := auth.IdentityFromContext(ctx)
+	if !ok || identity.Claims.UserID <= 0 {
 		return 0
 	}
-	return uint(userID)
+	return uint(identity.Claims.UserID)
+}
+
+// timePtr formats an optional time, returning nil when unset
+func timePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := formatTime(*t)
+	return &s
 }
 
 // stringPtr converts string to *string pointer
@@ -85,3 +150,28 @@ func ptrToString(s *string) string {
 	}
 	return *s
 }
+
+// passwordPolicyError converts a password policy violation into a GraphQL error
+// carrying the violated rules in its extensions
+func passwordPolicyError(err error) error {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return err
+	}
+	return &gqlerror.Error{
+		Message: policyErr.Error(),
+		Extensions: map[string]interface{}{
+			"code":       "PASSWORD_POLICY",
+			"violations": policyErr.Violations,
+		},
+	}
+}
+
+// policyError converts services.ErrForbidden into a FORBIDDEN GraphQL error,
+// matching the errors returned by the authorization directives
+func policyError(err error) error {
+	if errors.Is(err, services.ErrForbidden) {
+		return forbidden(err.Error())
+	}
+	return err
+}
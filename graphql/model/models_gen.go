@@ -2,6 +2,13 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
 type CreateMemberInput struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
@@ -29,6 +36,60 @@ type Member struct {
 type Mutation struct {
 }
 
+// A message in the current member's inbox. type names the event that produced
+// it and payload carries the event data as a JSON object.
+type Notification struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Title      string         `json:"title"`
+	Body       string         `json:"body"`
+	Payload    map[string]any `json:"payload,omitempty"`
+	ReadAt     *string        `json:"read_at,omitempty"`
+	ArchivedAt *string        `json:"archived_at,omitempty"`
+	CreatedAt  *string        `json:"created_at,omitempty"`
+}
+
+// Whether notifications of a type are delivered through a channel
+// (inbox, email or webhook)
+type NotificationChannelPreference struct {
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+type NotificationPreferenceInput struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// The current member's notification preferences. During quiet hours non-urgent
+// email and webhook notifications are deferred until the quiet hours end.
+type NotificationPreferences struct {
+	Locale     string                        `json:"locale"`
+	Timezone   string                        `json:"timezone"`
+	QuietHours *QuietHours                   `json:"quiet_hours,omitempty"`
+	Types      []*NotificationTypePreference `json:"types"`
+}
+
+// A notification type the member can configure. Mandatory types cannot be
+// disabled; types with default_enabled false are opt-in.
+type NotificationTypePreference struct {
+	Type           string                           `json:"type"`
+	Description    string                           `json:"description"`
+	Mandatory      bool                             `json:"mandatory"`
+	DefaultEnabled bool                             `json:"default_enabled"`
+	Channels       []*NotificationChannelPreference `json:"channels"`
+}
+
+type NotificationsResponse struct {
+	Notifications []*Notification `json:"notifications"`
+	Total         int             `json:"total"`
+	// Unread notifications in the inbox, regardless of the filters
+	Unread int `json:"unread"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
 type Product struct {
 	ID                 string  `json:"id"`
 	ProductName        string  `json:"product_name"`
@@ -50,11 +111,25 @@ type ProductsResponse struct {
 type Query struct {
 }
 
+// Quiet hours as HH:MM in the member's timezone; end before start spans midnight
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
 type UpdateMemberInput struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
 
+type UpdateNotificationPreferencesInput struct {
+	// Unchanged when omitted
+	Locale *string `json:"locale,omitempty"`
+	// IANA time zone name, unchanged when omitted
+	Timezone    *string                        `json:"timezone,omitempty"`
+	Preferences []*NotificationPreferenceInput `json:"preferences,omitempty"`
+}
+
 type UpdateProductInput struct {
 	ProductName        *string  `json:"product_name,omitempty"`
 	ProductPrice       *float64 `json:"product_price,omitempty"`
@@ -62,3 +137,123 @@ type UpdateProductInput struct {
 	ProductImage       *string  `json:"product_image,omitempty"`
 	ProductStock       *int     `json:"product_stock,omitempty"`
 }
+
+type Permission string
+
+const (
+	PermissionMemberRead    Permission = "MEMBER_READ"
+	PermissionMemberUpdate  Permission = "MEMBER_UPDATE"
+	PermissionMemberDelete  Permission = "MEMBER_DELETE"
+	PermissionRoleManage    Permission = "ROLE_MANAGE"
+	PermissionProductUpdate Permission = "PRODUCT_UPDATE"
+	PermissionProductDelete Permission = "PRODUCT_DELETE"
+)
+
+var AllPermission = []Permission{
+	PermissionMemberRead,
+	PermissionMemberUpdate,
+	PermissionMemberDelete,
+	PermissionRoleManage,
+	PermissionProductUpdate,
+	PermissionProductDelete,
+}
+
+func (e Permission) IsValid() bool {
+	switch e {
+	case PermissionMemberRead, PermissionMemberUpdate, PermissionMemberDelete, PermissionRoleManage, PermissionProductUpdate, PermissionProductDelete:
+		return true
+	}
+	return false
+}
+
+func (e Permission) String() string {
+	return string(e)
+}
+
+func (e *Permission) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Permission(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Permission", str)
+	}
+	return nil
+}
+
+func (e Permission) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Permission) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Permission) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Role string
+
+const (
+	RoleAdmin  Role = "ADMIN"
+	RoleStaff  Role = "STAFF"
+	RoleMember Role = "MEMBER"
+)
+
+var AllRole = []Role{
+	RoleAdmin,
+	RoleStaff,
+	RoleMember,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleAdmin, RoleStaff, RoleMember:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
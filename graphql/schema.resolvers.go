@@ -7,6 +7,7 @@ package graphql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"member_API/graphql/model"
 	"member_API/models"
@@ -21,9 +22,9 @@ func (r *mutationResolver) CreateMember(ctx context.Context, input model.CreateM
 	// 從 context 取得使用者 ID（如果沒有則使用 0 表示系統建立）
 	creatorId := getUserIDFromContext(ctx)
 
-	member, err := svc.CreateMember(input.Name, input.Email, input.Password, creatorId)
+	member, err := svc.CreateMember(input.Name, input.Email, input.Password, "", creatorId)
 	if err != nil {
-		return nil, err
+		return nil, passwordPolicyError(err)
 	}
 
 	return dbToModel(*member), nil
@@ -38,12 +39,9 @@ func (r *mutationResolver) UpdateMember(ctx context.Context, id string, input mo
 		return nil, fmt.Errorf("無效的會員 ID")
 	}
 
-	// 從 context 取得使用者 ID
-	modifierId := getUserIDFromContext(ctx)
-
-	member, err := svc.UpdateMember(uint(memberID), input.Name, input.Email, modifierId)
+	member, err := svc.UpdateMember(currentIdentity(ctx), uint(memberID), input.Name, input.Email)
 	if err != nil {
-		return nil, err
+		return nil, policyError(err)
 	}
 
 	return dbToModel(*member), nil
@@ -58,11 +56,8 @@ func (r *mutationResolver) DeleteMember(ctx context.Context, id string) (bool, e
 		return false, fmt.Errorf("無效的會員 ID")
 	}
 
-	// 從 context 取得使用者 ID
-	deleterId := getUserIDFromContext(ctx)
-
-	if err := svc.DeleteMember(uint(memberID), deleterId); err != nil {
-		return false, err
+	if err := svc.DeleteMember(currentIdentity(ctx), uint(memberID)); err != nil {
+		return false, policyError(err)
 	}
 
 	return true, nil
@@ -105,14 +100,6 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, input m
 		return nil, fmt.Errorf("invalid product ID")
 	}
 
-	var product models.Product
-	if err := r.DB.Where("is_deleted = ?", false).First(&product, productID).Error; err != nil {
-		return nil, fmt.Errorf("product not found")
-	}
-
-	modifierID := getUserIDFromContext(ctx)
-	product.LastModifierId = modifierID
-
 	updates := make(map[string]interface{})
 	if input.ProductName != nil {
 		updates["product_name"] = *input.ProductName
@@ -129,18 +116,14 @@ func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, input m
 	if input.ProductStock != nil {
 		updates["product_stock"] = *input.ProductStock
 	}
-	updates["last_modifier_id"] = modifierID
-
-	if err := r.DB.Model(&product).Updates(updates).Error; err != nil {
-		return nil, err
-	}
 
-	// Reload product
-	if err := r.DB.First(&product, productID).Error; err != nil {
-		return nil, err
+	svc := services.NewProductService(r.DB)
+	product, err := svc.UpdateProduct(currentIdentity(ctx), uint(productID), updates)
+	if err != nil {
+		return nil, policyError(err)
 	}
 
-	return productDBToModel(product), nil
+	return productDBToModel(*product), nil
 }
 
 // DeleteProduct is the resolver for the deleteProduct field.
@@ -154,18 +137,146 @@ func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool,
 		return false, fmt.Errorf("invalid product ID")
 	}
 
-	var product models.Product
-	if err := r.DB.Where("is_deleted = ?", false).First(&product, productID).Error; err != nil {
-		return false, fmt.Errorf("product not found")
+	svc := services.NewProductService(r.DB)
+	if err := svc.DeleteProduct(currentIdentity(ctx), uint(productID)); err != nil {
+		return false, policyError(err)
+	}
+
+	return true, nil
+}
+
+// MarkNotificationRead is the resolver for the markNotificationRead field.
+func (r *mutationResolver) MarkNotificationRead(ctx context.Context, id string, read *bool) (*model.Notification, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	notificationID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("無效的通知 ID")
+	}
+
+	svc := services.NewNotificationService(r.DB)
+	notification, err := svc.MarkRead(ctx, getUserIDFromContext(ctx), uint(notificationID), read == nil || *read)
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationDBToModel(*notification), nil
+}
+
+// MarkAllNotificationsRead is the resolver for the markAllNotificationsRead field.
+func (r *mutationResolver) MarkAllNotificationsRead(ctx context.Context) (int, error) {
+	if r.DB == nil {
+		return 0, fmt.Errorf("database connection not configured")
+	}
+
+	updated, err := services.NewNotificationService(r.DB).MarkAllRead(ctx, getUserIDFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(updated), nil
+}
+
+// ArchiveNotification is the resolver for the archiveNotification field.
+func (r *mutationResolver) ArchiveNotification(ctx context.Context, id string, archived *bool) (*model.Notification, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	notificationID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("無效的通知 ID")
+	}
+
+	svc := services.NewNotificationService(r.DB)
+	notification, err := svc.Archive(ctx, getUserIDFromContext(ctx), uint(notificationID), archived == nil || *archived)
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationDBToModel(*notification), nil
+}
+
+// DeleteNotification is the resolver for the deleteNotification field.
+func (r *mutationResolver) DeleteNotification(ctx context.Context, id string) (bool, error) {
+	if r.DB == nil {
+		return false, fmt.Errorf("database connection not configured")
+	}
+
+	notificationID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return false, fmt.Errorf("無效的通知 ID")
 	}
 
-	if err := r.DB.Model(&product).Updates(map[string]interface{}{"is_deleted": true}).Error; err != nil {
+	svc := services.NewNotificationService(r.DB)
+	if err := svc.Delete(ctx, getUserIDFromContext(ctx), uint(notificationID)); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+// UpdateNotificationPreferences is the resolver for the updateNotificationPreferences field.
+func (r *mutationResolver) UpdateNotificationPreferences(ctx context.Context, input model.UpdateNotificationPreferencesInput) (*model.NotificationPreferences, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	updates := make([]services.NotificationPreferenceUpdate, 0, len(input.Preferences))
+	for _, pref := range input.Preferences {
+		updates = append(updates, services.NotificationPreferenceUpdate{
+			Type:    pref.Type,
+			Channel: pref.Channel,
+			Enabled: pref.Enabled,
+		})
+	}
+
+	svc := services.NewNotificationPreferenceService(r.DB)
+	prefs, err := svc.Update(ctx, getUserIDFromContext(ctx), ptrToString(input.Locale), ptrToString(input.Timezone), updates)
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationPreferencesToModel(prefs), nil
+}
+
+// SetQuietHours is the resolver for the setQuietHours field.
+func (r *mutationResolver) SetQuietHours(ctx context.Context, start string, end string, timezone *string) (*model.NotificationPreferences, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	hours, err := services.ParseQuietHours(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := services.NewNotificationPreferenceService(r.DB)
+	prefs, err := svc.SetQuietHours(ctx, getUserIDFromContext(ctx), &hours, ptrToString(timezone))
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationPreferencesToModel(prefs), nil
+}
+
+// ClearQuietHours is the resolver for the clearQuietHours field.
+func (r *mutationResolver) ClearQuietHours(ctx context.Context) (*model.NotificationPreferences, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	svc := services.NewNotificationPreferenceService(r.DB)
+	prefs, err := svc.SetQuietHours(ctx, getUserIDFromContext(ctx), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationPreferencesToModel(prefs), nil
+}
+
 // Member is the resolver for the member field.
 func (r *queryResolver) Member(ctx context.Context, id string) (*model.Member, error) {
 	if r.DB == nil {
@@ -267,6 +378,107 @@ func (r *queryResolver) Products(ctx context.Context, limit *int, offset *int) (
 	}, nil
 }
 
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, unread *bool, archived *bool, typeArg *string, limit *int, offset *int) (*model.NotificationsResponse, error) {
+	if r.DB == nil {
+		return &model.NotificationsResponse{Notifications: []*model.Notification{}}, nil
+	}
+
+	lim := 50
+	if limit != nil && *limit > 0 {
+		if *limit > 100 {
+			lim = 100
+		} else {
+			lim = *limit
+		}
+	}
+
+	off := 0
+	if offset != nil && *offset >= 0 {
+		off = *offset
+	}
+
+	memberID := getUserIDFromContext(ctx)
+	svc := services.NewNotificationService(r.DB)
+	notifications, total, err := svc.List(ctx, memberID, services.NotificationFilter{
+		Unread:   unread,
+		Archived: archived,
+		Type:     ptrToString(typeArg),
+		Limit:    lim,
+		Offset:   off,
+	})
+	if err != nil {
+		return nil, err
+	}
+	count, err := svc.UnreadCount(ctx, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*model.Notification, len(notifications))
+	for i, n := range notifications {
+		out[i] = notificationDBToModel(n)
+	}
+
+	return &model.NotificationsResponse{
+		Notifications: out,
+		Total:         int(total),
+		Unread:        int(count),
+		Limit:         lim,
+		Offset:        off,
+	}, nil
+}
+
+// Notification is the resolver for the notification field.
+func (r *queryResolver) Notification(ctx context.Context, id string) (*model.Notification, error) {
+	if r.DB == nil {
+		return nil, nil
+	}
+
+	notificationID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+
+	notification, err := services.NewNotificationService(r.DB).Get(ctx, getUserIDFromContext(ctx), uint(notificationID))
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return notificationDBToModel(*notification), nil
+}
+
+// UnreadNotificationCount is the resolver for the unreadNotificationCount field.
+func (r *queryResolver) UnreadNotificationCount(ctx context.Context) (int, error) {
+	if r.DB == nil {
+		return 0, nil
+	}
+
+	count, err := services.NewNotificationService(r.DB).UnreadCount(ctx, getUserIDFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// NotificationPreferences is the resolver for the notificationPreferences field.
+func (r *queryResolver) NotificationPreferences(ctx context.Context) (*model.NotificationPreferences, error) {
+	if r.DB == nil {
+		return nil, fmt.Errorf("database connection not configured")
+	}
+
+	prefs, err := services.NewNotificationPreferenceService(r.DB).Get(ctx, getUserIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationPreferencesToModel(prefs), nil
+}
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
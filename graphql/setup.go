@@ -13,7 +13,9 @@ import (
 var gqlHTTPHandler http.Handler
 
 // SetupGraphQL initializes gqlgen schema and a unified handler.
-func SetupGraphQL(db *gorm.DB) error {
+// The caller's identity is read from the request context (see auth.OptionalAuthMiddleware)
+// and checked by the schema directives according to opts.
+func SetupGraphQL(db *gorm.DB, opts Options) error {
 	if db == nil {
 		log.Println("[GraphQL] ERROR: Database connection is nil, cannot initialize GraphQL")
 		return errors.New("database connection not initialized")
@@ -21,7 +23,7 @@ func SetupGraphQL(db *gorm.DB) error {
 
 	log.Println("[GraphQL] Setting up schema and handler...")
 	resolver := NewResolver(db)
-	schema := NewExecutableSchema(Config{Resolvers: resolver})
+	schema := NewExecutableSchema(Config{Resolvers: resolver, Directives: newDirectives(opts)})
 	server := handler.NewDefaultServer(schema)
 
 	// Single endpoint handler: GET -> Playground, others -> GraphQL server
@@ -0,0 +1,16 @@
+package mailer
+
+import "context"
+
+// Message 一封待寄送的電子郵件，HTML 為空時只寄送純文字內容
+type Message struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html,omitempty"`
+}
+
+// Mailer 寄送電子郵件的介面
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OutboxMailer 不實際寄信，而是將郵件保存在記憶體中；設定 Dir 時同時寫成 JSON 檔案。
+// 適用於測試與尚未設定 SMTP 的開發環境。
+type OutboxMailer struct {
+	Dir string
+
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewOutboxMailer 建立 OutboxMailer，dir 為空時只保存在記憶體中
+func NewOutboxMailer(dir string) *OutboxMailer {
+	return &OutboxMailer{Dir: dir}
+}
+
+// Send 保存郵件
+func (m *OutboxMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	if m.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%03d.json", time.Now().UTC().Format("20060102T150405.000000000"), len(m.messages))
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o644)
+}
+
+// Messages 回傳目前保存的所有郵件
+func (m *OutboxMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Last 回傳最後一封郵件
+func (m *OutboxMailer) Last() (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return Message{}, false
+	}
+	return m.messages[len(m.messages)-1], true
+}
+
+// Reset 清空保存的郵件
+func (m *OutboxMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
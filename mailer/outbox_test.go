@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxMailerMemory(t *testing.T) {
+	outbox := NewOutboxMailer("")
+
+	_, ok := outbox.Last()
+	assert.False(t, ok, "空的 outbox 不應有郵件")
+
+	first := Message{To: []string{"a@example.com"}, Subject: "第一封", Text: "hello"}
+	second := Message{To: []string{"b@example.com"}, Subject: "第二封", Text: "world"}
+	require.NoError(t, outbox.Send(context.Background(), first))
+	require.NoError(t, outbox.Send(context.Background(), second))
+
+	assert.Equal(t, []Message{first, second}, outbox.Messages())
+	last, ok := outbox.Last()
+	assert.True(t, ok)
+	assert.Equal(t, second, last)
+
+	// Messages 回傳的是副本
+	messages := outbox.Messages()
+	messages[0].Subject = "changed"
+	assert.Equal(t, "第一封", outbox.Messages()[0].Subject)
+
+	outbox.Reset()
+	assert.Empty(t, outbox.Messages())
+}
+
+func TestOutboxMailerFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	outbox := NewOutboxMailer(dir)
+
+	msg := Message{To: []string{"user@example.com"}, Subject: "重設密碼", Text: "token", HTML: "<p>token</p>"}
+	require.NoError(t, outbox.Send(context.Background(), msg))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+
+	var saved Message
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, msg, saved)
+	assert.Len(t, outbox.Messages(), 1, "寫入檔案時也應保存在記憶體中")
+}
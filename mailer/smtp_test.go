@@ -0,0 +1,171 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("純文字郵件", func(t *testing.T) {
+		data, err := buildMessage("noreply@example.com", Message{
+			To:      []string{"a@example.com", "b@example.com"},
+			Subject: "重設密碼",
+			Text:    "請點擊連結",
+		}, date)
+		require.NoError(t, err)
+
+		body := string(data)
+		assert.Contains(t, body, "From: noreply@example.com\r\n")
+		assert.Contains(t, body, "To: a@example.com, b@example.com\r\n")
+		assert.Contains(t, body, "Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n")
+		assert.Contains(t, body, "Content-Type: text/plain; charset=utf-8\r\n")
+		assert.NotContains(t, body, "multipart")
+
+		subject := strings.TrimPrefix(strings.Split(body[strings.Index(body, "Subject: "):], "\r\n")[0], "Subject: ")
+		decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+		require.NoError(t, err)
+		assert.Equal(t, "重設密碼", decoded)
+	})
+
+	t.Run("含 HTML 的郵件", func(t *testing.T) {
+		data, err := buildMessage("noreply@example.com", Message{
+			To:      []string{"a@example.com"},
+			Subject: "Hello",
+			Text:    "plain",
+			HTML:    "<p>html</p>",
+		}, date)
+		require.NoError(t, err)
+
+		body := string(data)
+		assert.Contains(t, body, "Content-Type: multipart/alternative; boundary=")
+		assert.Contains(t, body, "Content-Type: text/plain; charset=utf-8")
+		assert.Contains(t, body, "Content-Type: text/html; charset=utf-8")
+		assert.Contains(t, body, "plain")
+		assert.Contains(t, body, "<p>html</p>")
+	})
+}
+
+// fakeSMTPServer 最小化的 SMTP 伺服器，記錄收到的寄件者、收件者與郵件內容
+type fakeSMTPServer struct {
+	listener net.Listener
+	from     string
+	rcpt     []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.rcpt = append(s.rcpt, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	m := NewSMTPMailer("127.0.0.1", server.port(), "", "", "noreply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.Send(ctx, Message{
+		To:      []string{"user@example.com", "admin@example.com"},
+		Subject: "Test",
+		Text:    "hello world",
+	})
+	require.NoError(t, err)
+	<-server.done
+
+	assert.Equal(t, "noreply@example.com", server.from)
+	assert.Equal(t, []string{"user@example.com", "admin@example.com"}, server.rcpt)
+	assert.Contains(t, server.data, "Subject: Test")
+	assert.Contains(t, server.data, "hello world")
+}
+
+func TestSMTPMailerErrors(t *testing.T) {
+	m := NewSMTPMailer("127.0.0.1", 1, "", "", "noreply@example.com")
+
+	err := m.Send(context.Background(), Message{Subject: "no recipients"})
+	assert.Error(t, err, "沒有收件者時應返回錯誤")
+
+	// 取得一個未被使用的連接埠
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	m.Port = port
+	err = m.Send(context.Background(), Message{To: []string{"a@example.com"}, Subject: "x", Text: "y"})
+	assert.Error(t, err, "無法連線時應返回錯誤")
+}
@@ -2,21 +2,32 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"member_API/auth"
 	"member_API/config"
 	"member_API/controllers"
 	_ "member_API/docs" // 導入 swagger 文檔
 	"member_API/graphql"
+	"member_API/mailer"
 	"member_API/models"
+	"member_API/notify"
+	"member_API/oidc"
 	"member_API/routes"
+	"member_API/services"
+	"member_API/webauthn"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv" // 新增
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -43,6 +54,11 @@ import (
 // @name Authorization
 // @description JWT 認證，格式：Bearer {token}
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description API key 認證（供程式使用），格式：ApiKey {key}
+
 var db *gorm.DB
 
 func initPostgreSQL() error {
@@ -78,10 +94,46 @@ func initPostgreSQL() error {
 	if err := gormDB.WithContext(ctx).AutoMigrate(
 		&models.Member{},
 		&models.Product{},
+		&models.RefreshToken{},
+		&models.MemberRole{},
+		&models.TokenRevocation{},
+		&models.ActionToken{},
+		&models.RecoveryCode{},
+		&models.Identity{},
+		&models.APIKey{},
+		&models.Session{},
+		&models.AuditLog{},
+		&models.WebAuthnCredential{},
+		&models.Notification{},
+		&models.NotificationDelivery{},
+		&models.OutboxMessage{},
+		&models.NotificationTemplate{},
+		&models.NotificationPreference{},
 	); err != nil {
 		return err
 	}
 
+	// 指定初始管理員（會員需已註冊）
+	if email := os.Getenv("BOOTSTRAP_ADMIN_EMAIL"); email != "" {
+		if err := services.NewMemberService(gormDB).EnsureRoleByEmail(email, auth.RoleAdmin); err != nil {
+			log.Printf("Warning: failed to grant admin role to %s: %v\n", email, err)
+		}
+	}
+
+	// token 撤銷紀錄：啟動時載入快取，之後定期與其他實例同步
+	revocations := services.NewTokenRevocationService(gormDB)
+	if err := revocations.Sync(ctx); err != nil {
+		return err
+	}
+	revocations.StartSync(context.Background(), 30*time.Second)
+	auth.SetRevocationStore(revocations)
+
+	// 記錄登入工作階段的最後使用時間
+	auth.SetSessionTracker(services.NewSessionService(gormDB))
+
+	// API key 認證（Authorization: ApiKey {key}）
+	auth.SetAPIKeyStore(services.NewAPIKeyService(gormDB))
+
 	db = gormDB
 	controllers.SetupUserController(db)
 	controllers.SetupProductController(db)
@@ -114,6 +166,109 @@ func HealthCheck(c *gin.Context) {
 	})
 }
 
+// newMailer 依設定建立 Mailer：有 SMTP_HOST 時使用 SMTP，否則使用 outbox
+func newMailer(cfg config.MailConfig) mailer.Mailer {
+	if cfg.SMTPHost != "" {
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	}
+	log.Println("Warning: SMTP_HOST not set. Emails will be written to the outbox instead of being sent.")
+	return mailer.NewOutboxMailer(cfg.OutboxDir)
+}
+
+// newNotifier 依設定建立通知的 Dispatcher，傳送結果記錄在 notification_deliveries，並依會員的通知偏好與勿擾時段傳送
+func newNotifier(cfg config.NotifyConfig, m mailer.Mailer, svc *services.NotificationService) *notify.Dispatcher {
+	channels := []notify.Channel{notify.NewInboxChannel(svc)}
+	if cfg.Email {
+		channels = append(channels, notify.NewEmailChannel(m))
+	}
+	if cfg.WebhookURL != "" {
+		channels = append(channels, notify.NewWebhookChannel(cfg.WebhookURL, cfg.WebhookSecret, &http.Client{Timeout: cfg.WebhookTimeout}))
+	}
+	d := notify.NewDispatcher(svc, channels...)
+	d.Policy = services.NewNotificationPreferenceService(svc.DB)
+	return d
+}
+
+// newPasswordHasher 依設定建立產生新密碼雜湊使用的演算法
+func newPasswordHasher(cfg config.PasswordConfig) (auth.PasswordHasher, error) {
+	switch cfg.HashAlgorithm {
+	case "argon2id":
+		if cfg.Argon2Iterations < 1 || cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 || cfg.Argon2Memory < 8*cfg.Argon2Parallelism {
+			return nil, fmt.Errorf("invalid argon2id parameters m=%d,t=%d,p=%d", cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism)
+		}
+		hasher := auth.DefaultArgon2idHasher()
+		hasher.Memory = uint32(cfg.Argon2Memory)
+		hasher.Iterations = uint32(cfg.Argon2Iterations)
+		hasher.Parallelism = uint8(cfg.Argon2Parallelism)
+		return hasher, nil
+	case "bcrypt":
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid BCRYPT_COST %d", cfg.BcryptCost)
+		}
+		return &auth.BcryptHasher{Cost: cfg.BcryptCost}, nil
+	default:
+		return nil, fmt.Errorf("unsupported PASSWORD_HASH_ALGORITHM %q", cfg.HashAlgorithm)
+	}
+}
+
+// newPasswordPolicy 依設定建立密碼規則，並載入外洩密碼清單
+func newPasswordPolicy(cfg config.PasswordConfig) (*auth.PasswordPolicy, error) {
+	policy := &auth.PasswordPolicy{
+		MinLength:            cfg.MinLength,
+		MaxLength:            cfg.MaxLength,
+		RequireUpper:         cfg.RequireUpper,
+		RequireLower:         cfg.RequireLower,
+		RequireDigit:         cfg.RequireDigit,
+		RequireSymbol:        cfg.RequireSymbol,
+		DisallowPersonalInfo: cfg.DisallowPersonalInfo,
+	}
+	if cfg.BreachedListPath != "" {
+		breached, err := auth.LoadBreachedPasswords(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+	return policy, nil
+}
+
+// newOIDCProviders 依設定建立外部 IdP；discovery 文件在第一次登入時才下載
+func newOIDCProviders(cfgs []config.OIDCProviderConfig) (map[string]*oidc.Provider, error) {
+	providers := make(map[string]*oidc.Provider, len(cfgs))
+	for _, cfg := range cfgs {
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("OIDC provider %q requires an issuer and a client ID", cfg.Name)
+		}
+		providers[cfg.Name] = oidc.NewProvider(oidc.Config{
+			Name:           cfg.Name,
+			Issuer:         cfg.Issuer,
+			ClientID:       cfg.ClientID,
+			ClientSecret:   cfg.ClientSecret,
+			RedirectURL:    cfg.RedirectURL,
+			Scopes:         cfg.Scopes,
+			AllowedDomains: cfg.AllowedDomains,
+			AutoLink:       cfg.AutoLink,
+			AutoProvision:  cfg.AutoProvision,
+		}, &http.Client{Timeout: 10 * time.Second})
+	}
+	return providers, nil
+}
+
+// reloadSigningKeyOnHangup 收到 SIGHUP 時重新讀取簽章金鑰檔案並輪替（kid 使用 thumbprint），
+// 舊金鑰在 JWT_KEY_ROTATION_OVERLAP 期間內仍可驗證已簽發的 token
+func reloadSigningKeyOnHangup(ring *auth.KeyRing, cfg config.JWTConfig) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		key, err := ring.Reload(cfg.SigningKeyFile)
+		if err != nil {
+			log.Printf("Error reloading JWT signing key: %v\n", err)
+			continue
+		}
+		log.Printf("[Main] JWT signing key rotated (kid=%s)\n", key.ID)
+	}
+}
+
 func main() {
 
 	// 載入 .env 文件
@@ -121,7 +276,78 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	cfg := config.Load()
+
+	// 載入 JWT 非對稱簽章金鑰（未設定時使用 JWT_SECRET 的 HS256）
+	if cfg.JWT.SigningKeyFile == "" && cfg.JWT.Secret == "" {
+		log.Fatal("JWT_SIGNING_KEY_FILE or JWT_SECRET must be set")
+	}
+	auth.SetSecret(cfg.JWT.Secret)
+	if cfg.JWT.SigningKeyFile != "" {
+		ring, err := auth.LoadKeyRing(cfg.JWT.SigningKeyID, cfg.JWT.SigningKeyFile, cfg.JWT.PreviousKeyFiles, cfg.JWT.KeyRotationOverlap)
+		if err != nil {
+			log.Fatalf("Failed to load JWT signing keys: %v", err)
+		}
+		auth.SetKeyRing(ring)
+		go reloadSigningKeyOnHangup(ring, cfg.JWT)
+		log.Printf("[Main] JWT signing key loaded (kid=%s)\n", ring.Current().ID)
+	}
+
+	// 密碼雜湊演算法（舊格式的雜湊會在登入成功時自動升級）
+	hasher, err := newPasswordHasher(cfg.Password)
+	if err != nil {
+		log.Fatalf("Invalid password hashing configuration: %v", err)
+	}
+	auth.SetPasswordHasher(hasher)
+
+	// 密碼規則（REST、GraphQL 與重設密碼共用）
+	policy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+	auth.SetPasswordPolicy(policy)
+
+	// 登入暴力破解防護：帳號鎖定與來源 IP 節流
+	controllers.SetupLoginProtection(
+		auth.LockoutPolicy{
+			MaxAttempts: cfg.Auth.MaxFailedLogins,
+			Duration:    cfg.Auth.LockoutDuration,
+			MaxDuration: cfg.Auth.MaxLockoutDuration,
+		},
+		auth.NewLoginThrottle(auth.LockoutPolicy{
+			MaxAttempts: cfg.Auth.IPMaxFailedLogins,
+			Duration:    cfg.Auth.IPDelay,
+			MaxDuration: cfg.Auth.IPMaxDelay,
+		}),
+	)
+
+	// 外部 OpenID Connect 登入
+	providers, err := newOIDCProviders(cfg.OIDC)
+	if err != nil {
+		log.Fatalf("Invalid OIDC configuration: %v", err)
+	}
+	controllers.SetupOIDC(providers, oidc.NewMemoryStateStore())
+
+	// Passkey（WebAuthn）登入
+	rp, err := webauthn.New(webauthn.Config{
+		RPID:    cfg.WebAuthn.RPID,
+		RPName:  cfg.WebAuthn.RPName,
+		Origins: cfg.WebAuthn.Origins,
+	})
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn configuration: %v", err)
+	}
+	controllers.SetupWebAuthn(rp, webauthn.NewMemorySessionStore())
+
+	// 會員自行刪除帳號的緩衝期
+	controllers.SetupAccountDeletion(cfg.Account.DeletionGracePeriod)
+
+	// 設定郵件寄送（未設定 SMTP 時寫入 outbox）
+	mail := newMailer(cfg.Mail)
+	services.SetupMail(mail, cfg.Server.BaseURL)
+
 	// 初始化 PostgreSQL 連接
+	var outbox *services.OutboxWorker
 	if err := initPostgreSQL(); err != nil {
 		log.Printf("Warning: PostgreSQL connection failed: %v\n", err)
 		log.Println("Starting server without PostgreSQL connection...")
@@ -137,36 +363,75 @@ func main() {
 				log.Printf("Error retrieving SQL DB handle: %v\n", err)
 			}
 		}()
+
+		// 定期匿名化刪除申請已到期的會員
+		services.NewAccountDeletionService(db, cfg.Account.DeletionGracePeriod).
+			StartSweeper(context.Background(), cfg.Account.AnonymizeInterval)
+
+		// 通知管道：站內收件匣、電子郵件與 webhook
+		services.SetupNotifications(newNotifier(cfg.Notify, mail, services.NewNotificationService(db)))
+
+		// 背景寄送 outbox 中的郵件與通知
+		outbox = services.NewOutboxWorker(db, services.OutboxHandlers(db), services.OutboxOptions{
+			Workers:        cfg.Outbox.Workers,
+			PollInterval:   cfg.Outbox.PollInterval,
+			MaxAttempts:    cfg.Outbox.MaxAttempts,
+			BaseBackoff:    cfg.Outbox.BaseBackoff,
+			MaxBackoff:     cfg.Outbox.MaxBackoff,
+			Retention:      cfg.Outbox.Retention,
+			HandlerTimeout: cfg.Outbox.HandlerTimeout,
+		})
+		outbox.Start()
 	}
 
 	// 初始化 GraphQL（必須在路由設置之前）
-	if err := graphql.SetupGraphQL(db); err != nil {
+	if err := graphql.SetupGraphQL(db, graphql.Options{
+		RequireVerifiedEmail: cfg.Auth.RequireVerifiedEmail,
+		RequireAdminMFA:      cfg.Auth.RequireAdminMFA,
+	}); err != nil {
 		log.Printf("Warning: GraphQL setup failed: %v\n", err)
 	} else {
 		log.Println("[Main] GraphQL setup completed successfully")
 	}
 
 	// 創建 Gin 路由器
-	Router := gin.Default()
+	router := gin.Default()
 
 	// 設置路由（需要在 GraphQL 初始化之後）
-	routes.SetupRouter(Router)
+	routes.SetupRouter(router, cfg)
 
 	// Swagger 文檔路由
-	Router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 添加一個簡單的健康檢查端點
-	Router.GET("/health", HealthCheck)
-
-	// // 啟動服務器
-	cfg := config.Load()
-	router := gin.Default()
-	routes.SetupRouter(router)
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET("/health", HealthCheck)
 
-	log.Println("Server starting on :" + cfg.Server.Port)
-	if err := router.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatal(err)
+	// 啟動服務器
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: router,
+	}
+	go func() {
+		log.Println("Server starting on :" + cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// 收到 SIGINT/SIGTERM 時停止接受新請求，並等待進行中的請求與 outbox 寄送完成
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("[Main] Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Outbox.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down server: %v\n", err)
+	}
+	if outbox != nil {
+		if err := outbox.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down outbox worker: %v\n", err)
+		}
 	}
 }
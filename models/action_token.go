@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ActionToken is a hashed, expiring, single-use token emailed to a member
+// to authorise one action such as resetting a password.
+type ActionToken struct {
+	MemberID  uint       `gorm:"index;not null" json:"member_id"`
+	Purpose   string     `gorm:"size:32;index;not null" json:"purpose"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Payload   string     `gorm:"size:255" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	Base
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey is a named, scoped credential a member creates for machine clients.
+// Only the SHA-256 hash of the key is stored; Prefix is the non-secret part
+// shown in listings so members can tell their keys apart.
+type APIKey struct {
+	MemberID   uint       `gorm:"index;not null" json:"member_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;uniqueIndex;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:text;not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:45" json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	Base
+}
@@ -0,0 +1,16 @@
+package models
+
+// AuditLog records a security-relevant action, such as an administrator
+// starting or ending an impersonation. ActorID is the member who performed
+// the action and TargetID the member it was performed on; rows are never
+// deleted, only IP and UserAgent are cleared when a member is anonymized.
+type AuditLog struct {
+	ActorID   uint              `gorm:"index;not null" json:"actor_id"`
+	Action    string            `gorm:"size:64;index;not null" json:"action"`
+	TargetID  uint              `gorm:"index" json:"target_id"`
+	Reason    string            `gorm:"size:500" json:"reason"`
+	IP        string            `gorm:"size:45" json:"ip"`
+	UserAgent string            `gorm:"size:512" json:"user_agent"`
+	Metadata  map[string]string `gorm:"serializer:json;type:text" json:"metadata,omitempty"`
+	Base
+}
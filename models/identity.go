@@ -0,0 +1,12 @@
+package models
+
+// Identity links a member to an account at an external OpenID Connect provider.
+// Provider and Subject together identify the external account; Email is the
+// address the provider reported at the last login.
+type Identity struct {
+	MemberID uint   `gorm:"index;not null" json:"member_id"`
+	Provider string `gorm:"size:64;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject  string `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email    string `gorm:"size:255" json:"email"`
+	Base
+}
@@ -1,9 +1,38 @@
 package models
 
+import "time"
+
 // Member represents a user stored in PostgreSQL and managed by GORM.
 type Member struct {
 	Name         string `gorm:"size:255;not null" json:"name"`
 	Email        string `gorm:"size:255;uniqueIndex;not null" json:"email"`
 	PasswordHash string `gorm:"size:255" json:"-"`
+	// Locale selects the language of emails and notifications (e.g. "zh-TW", "en"); empty means the default.
+	Locale string `gorm:"size:16" json:"locale,omitempty"`
+	// Timezone is the IANA time zone quiet hours are evaluated in (e.g. "Asia/Taipei"); empty means UTC.
+	Timezone string `gorm:"size:64" json:"timezone,omitempty"`
+	// QuietHoursStart and QuietHoursEnd are minutes after midnight in Timezone during which
+	// non-urgent notifications are deferred; nil disables quiet hours. The range may wrap past midnight.
+	QuietHoursStart *int `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd   *int `json:"quiet_hours_end,omitempty"`
+	// EmailVerifiedAt is set once the member follows the verification link; nil means unverified.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// FailedLoginAttempts counts consecutive failed logins; reset on success or by an admin unlock.
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	// TOTPSecret holds the Base32 TOTP key; it is pending until TOTPEnabledAt is set.
+	TOTPSecret    string     `gorm:"size:64" json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at,omitempty"`
+	// TOTPLastStep is the last accepted TOTP time step, used to reject replayed codes.
+	TOTPLastStep  int64          `gorm:"not null;default:0" json:"-"`
+	RecoveryCodes []RecoveryCode `gorm:"foreignKey:MemberID" json:"-"`
+	Roles         []MemberRole   `gorm:"foreignKey:MemberID" json:"roles,omitempty"`
+
+	// MagicLinkEnabledAt is set once the member opts in to passwordless login links; nil means opted out.
+	MagicLinkEnabledAt *time.Time `json:"magic_link_enabled_at,omitempty"`
+	// DeletionScheduledAt is when a self-requested account deletion takes effect; nil when none is pending.
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty"`
+	// AnonymizedAt is set once personal data has been scrubbed from a deleted member.
+	AnonymizedAt *time.Time `json:"-"`
 	Base
 }
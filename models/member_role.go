@@ -0,0 +1,9 @@
+package models
+
+// MemberRole assigns an explicit role (e.g. admin, staff) to a member.
+// Every member implicitly has the "member" role, which is never stored.
+type MemberRole struct {
+	MemberID uint   `gorm:"uniqueIndex:idx_member_role;not null" json:"member_id"`
+	Role     string `gorm:"size:32;uniqueIndex:idx_member_role;not null" json:"role"`
+	Base
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Notification is a message in a member's inbox. Type names the event that
+// produced it (e.g. "account.password_changed") and Payload carries the event
+// data, so clients can render their own text or link to the related resource.
+// Read and archived states are timestamps; nil means unread / in the inbox.
+// MessageID is shared with the other channels the notification was sent over
+// and makes redelivery to the inbox idempotent.
+type Notification struct {
+	MemberID   uint                   `gorm:"index;not null" json:"member_id"`
+	MessageID  string                 `gorm:"size:64;uniqueIndex" json:"message_id"`
+	Type       string                 `gorm:"size:64;index;not null" json:"type"`
+	Title      string                 `gorm:"size:200;not null" json:"title"`
+	Body       string                 `gorm:"type:text" json:"body"`
+	Payload    map[string]interface{} `gorm:"serializer:json;type:text" json:"payload,omitempty"`
+	ReadAt     *time.Time             `json:"read_at"`
+	ArchivedAt *time.Time             `json:"archived_at"`
+	Base
+}
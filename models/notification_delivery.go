@@ -0,0 +1,15 @@
+package models
+
+// NotificationDelivery records the outcome of sending one notification over
+// one channel (inbox, email, webhook, ...). MessageID ties together the rows
+// of a single notification; Status is sent, failed or skipped when the member
+// is not eligible for the channel.
+type NotificationDelivery struct {
+	MessageID string `gorm:"size:64;index;not null" json:"message_id"`
+	MemberID  uint   `gorm:"index;not null" json:"member_id"`
+	Type      string `gorm:"size:64;not null" json:"type"`
+	Channel   string `gorm:"size:32;not null" json:"channel"`
+	Status    string `gorm:"size:16;index;not null" json:"status"`
+	Error     string `gorm:"size:1000" json:"error,omitempty"`
+	Base
+}
@@ -0,0 +1,12 @@
+package models
+
+// NotificationPreference records a member's choice to receive (or not) one
+// notification type over one channel. Types and channels without a row use
+// the type's default, and mandatory types ignore the preference.
+type NotificationPreference struct {
+	MemberID uint   `gorm:"not null;uniqueIndex:idx_notification_preferences_key" json:"member_id"`
+	Type     string `gorm:"size:64;not null;uniqueIndex:idx_notification_preferences_key" json:"type"`
+	Channel  string `gorm:"size:32;not null;uniqueIndex:idx_notification_preferences_key" json:"channel"`
+	Enabled  bool   `gorm:"not null" json:"enabled"`
+	Base
+}
@@ -0,0 +1,15 @@
+package models
+
+// NotificationTemplate overrides the built-in template of one event type,
+// channel and locale. Subject and Text are text/template sources, HTML is an
+// html/template source used only for email. Deleting the row restores the
+// built-in template.
+type NotificationTemplate struct {
+	Type    string `gorm:"size:64;not null;uniqueIndex:idx_notification_templates_key" json:"type"`
+	Channel string `gorm:"size:32;not null;uniqueIndex:idx_notification_templates_key" json:"channel"`
+	Locale  string `gorm:"size:16;not null;uniqueIndex:idx_notification_templates_key" json:"locale"`
+	Subject string `gorm:"size:500;not null" json:"subject"`
+	Text    string `gorm:"type:text;not null" json:"text"`
+	HTML    string `gorm:"type:text" json:"html,omitempty"`
+	Base
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// OutboxMessage is a side effect, such as an email or a notification, written
+// in the same transaction as the business change that caused it and delivered
+// afterwards by the background worker. A pending row is due at NextAttemptAt;
+// failed attempts are retried with backoff until the attempt limit, after
+// which the row is dead-lettered and kept for inspection.
+type OutboxMessage struct {
+	MemberID      uint       `gorm:"index" json:"member_id,omitempty"`
+	Topic         string     `gorm:"size:64;not null" json:"topic"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"size:16;not null;index:idx_outbox_messages_due,priority:1" json:"status"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_messages_due,priority:2" json:"next_attempt_at"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"size:1000" json:"last_error,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+	ClaimToken    string     `gorm:"size:32" json:"-"`
+	Base
+}
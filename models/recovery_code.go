@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode is a one-time two-factor recovery code. Only the SHA-256 hash
+// of the code is stored; a used code has UsedAt set.
+type RecoveryCode struct {
+	MemberID uint       `gorm:"index;not null" json:"member_id"`
+	CodeHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+	Base
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RefreshToken represents a long-lived refresh token issued to a member.
+// Only the SHA-256 hash of the token is stored. Tokens produced by rotating
+// the same login share a FamilyID so a replayed token can revoke the chain,
+// and belong to the login's Session.
+type RefreshToken struct {
+	MemberID  uint       `gorm:"index;not null" json:"member_id"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	FamilyID  string     `gorm:"size:64;index;not null" json:"family_id"`
+	SessionID uint       `gorm:"index" json:"session_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	Base
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Session is a login on one device. Refresh tokens and access tokens issued
+// for the login carry its ID, so revoking the session signs the device out.
+// ExpiresAt follows the refresh token expiry and moves forward on each refresh.
+type Session struct {
+	MemberID   uint       `gorm:"index;not null" json:"member_id"`
+	UserAgent  string     `gorm:"size:512" json:"user_agent"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Passkey marks logins made with a user-verified passkey, which count as multi-factor.
+	Passkey bool `gorm:"not null;default:false" json:"passkey"`
+	Base
+}
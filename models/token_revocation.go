@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TokenRevocation records an access token revoked before its expiry.
+// A row revokes a single token by JTI, every token bound to SessionID,
+// or every token of the member issued at or before RevokedBefore.
+type TokenRevocation struct {
+	JTI           string     `gorm:"column:jti;size:64;index" json:"jti"`
+	MemberID      uint       `gorm:"index;not null" json:"member_id"`
+	SessionID     uint       `gorm:"index" json:"session_id"`
+	RevokedBefore *time.Time `json:"revoked_before"`
+	ExpiresAt     time.Time  `gorm:"index;not null" json:"expires_at"`
+	Reason        string     `gorm:"size:64" json:"reason"`
+	Base
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// WebAuthnCredential is a passkey registered by a member. PublicKey holds the
+// COSE-encoded key used to verify login assertions, and SignCount the last
+// signature counter reported by the authenticator (0 if it keeps none).
+type WebAuthnCredential struct {
+	MemberID     uint   `gorm:"index;not null" json:"member_id"`
+	Name         string `gorm:"size:100;not null" json:"name"`
+	CredentialID []byte `gorm:"uniqueIndex;not null" json:"-"`
+	PublicKey    []byte `gorm:"not null" json:"-"`
+	Algorithm    int    `gorm:"not null" json:"algorithm"`
+	SignCount    uint32 `gorm:"not null;default:0" json:"-"`
+	// AAGUID identifies the authenticator model; all zeros when not disclosed.
+	AAGUID         string     `gorm:"size:36" json:"aaguid"`
+	Transports     []string   `gorm:"serializer:json;type:text" json:"transports"`
+	BackupEligible bool       `gorm:"not null;default:false" json:"backup_eligible"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	Base
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// 管道的傳送狀態
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+	// StatusDeferred 依 Policy 延後傳送，呼叫端需在 DeferredError.Until 之後重新傳送
+	StatusDeferred = "deferred"
+)
+
+// DefaultSendTimeout 單一管道傳送的逾時時間
+const DefaultSendTimeout = 30 * time.Second
+
+// Result 單一管道的傳送結果
+type Result struct {
+	Channel string        `json:"channel"`
+	Status  string        `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Elapsed time.Duration `json:"-"`
+}
+
+// Recorder 保存每個管道的傳送結果
+type Recorder interface {
+	RecordDeliveries(ctx context.Context, recipient Recipient, msg Message, results []Result) error
+}
+
+// Decision Policy 對單一管道的決定
+type Decision struct {
+	// Allow 為 false 時不傳送，記為 skipped，Reason 說明原因
+	Allow  bool
+	Reason string
+	// DeferUntil 不是零值時延後到此時間再傳送
+	DeferUntil time.Time
+}
+
+// Policy 依會員的偏好決定是否透過某管道傳送通知，或延後傳送
+type Policy interface {
+	Decide(ctx context.Context, recipient Recipient, msg Message, channel string) (Decision, error)
+}
+
+// DeferredError 通知依 Policy 延後傳送時回傳，呼叫端應在 Until 之後重新傳送
+type DeferredError struct {
+	Channel string
+	Until   time.Time
+}
+
+func (e *DeferredError) Error() string {
+	return fmt.Sprintf("%s: deferred until %s", e.Channel, e.Until.UTC().Format(time.RFC3339))
+}
+
+// Dispatcher 將通知分送到會員符合條件的每個管道
+type Dispatcher struct {
+	channels []Channel
+	recorder Recorder
+	// SendTimeout 單一管道傳送的逾時時間，0 表示 DefaultSendTimeout
+	SendTimeout time.Duration
+	// Policy 為 nil 時傳送到每個符合條件的管道
+	Policy Policy
+}
+
+// NewDispatcher 建立 Dispatcher；recorder 為 nil 時不保存傳送結果
+func NewDispatcher(recorder Recorder, channels ...Channel) *Dispatcher {
+	return &Dispatcher{channels: channels, recorder: recorder}
+}
+
+// Channels 回傳已註冊的管道名稱
+func (d *Dispatcher) Channels() []string {
+	names := make([]string, len(d.channels))
+	for i, channel := range d.channels {
+		names[i] = channel.Name()
+	}
+	return names
+}
+
+// Dispatch 同時透過每個符合條件的管道傳送通知，回傳依管道註冊順序排列的結果。
+// 不符合條件或被 Policy 拒絕的管道記為 skipped，被延後的管道記為 deferred 並回傳 *DeferredError；
+// 任一管道失敗時回傳的 error 包含所有失敗的管道，其他管道的結果不受影響。
+// msg.ID 與 msg.CreatedAt 為空時自動產生。
+func (d *Dispatcher) Dispatch(ctx context.Context, recipient Recipient, msg Message) ([]Result, error) {
+	msg, err := prepare(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, len(d.channels))
+	errs := make([]error, len(d.channels))
+	var wg sync.WaitGroup
+	for i, channel := range d.channels {
+		wg.Add(1)
+		go func(i int, channel Channel) {
+			defer wg.Done()
+			results[i], errs[i] = d.deliver(ctx, channel, recipient, msg)
+		}(i, channel)
+	}
+	wg.Wait()
+
+	if err := d.record(ctx, recipient, msg, results); err != nil {
+		errs = append(errs, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// Send 只透過名為 channel 的管道傳送通知並記錄結果，供逐一管道重試使用；
+// 找不到管道（例如設定已變更）或會員不符合條件時記為 skipped。
+// 記錄失敗只寫入 log，不回傳錯誤，以免呼叫端重試時重複傳送已送出的通知
+func (d *Dispatcher) Send(ctx context.Context, recipient Recipient, msg Message, channel string) (Result, error) {
+	msg, err := prepare(msg)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Channel: channel, Status: StatusSkipped}
+	var sendErr error
+	for _, c := range d.channels {
+		if c.Name() == channel {
+			result, sendErr = d.deliver(ctx, c, recipient, msg)
+			break
+		}
+	}
+
+	if err := d.record(ctx, recipient, msg, []Result{result}); err != nil {
+		log.Printf("Warning: notification %s sent via %s but not recorded: %v\n", msg.ID, channel, err)
+	}
+	return result, sendErr
+}
+
+// prepare 補上通知的 ID 與建立時間
+func prepare(msg Message) (Message, error) {
+	if msg.ID == "" {
+		id, err := NewMessageID()
+		if err != nil {
+			return msg, err
+		}
+		msg.ID = id
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+	return msg, nil
+}
+
+// deliver 透過單一管道傳送通知，會員不符合條件或 Policy 不允許時不傳送
+func (d *Dispatcher) deliver(ctx context.Context, channel Channel, recipient Recipient, msg Message) (Result, error) {
+	result := Result{Channel: channel.Name(), Status: StatusSkipped}
+	if !channel.Eligible(recipient) {
+		return result, nil
+	}
+
+	if d.Policy != nil {
+		decision, err := d.Policy.Decide(ctx, recipient, msg, channel.Name())
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			return result, fmt.Errorf("%s: %w", channel.Name(), err)
+		}
+		if !decision.Allow {
+			result.Error = decision.Reason
+			return result, nil
+		}
+		if !decision.DeferUntil.IsZero() {
+			deferred := &DeferredError{Channel: channel.Name(), Until: decision.DeferUntil}
+			result.Status = StatusDeferred
+			result.Error = deferred.Error()
+			return result, deferred
+		}
+	}
+
+	timeout := d.SendTimeout
+	if timeout <= 0 {
+		timeout = DefaultSendTimeout
+	}
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := channel.Send(sendCtx, recipient, msg)
+	result.Elapsed = time.Since(start)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		return result, fmt.Errorf("%s: %w", channel.Name(), err)
+	}
+	result.Status = StatusSent
+	return result, nil
+}
+
+// record 保存傳送結果
+func (d *Dispatcher) record(ctx context.Context, recipient Recipient, msg Message, results []Result) error {
+	if d.recorder == nil {
+		return nil
+	}
+	if err := d.recorder.RecordDeliveries(ctx, recipient, msg, results); err != nil {
+		return fmt.Errorf("record deliveries: %w", err)
+	}
+	return nil
+}
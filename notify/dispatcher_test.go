@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"member_API/mailer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder 將傳送結果保存在記憶體中
+type memoryRecorder struct {
+	msg     Message
+	results []Result
+	err     error
+}
+
+func (r *memoryRecorder) RecordDeliveries(_ context.Context, _ Recipient, msg Message, results []Result) error {
+	r.msg = msg
+	r.results = results
+	return r.err
+}
+
+func TestDispatch(t *testing.T) {
+	recipient := Recipient{MemberID: 7, Name: "張三", Email: "zhang@example.com", EmailVerified: true}
+	msg := Message{Type: "account.password_changed", Title: "密碼已變更", Body: "您的密碼已變更"}
+
+	t.Run("分送到每個符合條件的管道", func(t *testing.T) {
+		inbox := NewRecordingChannel(ChannelInbox)
+		email := NewRecordingChannel(ChannelEmail)
+		recorder := &memoryRecorder{}
+
+		results, err := NewDispatcher(recorder, inbox, email).Dispatch(context.Background(), recipient, msg)
+		require.NoError(t, err)
+
+		require.Len(t, results, 2)
+		assert.Equal(t, ChannelInbox, results[0].Channel)
+		assert.Equal(t, StatusSent, results[0].Status)
+		assert.Equal(t, ChannelEmail, results[1].Channel)
+		assert.Equal(t, StatusSent, results[1].Status)
+
+		require.Len(t, inbox.Deliveries(), 1)
+		require.Len(t, email.Deliveries(), 1)
+		sent := inbox.Deliveries()[0].Message
+		assert.Len(t, sent.ID, 32, "未指定 ID 時自動產生")
+		assert.False(t, sent.CreatedAt.IsZero())
+		assert.Equal(t, sent.ID, email.Deliveries()[0].Message.ID, "各管道的通知 ID 相同")
+
+		assert.Equal(t, sent.ID, recorder.msg.ID)
+		assert.Equal(t, results, recorder.results)
+	})
+
+	t.Run("不符合條件的管道記為 skipped", func(t *testing.T) {
+		inbox := NewRecordingChannel(ChannelInbox)
+		email := NewRecordingChannel(ChannelEmail)
+		email.EligibleFunc = func(r Recipient) bool { return r.EmailVerified }
+
+		unverified := recipient
+		unverified.EmailVerified = false
+		results, err := NewDispatcher(nil, inbox, email).Dispatch(context.Background(), unverified, msg)
+		require.NoError(t, err)
+
+		assert.Equal(t, StatusSent, results[0].Status)
+		assert.Equal(t, StatusSkipped, results[1].Status)
+		assert.Empty(t, email.Deliveries())
+	})
+
+	t.Run("單一管道失敗不影響其他管道", func(t *testing.T) {
+		inbox := NewRecordingChannel(ChannelInbox)
+		webhook := NewRecordingChannel(ChannelWebhook)
+		webhook.Err = errors.New("connection refused")
+		recorder := &memoryRecorder{}
+
+		results, err := NewDispatcher(recorder, webhook, inbox).Dispatch(context.Background(), recipient, msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "webhook: connection refused")
+		assert.ErrorIs(t, err, webhook.Err)
+
+		assert.Equal(t, StatusFailed, results[0].Status)
+		assert.Equal(t, "connection refused", results[0].Error)
+		assert.Equal(t, StatusSent, results[1].Status)
+		assert.Len(t, inbox.Deliveries(), 1)
+		assert.Equal(t, results, recorder.results, "失敗也要記錄")
+	})
+
+	t.Run("保留呼叫者指定的 ID", func(t *testing.T) {
+		inbox := NewRecordingChannel(ChannelInbox)
+		withID := msg
+		withID.ID = "evt-1"
+		withID.CreatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		_, err := NewDispatcher(nil, inbox).Dispatch(context.Background(), recipient, withID)
+		require.NoError(t, err)
+		assert.Equal(t, withID, inbox.Deliveries()[0].Message)
+	})
+
+	t.Run("記錄失敗時回傳錯誤", func(t *testing.T) {
+		recorder := &memoryRecorder{err: errors.New("db down")}
+		results, err := NewDispatcher(recorder, NewRecordingChannel(ChannelInbox)).Dispatch(context.Background(), recipient, msg)
+		require.Error(t, err)
+		assert.Equal(t, StatusSent, results[0].Status)
+	})
+}
+
+func TestSend(t *testing.T) {
+	recipient := Recipient{MemberID: 7, Email: "zhang@example.com"}
+	msg := Message{ID: "evt-1", Type: "admin.message", Title: "系統維護通知"}
+
+	inbox := NewRecordingChannel(ChannelInbox)
+	email := NewRecordingChannel(ChannelEmail)
+	email.EligibleFunc = func(r Recipient) bool { return r.EmailVerified }
+	webhook := NewRecordingChannel(ChannelWebhook)
+	webhook.Err = errors.New("connection refused")
+	recorder := &memoryRecorder{}
+	dispatcher := NewDispatcher(recorder, inbox, email, webhook)
+
+	tests := []struct {
+		name    string
+		channel string
+		status  string
+		wantErr bool
+	}{
+		{name: "只傳送指定的管道", channel: ChannelInbox, status: StatusSent},
+		{name: "不符合條件", channel: ChannelEmail, status: StatusSkipped},
+		{name: "傳送失敗", channel: ChannelWebhook, status: StatusFailed, wantErr: true},
+		{name: "未註冊的管道", channel: "sms", status: StatusSkipped},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := dispatcher.Send(context.Background(), recipient, msg, tt.channel)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.channel, result.Channel)
+			assert.Equal(t, tt.status, result.Status)
+			assert.Equal(t, []Result{result}, recorder.results)
+		})
+	}
+
+	assert.Len(t, inbox.Deliveries(), 1)
+	assert.Empty(t, email.Deliveries())
+
+	t.Run("記錄失敗時不回傳錯誤，避免重試時重複傳送", func(t *testing.T) {
+		failing := NewDispatcher(&memoryRecorder{err: errors.New("db down")}, inbox)
+		result, err := failing.Send(context.Background(), recipient, msg, ChannelInbox)
+		require.NoError(t, err)
+		assert.Equal(t, StatusSent, result.Status)
+	})
+}
+
+// policyFunc 以函式實作 Policy
+type policyFunc func(ctx context.Context, recipient Recipient, msg Message, channel string) (Decision, error)
+
+func (f policyFunc) Decide(ctx context.Context, recipient Recipient, msg Message, channel string) (Decision, error) {
+	return f(ctx, recipient, msg, channel)
+}
+
+func TestDispatchPolicy(t *testing.T) {
+	recipient := Recipient{MemberID: 7, Name: "張三", Email: "zhang@example.com", EmailVerified: true}
+	msg := Message{Type: "announcement", Title: "新功能上線", Body: "歡迎試用"}
+	until := time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC)
+
+	inbox := NewRecordingChannel(ChannelInbox)
+	email := NewRecordingChannel(ChannelEmail)
+	webhook := NewRecordingChannel(ChannelWebhook)
+	recorder := &memoryRecorder{}
+
+	d := NewDispatcher(recorder, inbox, email, webhook)
+	d.Policy = policyFunc(func(_ context.Context, _ Recipient, _ Message, channel string) (Decision, error) {
+		switch channel {
+		case ChannelEmail:
+			return Decision{Reason: "disabled by member preference"}, nil
+		case ChannelWebhook:
+			return Decision{Allow: true, DeferUntil: until}, nil
+		}
+		return Decision{Allow: true}, nil
+	})
+
+	results, err := d.Dispatch(context.Background(), recipient, msg)
+	var deferred *DeferredError
+	require.True(t, errors.As(err, &deferred), "延後的管道應回傳 *DeferredError，實際為 %v", err)
+	assert.Equal(t, ChannelWebhook, deferred.Channel)
+	assert.True(t, until.Equal(deferred.Until))
+
+	require.Len(t, results, 3)
+	assert.Equal(t, StatusSent, results[0].Status)
+	assert.Equal(t, StatusSkipped, results[1].Status)
+	assert.Equal(t, "disabled by member preference", results[1].Error)
+	assert.Equal(t, StatusDeferred, results[2].Status)
+	assert.Equal(t, results, recorder.results)
+
+	assert.Len(t, inbox.Deliveries(), 1)
+	assert.Empty(t, email.Deliveries(), "被拒絕的管道不傳送")
+	assert.Empty(t, webhook.Deliveries(), "被延後的管道不傳送")
+
+	t.Run("Policy 失敗時管道記為 failed", func(t *testing.T) {
+		d := NewDispatcher(nil, NewRecordingChannel(ChannelInbox))
+		d.Policy = policyFunc(func(context.Context, Recipient, Message, string) (Decision, error) {
+			return Decision{}, errors.New("db down")
+		})
+
+		results, err := d.Dispatch(context.Background(), recipient, msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "inbox: db down")
+		assert.Equal(t, StatusFailed, results[0].Status)
+	})
+}
+
+func TestEmailChannel(t *testing.T) {
+	outbox := mailer.NewOutboxMailer("")
+	channel := NewEmailChannel(outbox)
+
+	assert.True(t, channel.Eligible(Recipient{Email: "zhang@example.com", EmailVerified: true}))
+	assert.False(t, channel.Eligible(Recipient{Email: "zhang@example.com"}), "email 未驗證")
+	assert.False(t, channel.Eligible(Recipient{EmailVerified: true}), "沒有 email")
+
+	err := channel.Send(context.Background(), Recipient{Email: "zhang@example.com", EmailVerified: true}, Message{Title: "密碼已變更", Body: "您的密碼已變更"})
+	require.NoError(t, err)
+
+	sent, ok := outbox.Last()
+	require.True(t, ok)
+	assert.Equal(t, []string{"zhang@example.com"}, sent.To)
+	assert.Equal(t, "密碼已變更", sent.Subject)
+	assert.Equal(t, "您的密碼已變更", sent.Text)
+}
@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"context"
+
+	"member_API/mailer"
+)
+
+// EmailChannel 以電子郵件寄送通知，只寄給 email 已驗證的會員
+type EmailChannel struct {
+	Mailer mailer.Mailer
+}
+
+// NewEmailChannel 建立電子郵件管道
+func NewEmailChannel(m mailer.Mailer) *EmailChannel {
+	return &EmailChannel{Mailer: m}
+}
+
+func (c *EmailChannel) Name() string { return ChannelEmail }
+
+func (c *EmailChannel) Eligible(recipient Recipient) bool {
+	return recipient.Email != "" && recipient.EmailVerified
+}
+
+func (c *EmailChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	return c.Mailer.Send(ctx, mailer.Message{
+		To:      []string{recipient.Email},
+		Subject: msg.Title,
+		Text:    msg.Body,
+		HTML:    msg.HTML,
+	})
+}
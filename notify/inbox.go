@@ -0,0 +1,26 @@
+package notify
+
+import "context"
+
+// Inbox 保存站內通知的儲存層，由 services.NotificationService 實作
+type Inbox interface {
+	DeliverToInbox(ctx context.Context, memberID uint, msg Message) error
+}
+
+// InboxChannel 將通知放入會員的站內收件匣，所有會員皆符合條件
+type InboxChannel struct {
+	Inbox Inbox
+}
+
+// NewInboxChannel 建立站內收件匣管道
+func NewInboxChannel(inbox Inbox) *InboxChannel {
+	return &InboxChannel{Inbox: inbox}
+}
+
+func (c *InboxChannel) Name() string { return ChannelInbox }
+
+func (c *InboxChannel) Eligible(recipient Recipient) bool { return recipient.MemberID != 0 }
+
+func (c *InboxChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	return c.Inbox.DeliverToInbox(ctx, recipient.MemberID, msg)
+}
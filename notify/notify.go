@@ -0,0 +1,59 @@
+// Package notify 將同一則通知透過多個管道（站內收件匣、電子郵件、webhook 等）送給會員。
+// 每個管道實作 Channel，Dispatcher 依會員是否符合各管道的條件分送，並記錄每個管道的結果。
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// 內建管道名稱
+const (
+	ChannelInbox   = "inbox"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Message 一則通知；ID 為事件的唯一識別碼，同一則通知在各管道與重試時都相同。
+// HTML 為選填的 HTML 內文，只有電子郵件使用；Urgent 的通知在會員的勿擾時段仍會立即傳送
+type Message struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Body      string                 `json:"body"`
+	HTML      string                 `json:"html,omitempty"`
+	Urgent    bool                   `json:"urgent,omitempty"`
+	Payload   map[string]interface{} `json:"payload,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// Recipient 通知的收件會員
+type Recipient struct {
+	MemberID      uint   `json:"member_id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	// Locale 會員偏好的語系，用於選擇通知範本
+	Locale string `json:"locale"`
+}
+
+// Channel 通知的傳送管道
+type Channel interface {
+	// Name 管道名稱，記錄在傳送結果中
+	Name() string
+	// Eligible 會員是否可以透過此管道接收通知，例如電子郵件需要已驗證的 email
+	Eligible(recipient Recipient) bool
+	// Send 傳送通知
+	Send(ctx context.Context, recipient Recipient, msg Message) error
+}
+
+// NewMessageID 產生隨機的通知 ID（32 個十六進位字元）
+func NewMessageID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Delivery RecordingChannel 保存的一次傳送
+type Delivery struct {
+	Recipient Recipient
+	Message   Message
+}
+
+// RecordingChannel 不實際傳送，而是將通知保存在記憶體中，適用於測試與停用外部管道的環境。
+// Err 不為 nil 時 Send 回傳該錯誤（不保存），EligibleFunc 為 nil 時所有會員皆符合條件
+type RecordingChannel struct {
+	ChannelName  string
+	EligibleFunc func(Recipient) bool
+	Err          error
+
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+// NewRecordingChannel 建立名為 name 的記錄管道
+func NewRecordingChannel(name string) *RecordingChannel {
+	return &RecordingChannel{ChannelName: name}
+}
+
+func (c *RecordingChannel) Name() string { return c.ChannelName }
+
+func (c *RecordingChannel) Eligible(recipient Recipient) bool {
+	return c.EligibleFunc == nil || c.EligibleFunc(recipient)
+}
+
+func (c *RecordingChannel) Send(_ context.Context, recipient Recipient, msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Err != nil {
+		return c.Err
+	}
+	c.deliveries = append(c.deliveries, Delivery{Recipient: recipient, Message: msg})
+	return nil
+}
+
+// Deliveries 回傳目前保存的所有傳送
+func (c *RecordingChannel) Deliveries() []Delivery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Delivery(nil), c.deliveries...)
+}
+
+// Reset 清空保存的傳送
+func (c *RecordingChannel) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deliveries = nil
+}
@@ -0,0 +1,312 @@
+package notify
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// ErrUnknownVariable 範本引用了事件沒有提供的變數
+var ErrUnknownVariable = errors.New("unknown template variable")
+
+// TemplateKey 範本的識別：事件類型、管道與語系
+type TemplateKey struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Locale  string `json:"locale"`
+}
+
+// Template 一則通知在單一管道與語系的文字。Subject 與 Text 以 text/template 渲染，
+// HTML 以 html/template 渲染（變數會自動跳脫，只用於電子郵件，可留空）
+type Template struct {
+	Subject string `json:"subject" example:"您的密碼已變更"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Rendered 渲染後的通知文字；站內收件匣與 webhook 以 Subject 為標題、Text 為內文
+type Rendered struct {
+	Subject string `json:"subject" example:"您的密碼已變更"`
+	Text    string `json:"text" example:"張三 您好：您的帳號密碼已於 2025-01-01 12:00 變更。"`
+	HTML    string `json:"html,omitempty" example:"<p>張三 您好：</p>"`
+}
+
+// TemplateError 範本語法錯誤或引用了未知的變數；Part 為 subject、text 或 html
+type TemplateError struct {
+	Part string
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Part, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// parsed 解析後的範本，未提供 HTML 時 html 為 nil
+type parsed struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// parse 解析範本的各部分；變數不存在時渲染失敗，而不是輸出 "<no value>"
+func (t Template) parse() (*parsed, error) {
+	if strings.TrimSpace(t.Subject) == "" {
+		return nil, &TemplateError{Part: "subject", Err: errors.New("must not be empty")}
+	}
+	if strings.TrimSpace(t.Text) == "" {
+		return nil, &TemplateError{Part: "text", Err: errors.New("must not be empty")}
+	}
+
+	var p parsed
+	var err error
+	if p.subject, err = texttemplate.New("subject").Option("missingkey=error").Parse(t.Subject); err != nil {
+		return nil, &TemplateError{Part: "subject", Err: err}
+	}
+	if p.text, err = texttemplate.New("text").Option("missingkey=error").Parse(t.Text); err != nil {
+		return nil, &TemplateError{Part: "text", Err: err}
+	}
+	if t.HTML != "" {
+		if p.html, err = htmltemplate.New("html").Option("missingkey=error").Parse(t.HTML); err != nil {
+			return nil, &TemplateError{Part: "html", Err: err}
+		}
+	}
+	return &p, nil
+}
+
+// Validate 檢查範本語法，並確認只引用 variables 中的變數（{{.Name}} 或 {{$.Name}}）
+func (t Template) Validate(variables []string) error {
+	p, err := t.parse()
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		known[v] = true
+	}
+
+	type part struct {
+		name  string
+		trees []*parse.Tree
+	}
+	parts := []part{
+		{"subject", textTrees(p.subject)},
+		{"text", textTrees(p.text)},
+	}
+	if p.html != nil {
+		parts = append(parts, part{"html", htmlTrees(p.html)})
+	}
+
+	for _, part := range parts {
+		refs := map[string]bool{}
+		for _, tree := range part.trees {
+			if tree != nil && tree.Root != nil {
+				collectVariables(tree.Root, true, refs)
+			}
+		}
+		var unknown []string
+		for name := range refs {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return &TemplateError{Part: part.name, Err: fmt.Errorf("%w: %s", ErrUnknownVariable, strings.Join(unknown, ", "))}
+		}
+	}
+	return nil
+}
+
+func textTrees(t *texttemplate.Template) []*parse.Tree {
+	var trees []*parse.Tree
+	for _, tmpl := range t.Templates() {
+		trees = append(trees, tmpl.Tree)
+	}
+	return trees
+}
+
+func htmlTrees(t *htmltemplate.Template) []*parse.Tree {
+	var trees []*parse.Tree
+	for _, tmpl := range t.Templates() {
+		trees = append(trees, tmpl.Tree)
+	}
+	return trees
+}
+
+// collectVariables 收集以資料根層級為起點的欄位名稱；range 與 with 內的 "." 不是根層級，
+// 只能透過 $ 引用根層級的變數
+func collectVariables(node parse.Node, dotIsRoot bool, refs map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectVariables(child, dotIsRoot, refs)
+		}
+	case *parse.ActionNode:
+		collectVariables(n.Pipe, dotIsRoot, refs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectVariables(cmd, dotIsRoot, refs)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectVariables(arg, dotIsRoot, refs)
+		}
+	case *parse.ChainNode:
+		collectVariables(n.Node, dotIsRoot, refs)
+	case *parse.FieldNode:
+		if dotIsRoot && len(n.Ident) > 0 {
+			refs[n.Ident[0]] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			refs[n.Ident[1]] = true
+		}
+	case *parse.IfNode:
+		collectVariables(n.Pipe, dotIsRoot, refs)
+		collectVariables(n.List, dotIsRoot, refs)
+		collectVariables(n.ElseList, dotIsRoot, refs)
+	case *parse.RangeNode:
+		collectVariables(n.Pipe, dotIsRoot, refs)
+		collectVariables(n.List, false, refs)
+		collectVariables(n.ElseList, dotIsRoot, refs)
+	case *parse.WithNode:
+		collectVariables(n.Pipe, dotIsRoot, refs)
+		collectVariables(n.List, false, refs)
+		collectVariables(n.ElseList, dotIsRoot, refs)
+	case *parse.TemplateNode:
+		collectVariables(n.Pipe, dotIsRoot, refs)
+	}
+}
+
+// Render 以 data 渲染範本；標題中的換行與連續空白會合併為單一空白
+func (t Template) Render(data map[string]interface{}) (Rendered, error) {
+	p, err := t.parse()
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	var out Rendered
+	var b strings.Builder
+	if err := p.subject.Execute(&b, data); err != nil {
+		return Rendered{}, &TemplateError{Part: "subject", Err: err}
+	}
+	out.Subject = strings.Join(strings.Fields(b.String()), " ")
+
+	b.Reset()
+	if err := p.text.Execute(&b, data); err != nil {
+		return Rendered{}, &TemplateError{Part: "text", Err: err}
+	}
+	out.Text = b.String()
+
+	if p.html != nil {
+		b.Reset()
+		if err := p.html.Execute(&b, data); err != nil {
+			return Rendered{}, &TemplateError{Part: "html", Err: err}
+		}
+		out.HTML = b.String()
+	}
+	return out, nil
+}
+
+// ParseTemplateFile 解析範本檔。檔案以 "--- subject"、"--- text" 與選填的 "--- html" 分隔各部分，
+// 每部分結尾多餘的空行會被移除
+func ParseTemplateFile(src string) (Template, error) {
+	var t Template
+	var current *string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if section, ok := strings.CutPrefix(line, "--- "); ok {
+			section = strings.TrimSpace(section)
+			if seen[section] {
+				return Template{}, fmt.Errorf("duplicate section %q", section)
+			}
+			seen[section] = true
+			switch section {
+			case "subject":
+				current = &t.Subject
+			case "text":
+				current = &t.Text
+			case "html":
+				current = &t.HTML
+			default:
+				return Template{}, fmt.Errorf("unknown section %q", section)
+			}
+			continue
+		}
+		if current == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return Template{}, errors.New("content before the first section")
+		}
+		*current += line + "\n"
+	}
+	if err := scanner.Err(); err != nil {
+		return Template{}, err
+	}
+
+	t.Subject = strings.TrimSpace(t.Subject)
+	t.Text = strings.TrimRight(t.Text, "\n") + "\n"
+	t.HTML = strings.TrimRight(t.HTML, "\n")
+	if t.HTML != "" {
+		t.HTML += "\n"
+	}
+	if _, err := t.parse(); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// LoadTemplates 從 fsys 載入範本檔，路徑格式為 <語系>/<事件類型>.<管道>.tmpl，
+// 例如 zh-TW/account.password_reset.email.tmpl
+func LoadTemplates(fsys fs.FS) (map[TemplateKey]Template, error) {
+	templates := map[TemplateKey]Template{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+			return err
+		}
+
+		locale, file := path.Split(p)
+		locale = strings.Trim(locale, "/")
+		name := strings.TrimSuffix(file, ".tmpl")
+		dot := strings.LastIndex(name, ".")
+		if locale == "" || strings.Contains(locale, "/") || dot <= 0 {
+			return fmt.Errorf("%s: expected <locale>/<type>.<channel>.tmpl", p)
+		}
+
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		t, err := ParseTemplateFile(string(src))
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		templates[TemplateKey{Type: name[:dot], Channel: name[dot+1:], Locale: locale}] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
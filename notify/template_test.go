@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateValidate(t *testing.T) {
+	variables := []string{"Name", "Link", "Items"}
+
+	tests := []struct {
+		name     string
+		template Template
+		wantPart string
+		unknown  bool
+	}{
+		{"只使用已知變數", Template{Subject: "{{.Name}}", Text: "{{.Name}} {{.Link}}", HTML: `<a href="{{.Link}}">{{.Name}}</a>`}, "", false},
+		{"以 $ 引用根層級變數", Template{Subject: "hi", Text: "{{range .Items}}{{$.Name}}{{end}}"}, "", false},
+		{"range 內的欄位屬於元素", Template{Subject: "hi", Text: "{{range .Items}}{{.Title}}{{end}}"}, "", false},
+		{"with 內的欄位屬於目前的值", Template{Subject: "hi", Text: "{{with .Link}}{{.Host}}{{else}}{{.Name}}{{end}}"}, "", false},
+		{"標題引用未知變數", Template{Subject: "{{.Password}}", Text: "hi"}, "subject", true},
+		{"內文引用未知變數", Template{Subject: "hi", Text: "{{if .Token}}{{.Name}}{{end}}"}, "text", true},
+		{"HTML 以 $ 引用未知變數", Template{Subject: "hi", Text: "hi", HTML: "{{range .Items}}{{$.Secret}}{{end}}"}, "html", true},
+		{"define 中引用未知變數", Template{Subject: "hi", Text: `{{define "x"}}{{.Secret}}{{end}}{{template "x" .}}`}, "text", true},
+		{"語法錯誤", Template{Subject: "hi", Text: "{{.Name"}, "text", false},
+		{"未知的函式", Template{Subject: "hi", Text: "{{upper .Name}}"}, "text", false},
+		{"標題不可為空", Template{Subject: " ", Text: "hi"}, "subject", false},
+		{"內文不可為空", Template{Subject: "hi", Text: ""}, "text", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.template.Validate(variables)
+			if tt.wantPart == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var templateErr *TemplateError
+			require.True(t, errors.As(err, &templateErr), "應回傳 *TemplateError，實際為 %v", err)
+			assert.Equal(t, tt.wantPart, templateErr.Part)
+			assert.Equal(t, tt.unknown, errors.Is(err, ErrUnknownVariable))
+		})
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := Template{
+		Subject: "  {{.Name}}\n您好  ",
+		Text:    "{{.Name}} 您好：{{.Link}}\n",
+		HTML:    `<p>{{.Name}}</p><a href="{{.Link}}">連結</a>`,
+	}
+
+	rendered, err := tmpl.Render(map[string]interface{}{
+		"Name": "<張三>",
+		"Link": "https://app.example.com/x?a=1&b=2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "<張三> 您好", rendered.Subject, "標題的換行與多餘空白會合併")
+	assert.Equal(t, "<張三> 您好：https://app.example.com/x?a=1&b=2\n", rendered.Text, "純文字不跳脫")
+	assert.Equal(t, `<p>&lt;張三&gt;</p><a href="https://app.example.com/x?a=1&amp;b=2">連結</a>`, rendered.HTML, "HTML 自動跳脫")
+
+	t.Run("缺少變數時失敗", func(t *testing.T) {
+		_, err := tmpl.Render(map[string]interface{}{"Name": "張三"})
+		var templateErr *TemplateError
+		require.True(t, errors.As(err, &templateErr))
+		assert.Equal(t, "text", templateErr.Part)
+	})
+
+	t.Run("沒有 HTML 時不產生 HTML", func(t *testing.T) {
+		rendered, err := Template{Subject: "hi", Text: "hi"}.Render(nil)
+		require.NoError(t, err)
+		assert.Empty(t, rendered.HTML)
+	})
+}
+
+func TestParseTemplateFile(t *testing.T) {
+	tmpl, err := ParseTemplateFile("\n--- subject\n您的密碼已變更\n--- text\n{{.Name}} 您好：\n\n內文\n\n--- html\n<p>{{.Name}}</p>\n\n")
+	require.NoError(t, err)
+	assert.Equal(t, Template{
+		Subject: "您的密碼已變更",
+		Text:    "{{.Name}} 您好：\n\n內文\n",
+		HTML:    "<p>{{.Name}}</p>\n",
+	}, tmpl)
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"第一個區段前有內容", "hello\n--- subject\nhi\n--- text\nhi\n"},
+		{"未知的區段", "--- subject\nhi\n--- body\nhi\n"},
+		{"重複的區段", "--- subject\nhi\n--- text\nhi\n--- text\nhi\n"},
+		{"缺少內文", "--- subject\nhi\n"},
+		{"語法錯誤", "--- subject\nhi\n--- text\n{{.Name\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplateFile(tt.src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"zh-TW/account.password_changed.email.tmpl": {Data: []byte("--- subject\n密碼已變更\n--- text\n{{.Name}}\n")},
+		"en/account.password_changed.email.tmpl":    {Data: []byte("--- subject\nPassword changed\n--- text\n{{.Name}}\n")},
+		"README.md":                                 {Data: []byte("ignored")},
+	}
+
+	templates, err := LoadTemplates(fsys)
+	require.NoError(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "Password changed", templates[TemplateKey{Type: "account.password_changed", Channel: "email", Locale: "en"}].Subject)
+	assert.Equal(t, "密碼已變更", templates[TemplateKey{Type: "account.password_changed", Channel: "email", Locale: "zh-TW"}].Subject)
+
+	t.Run("路徑缺少語系", func(t *testing.T) {
+		_, err := LoadTemplates(fstest.MapFS{"account.password_changed.email.tmpl": {Data: []byte("--- subject\nhi\n--- text\nhi\n")}})
+		assert.Error(t, err)
+	})
+
+	t.Run("檔名缺少管道", func(t *testing.T) {
+		_, err := LoadTemplates(fstest.MapFS{"en/welcome.tmpl": {Data: []byte("--- subject\nhi\n--- text\nhi\n")}})
+		assert.Error(t, err)
+	})
+}
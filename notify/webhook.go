@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// webhook 請求的標頭
+const (
+	HeaderWebhookID        = "X-Notification-Id"
+	HeaderWebhookTimestamp = "X-Notification-Timestamp"
+	HeaderWebhookSignature = "X-Notification-Signature"
+)
+
+// WebhookPayload webhook 請求的 JSON 內容
+type WebhookPayload struct {
+	Message
+	Recipient WebhookRecipient `json:"recipient"`
+}
+
+// WebhookRecipient webhook 中的收件會員（不含 email，避免將個人資料傳給外部服務）
+type WebhookRecipient struct {
+	MemberID uint `json:"member_id"`
+}
+
+// WebhookError 接收端回應非 2xx 狀態碼
+type WebhookError struct {
+	StatusCode int
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status %d", e.StatusCode)
+}
+
+// WebhookChannel 以 HTTP POST 將通知送到外部服務。設定 Secret 時，
+// X-Notification-Signature 為 "sha256=" 加上以 Secret 對「timestamp.body」計算的 HMAC-SHA256（十六進位），
+// 接收端應驗證簽章並拒絕 timestamp 過舊的請求
+type WebhookChannel struct {
+	URL    string
+	Secret string
+	Client *http.Client
+
+	now func() time.Time
+}
+
+// NewWebhookChannel 建立 webhook 管道，client 為 nil 時使用 http.DefaultClient
+func NewWebhookChannel(url, secret string, client *http.Client) *WebhookChannel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookChannel{URL: url, Secret: secret, Client: client, now: time.Now}
+}
+
+func (c *WebhookChannel) Name() string { return ChannelWebhook }
+
+func (c *WebhookChannel) Eligible(recipient Recipient) bool { return c.URL != "" }
+
+func (c *WebhookChannel) Send(ctx context.Context, recipient Recipient, msg Message) error {
+	body, err := json.Marshal(WebhookPayload{
+		Message:   msg,
+		Recipient: WebhookRecipient{MemberID: recipient.MemberID},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(c.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, msg.ID)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	if c.Secret != "" {
+		req.Header.Set(HeaderWebhookSignature, SignWebhook(c.Secret, timestamp, body))
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &WebhookError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// SignWebhook 計算 webhook 簽章，供接收端以相同方式驗證
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookChannel(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+		status = http.StatusNoContent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(server.URL, "s3cret", server.Client())
+	channel.now = func() time.Time { return time.Unix(1700000000, 0) }
+	recipient := Recipient{MemberID: 7, Email: "zhang@example.com"}
+	msg := Message{ID: "evt-1", Type: "account.password_changed", Title: "密碼已變更", Payload: map[string]interface{}{"ip": "203.0.113.1"}}
+
+	t.Run("送出簽章的 JSON", func(t *testing.T) {
+		require.NoError(t, channel.Send(context.Background(), recipient, msg))
+
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "evt-1", header.Get(HeaderWebhookID))
+		assert.Equal(t, "1700000000", header.Get(HeaderWebhookTimestamp))
+		assert.Equal(t, SignWebhook("s3cret", "1700000000", body), header.Get(HeaderWebhookSignature))
+		assert.NotEqual(t, SignWebhook("other", "1700000000", body), header.Get(HeaderWebhookSignature))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, "evt-1", payload["id"])
+		assert.Equal(t, "account.password_changed", payload["type"])
+		assert.Equal(t, map[string]interface{}{"member_id": float64(7)}, payload["recipient"])
+		assert.NotContains(t, string(body), "zhang@example.com", "不傳送 email")
+	})
+
+	t.Run("非 2xx 回應視為失敗", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		defer func() { status = http.StatusNoContent }()
+
+		err := channel.Send(context.Background(), recipient, msg)
+		var webhookErr *WebhookError
+		require.True(t, errors.As(err, &webhookErr))
+		assert.Equal(t, http.StatusServiceUnavailable, webhookErr.StatusCode)
+	})
+
+	t.Run("未設定 secret 時不簽章", func(t *testing.T) {
+		unsigned := NewWebhookChannel(server.URL, "", server.Client())
+		require.NoError(t, unsigned.Send(context.Background(), recipient, msg))
+		assert.Empty(t, header.Get(HeaderWebhookSignature))
+	})
+
+	t.Run("未設定 URL 時不符合條件", func(t *testing.T) {
+		assert.True(t, channel.Eligible(recipient))
+		assert.False(t, NewWebhookChannel("", "", nil).Eligible(recipient))
+	})
+}
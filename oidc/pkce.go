@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// randomString 產生 size 位元組的隨機值並以 base64url（無 padding）編碼
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateState 產生授權請求的 state
+func GenerateState() (string, error) {
+	return randomString(24)
+}
+
+// GenerateNonce 產生綁定在 ID token 中的 nonce
+func GenerateNonce() (string, error) {
+	return randomString(24)
+}
+
+// GenerateVerifier 產生 PKCE code verifier（RFC 7636，43 個字元）
+func GenerateVerifier() (string, error) {
+	return randomString(32)
+}
+
+// S256Challenge 計算 PKCE S256 code challenge
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
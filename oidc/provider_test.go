@@ -0,0 +1,384 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdP 以 httptest 實作的最小 OIDC IdP：discovery、授權、token 與 JWKS 端點
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+
+	clientID     string
+	clientSecret string
+
+	mu        sync.Mutex
+	key       *rsa.PrivateKey
+	kid       string
+	codes     map[string]pendingCode
+	jwksCalls int
+
+	// 覆寫 ID token 的 claims，用於測試驗證失敗的情況
+	mutateClaims func(claims jwt.MapClaims)
+	email        string
+}
+
+// pendingCode 授權端點發出、尚未兌換的授權碼
+type pendingCode struct {
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{
+		t:            t,
+		clientID:     "member-api",
+		clientSecret: "s3cret/with+chars",
+		key:          key,
+		kid:          "key-1",
+		codes:        make(map[string]pendingCode),
+		email:        "staff@corp.example",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (m *mockIdP) issuer() string { return m.server.URL }
+
+func (m *mockIdP) provider() *Provider {
+	return NewProvider(Config{
+		Name:         "corp",
+		Issuer:       m.issuer(),
+		ClientID:     m.clientID,
+		ClientSecret: m.clientSecret,
+		RedirectURL:  "http://localhost:8080/api/v1/oidc/corp/callback",
+		Scopes:       []string{"email", "profile"},
+	}, m.server.Client())
+}
+
+func (m *mockIdP) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 m.issuer(),
+		"authorization_endpoint": m.issuer() + "/authorize",
+		"token_endpoint":         m.issuer() + "/token",
+		"jwks_uri":               m.issuer() + "/jwks",
+	})
+}
+
+// authorize 模擬使用者同意後，直接以 302 導回 redirect_uri 並附上授權碼
+func (m *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != m.clientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := randomString(16)
+	require.NoError(m.t, err)
+
+	m.mu.Lock()
+	m.codes[code] = pendingCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), redirectURI: q.Get("redirect_uri")}
+	m.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	oauthError := func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	if id != m.clientID || secret != m.clientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		oauthError("invalid_request")
+		return
+	}
+
+	m.mu.Lock()
+	pending, found := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !found || pending.redirectURI != r.PostForm.Get("redirect_uri") {
+		oauthError("invalid_grant")
+		return
+	}
+	if S256Challenge(r.PostForm.Get("code_verifier")) != pending.challenge {
+		oauthError("invalid_grant")
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.idToken(pending.nonce),
+	})
+}
+
+func (m *mockIdP) idToken(nonce string) string {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.issuer(),
+		"sub":            "user-123",
+		"aud":            m.clientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          m.email,
+		"email_verified": true,
+		"name":           "Corp Staff",
+	}
+	if m.mutateClaims != nil {
+		m.mutateClaims(claims)
+	}
+
+	m.mu.Lock()
+	key, kid := m.key, m.kid
+	m.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(m.t, err)
+	return signed
+}
+
+func (m *mockIdP) jwks(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jwksCalls++
+
+	public := m.key.PublicKey
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+// rotateKey 模擬 IdP 輪替簽章金鑰
+func (m *mockIdP) rotateKey(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m.mu.Lock()
+	m.key, m.kid = key, kid
+	m.mu.Unlock()
+}
+
+// login 模擬完整的瀏覽器流程，回傳回呼收到的授權碼與保存的 state
+func login(t *testing.T, idp *mockIdP, provider *Provider, store StateStore) (string, *AuthState) {
+	t.Helper()
+	ctx := context.Background()
+
+	state, err := GenerateState()
+	require.NoError(t, err)
+	nonce, err := GenerateNonce()
+	require.NoError(t, err)
+	verifier, err := GenerateVerifier()
+	require.NoError(t, err)
+	require.NoError(t, store.Save(ctx, state, AuthState{Provider: provider.Name, Verifier: verifier, Nonce: nonce}))
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	require.NoError(t, err)
+
+	client := idp.server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	saved, err := store.Take(ctx, callback.Query().Get("state"))
+	require.NoError(t, err)
+	return callback.Query().Get("code"), saved
+}
+
+func TestProviderAuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := parsed.Query()
+	assert.Equal(t, idp.issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "state-1", q.Get("state"))
+	assert.Equal(t, "nonce-1", q.Get("nonce"))
+	assert.Equal(t, S256Challenge("verifier-1"), q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+}
+
+func TestProviderAuthenticate(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	store := NewMemoryStateStore()
+
+	code, state := login(t, idp, provider, store)
+	claims, err := provider.Authenticate(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, "staff@corp.example", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "Corp Staff", claims.Name)
+
+	// 授權碼只能兌換一次
+	_, err = provider.Authenticate(context.Background(), code, state)
+	assert.Error(t, err)
+}
+
+func TestProviderAuthenticateFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(claims jwt.MapClaims)
+		tamper  func(state *AuthState)
+		domains []string
+		wantErr error
+	}{
+		{name: "nonce 不符", tamper: func(s *AuthState) { s.Nonce = "other" }, wantErr: ErrInvalidIDToken},
+		{name: "PKCE verifier 錯誤", tamper: func(s *AuthState) { s.Verifier = "wrong-verifier" }},
+		{name: "簽發者錯誤", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example" }, wantErr: ErrInvalidIDToken},
+		{name: "對象錯誤", mutate: func(c jwt.MapClaims) { c["aud"] = "other-client" }, wantErr: ErrInvalidIDToken},
+		{name: "多個對象但 azp 不符", mutate: func(c jwt.MapClaims) { c["aud"] = []string{"member-api", "other"}; c["azp"] = "other" }, wantErr: ErrInvalidIDToken},
+		{name: "已過期", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: ErrInvalidIDToken},
+		{name: "缺少 sub", mutate: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: ErrInvalidIDToken},
+		{name: "網域不允許", domains: []string{"other.example"}, wantErr: ErrDomainNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			idp.mutateClaims = tt.mutate
+			provider := idp.provider()
+			provider.AllowedDomains = tt.domains
+
+			code, state := login(t, idp, provider, NewMemoryStateStore())
+			if tt.tamper != nil {
+				tt.tamper(state)
+			}
+
+			claims, err := provider.Authenticate(context.Background(), code, state)
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderAllowedDomains(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	provider.AllowedDomains = []string{"CORP.example"}
+
+	code, state := login(t, idp, provider, NewMemoryStateStore())
+	claims, err := provider.Authenticate(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, "staff@corp.example", claims.Email)
+}
+
+func TestProviderKeyRotation(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := idp.provider()
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	store := NewMemoryStateStore()
+
+	code, state := login(t, idp, provider, store)
+	_, err := provider.Authenticate(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	// IdP 輪替金鑰後，短時間內不會重複下載 JWKS
+	idp.rotateKey(t, "key-2")
+	code, state = login(t, idp, provider, store)
+	_, err = provider.Authenticate(context.Background(), code, state)
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+	assert.Equal(t, 1, idp.jwksCalls)
+
+	// 超過間隔後遇到未知 kid 會重新下載
+	now = now.Add(jwksRefreshInterval + time.Second)
+	code, state = login(t, idp, provider, store)
+	_, err = provider.Authenticate(context.Background(), code, state)
+	require.NoError(t, err)
+	assert.Equal(t, 2, idp.jwksCalls)
+}
+
+func TestProviderDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	provider := NewProvider(Config{Name: "corp", Issuer: idp.issuer() + "/tenant", ClientID: idp.clientID}, idp.server.Client())
+
+	// discovery 文件位於 /tenant 之下不存在，且 issuer 不符
+	_, err := provider.Discover(context.Background())
+	assert.Error(t, err)
+
+	mismatch := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://attacker.example",
+			"authorization_endpoint": "https://attacker.example/authorize",
+			"token_endpoint":         "https://attacker.example/token",
+			"jwks_uri":               "https://attacker.example/jwks",
+		})
+	}))
+	defer mismatch.Close()
+
+	_, err = NewProvider(Config{Issuer: mismatch.URL, ClientID: "x"}, mismatch.Client()).Discover(context.Background())
+	assert.ErrorContains(t, err, "issuer mismatch")
+}
@@ -0,0 +1,74 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// StateTTL 授權請求從導向 IdP 到回呼的期限
+const StateTTL = 10 * time.Minute
+
+// ErrStateNotFound state 不存在、已使用或已過期
+var ErrStateNotFound = errors.New("oidc: state not found or expired")
+
+// AuthState 發起授權請求時保存、在回呼時取回的資料
+type AuthState struct {
+	Provider  string
+	Verifier  string
+	Nonce     string
+	ExpiresAt time.Time
+}
+
+// StateStore 保存進行中的授權請求；Take 取回後即刪除，確保 state 只能使用一次
+type StateStore interface {
+	Save(ctx context.Context, state string, data AuthState) error
+	Take(ctx context.Context, state string) (*AuthState, error)
+}
+
+// MemoryStateStore 以記憶體保存授權請求，只適用於單一實例或測試
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]AuthState
+	now    func() time.Time
+}
+
+// NewMemoryStateStore 建立記憶體 state store
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: make(map[string]AuthState), now: time.Now}
+}
+
+// Save 保存授權請求，並順便清除已過期的紀錄
+func (s *MemoryStateStore) Save(_ context.Context, state string, data AuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, existing := range s.states {
+		if now.After(existing.ExpiresAt) {
+			delete(s.states, key)
+		}
+	}
+	if data.ExpiresAt.IsZero() {
+		data.ExpiresAt = now.Add(StateTTL)
+	}
+	s.states[state] = data
+	return nil
+}
+
+// Take 取回並刪除授權請求
+func (s *MemoryStateStore) Take(_ context.Context, state string) (*AuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.states[state]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	delete(s.states, state)
+	if s.now().After(data.ExpiresAt) {
+		return nil, ErrStateNotFound
+	}
+	return &data, nil
+}
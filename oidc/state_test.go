@@ -0,0 +1,50 @@
+package oidc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Save(ctx, "state-1", AuthState{Provider: "corp", Verifier: "v", Nonce: "n"}))
+
+	data, err := store.Take(ctx, "state-1")
+	require.NoError(t, err)
+	assert.Equal(t, "corp", data.Provider)
+	assert.Equal(t, now.Add(StateTTL), data.ExpiresAt)
+
+	// state 只能使用一次
+	_, err = store.Take(ctx, "state-1")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+
+	_, err = store.Take(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+
+	// 過期的 state 無法取回，且會在下次 Save 時被清除
+	require.NoError(t, store.Save(ctx, "state-2", AuthState{Provider: "corp"}))
+	now = now.Add(StateTTL + time.Second)
+	_, err = store.Take(ctx, "state-2")
+	assert.ErrorIs(t, err, ErrStateNotFound)
+
+	require.NoError(t, store.Save(ctx, "state-3", AuthState{Provider: "corp", ExpiresAt: now.Add(-time.Second)}))
+	require.NoError(t, store.Save(ctx, "state-4", AuthState{Provider: "corp"}))
+	assert.Len(t, store.states, 1)
+}
+
+func TestPKCE(t *testing.T) {
+	// RFC 7636 附錄 B 範例
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256Challenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+
+	verifier, err := GenerateVerifier()
+	require.NoError(t, err)
+	assert.Len(t, verifier, 43)
+}
@@ -6,26 +6,51 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"member_API/auth"
+	"member_API/config"
 	"member_API/controllers"
 	"member_API/graphql"
 )
 
 // SetupRouter registers API routes on the provided Gin engine.
-func SetupRouter(Router *gin.Engine) {
+func SetupRouter(Router *gin.Engine, cfg *config.Config) {
 	Router.GET("/Hello", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "Hello, RESTful API!"})
 	})
 
+	// Public signing keys for validating issued tokens
+	Router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+
 	// Public - no authentication required
 	public := Router.Group("/api/v1")
 	{
 		// Authentication-related routes
 		public.POST("/register", controllers.Register)
 		public.POST("/login", controllers.Login)
+		public.POST("/login/mfa", controllers.LoginMFA)
+		public.POST("/token/refresh", controllers.RefreshToken)
+		public.POST("/password/forgot", controllers.ForgotPassword)
+		public.POST("/password/reset", controllers.ResetPassword)
+		public.POST("/email/verify", controllers.VerifyEmail)
+		public.POST("/email/change/confirm", controllers.ConfirmEmailChange)
+
+		// Passwordless login via a single-use link emailed to the member (each member opts in)
+		if cfg.Auth.MagicLinkLogin {
+			public.POST("/login/link", controllers.RequestMagicLink)
+			public.POST("/login/link/verify", controllers.MagicLinkLogin)
+		}
+
+		// Passkey (WebAuthn) login
+		public.POST("/login/passkey/begin", controllers.BeginPasskeyLogin)
+		public.POST("/login/passkey/finish", controllers.FinishPasskeyLogin)
+
+		// External login via OpenID Connect providers
+		public.GET("/oidc/providers", controllers.GetOIDCProviders)
+		public.GET("/oidc/:provider/login", controllers.OIDCLogin)
+		public.GET("/oidc/:provider/callback", controllers.OIDCCallback)
 	}
 
-	// GraphQL endpoint
-	Router.Any("/graphql", func(c *gin.Context) {
+	// GraphQL endpoint - credentials are optional here, access is enforced by schema directives
+	Router.Any("/graphql", auth.OptionalAuthMiddleware(), func(c *gin.Context) {
 		graphqlHandler := graphql.GetHandler()
 		if graphqlHandler == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "GraphQL handler not initialized"})
@@ -37,19 +62,120 @@ func SetupRouter(Router *gin.Engine) {
 	// Protected routes - require authentication
 	protected := Router.Group("/api/v1")
 	protected.Use(auth.AuthMiddleware()) // Add authentication middleware
+	// Impersonation tokens are read-only
+	protected.Use(auth.DenyImpersonatedWrites())
 	{
 		protected.GET("/users", controllers.GetUsers)
 		protected.GET("/user/:id", func(c *gin.Context) {
 			controllers.GetUserByID(c)
 		})
 		protected.GET("/profile", controllers.GetProfile) // Get current user information
-		protected.DELETE("/user/:id", controllers.DeleteUserByID)
+		protected.POST("/email/verify/resend", controllers.ResendVerificationEmail)
+		protected.DELETE("/user/:id", auth.RequirePermission(auth.PermMemberDelete), controllers.DeleteUserByID)
 
 		// Product routes
 		protected.GET("/products", controllers.GetProducts)
 		protected.GET("/product/:id", controllers.GetProductByID)
-		protected.POST("/product", controllers.CreateProduct)
-		protected.PUT("/product/:id", controllers.UpdateProduct)
-		protected.DELETE("/product/:id", controllers.DeleteProduct)
+
+		// Notification inbox of the current member
+		protected.GET("/notifications", controllers.ListNotifications)
+		protected.GET("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+		protected.POST("/notifications/read-all", controllers.MarkAllNotificationsRead)
+		protected.GET("/notifications/:id", controllers.GetNotification)
+		protected.DELETE("/notifications/:id", controllers.DeleteNotification)
+		protected.POST("/notifications/:id/read", controllers.MarkNotificationRead)
+		protected.DELETE("/notifications/:id/read", controllers.MarkNotificationUnread)
+		protected.POST("/notifications/:id/archive", controllers.ArchiveNotification)
+		protected.DELETE("/notifications/:id/archive", controllers.UnarchiveNotification)
+
+		// Notification preferences and quiet hours of the current member
+		protected.GET("/notification-preferences", controllers.GetNotificationPreferences)
+		protected.PUT("/notification-preferences", controllers.UpdateNotificationPreferences)
+		protected.PUT("/notification-preferences/quiet-hours", controllers.SetQuietHours)
+		protected.DELETE("/notification-preferences/quiet-hours", controllers.ClearQuietHours)
+	}
+
+	// Credential management - interactive sessions only, API keys and impersonation tokens are rejected
+	interactive := protected.Group("")
+	interactive.Use(auth.DenyAPIKey(), auth.DenyImpersonation())
+	{
+		interactive.POST("/logout", controllers.Logout)
+
+		// Credentials
+		interactive.POST("/password/change", controllers.ChangePassword)
+		interactive.POST("/email/change", controllers.ChangeEmail)
+
+		// Personal data export and account self-deletion
+		interactive.GET("/profile/export", controllers.ExportProfile)
+		interactive.POST("/profile/deletion", controllers.RequestAccountDeletion)
+		interactive.DELETE("/profile/deletion", controllers.CancelAccountDeletion)
+
+		// Opt in to passwordless login links
+		if cfg.Auth.MagicLinkLogin {
+			interactive.PUT("/profile/magic-link", controllers.SetMagicLinkLogin)
+		}
+
+		// Two-factor authentication
+		interactive.POST("/2fa/totp/enroll", controllers.EnrollTOTP)
+		interactive.POST("/2fa/totp/confirm", controllers.ConfirmTOTP)
+		interactive.POST("/2fa/totp/disable", controllers.DisableTOTP)
+
+		// Passkeys (WebAuthn credentials)
+		interactive.GET("/passkeys", controllers.ListPasskeys)
+		interactive.POST("/passkeys/register/begin", controllers.BeginPasskeyRegistration)
+		interactive.POST("/passkeys/register/finish", controllers.FinishPasskeyRegistration)
+		interactive.DELETE("/passkeys/:id", controllers.DeletePasskey)
+
+		// Active sessions (logged-in devices)
+		interactive.GET("/sessions", controllers.ListSessions)
+		interactive.DELETE("/sessions", controllers.RevokeAllSessions)
+		interactive.DELETE("/sessions/:id", controllers.RevokeSession)
+
+		// API keys for machine clients
+		interactive.GET("/api-keys", controllers.ListAPIKeys)
+		interactive.POST("/api-keys", controllers.CreateAPIKey)
+		interactive.DELETE("/api-keys/:id", controllers.RevokeAPIKey)
+	}
+
+	// Product write routes - require a verified email when REQUIRE_VERIFIED_EMAIL is enabled.
+	// The GraphQL product mutations apply the same rule (graphql.verifiedEmailMutations)
+	verified := protected.Group("")
+	if cfg.Auth.RequireVerifiedEmail {
+		verified.Use(auth.RequireVerifiedEmail())
+	}
+	{
+		verified.POST("/product", controllers.CreateProduct)
+		verified.PUT("/product/:id", controllers.UpdateProduct)    // owner or product:update, checked by the service
+		verified.DELETE("/product/:id", controllers.DeleteProduct) // owner or product:delete, checked by the service
+	}
+
+	// Admin routes - require the admin role (and 2FA when REQUIRE_ADMIN_MFA is enabled)
+	admin := protected.Group("/admin")
+	admin.Use(auth.RequireRole(auth.RoleAdmin))
+	if cfg.Auth.RequireAdminMFA {
+		admin.Use(auth.RequireMFA())
+	}
+	{
+		admin.GET("/members/:id/roles", controllers.GetMemberRoles)
+		admin.POST("/members/:id/roles", controllers.GrantMemberRole)
+		admin.DELETE("/members/:id/roles/:role", controllers.RevokeMemberRole)
+		admin.POST("/members/:id/revoke-tokens", controllers.RevokeMemberTokens)
+		admin.POST("/members/:id/unlock", controllers.UnlockMember)
+		admin.POST("/members/:id/notifications", controllers.SendNotification)
+		admin.POST("/members/:id/impersonate", auth.DenyAPIKey(), controllers.StartImpersonation)
+		admin.GET("/audit-logs", controllers.GetAuditLogs)
+
+		// Notification templates (built-in templates overridden per event type, channel and locale)
+		admin.GET("/notification-templates", controllers.ListNotificationTemplates)
+		admin.POST("/notification-templates/preview", controllers.PreviewNotificationTemplate)
+		admin.PUT("/notification-templates/:type/:channel/:locale", controllers.UpdateNotificationTemplate)
+		admin.DELETE("/notification-templates/:type/:channel/:locale", controllers.ResetNotificationTemplate)
+	}
+
+	// Ending an impersonation is the only write an impersonation token may perform
+	impersonation := Router.Group("/api/v1")
+	impersonation.Use(auth.AuthMiddleware())
+	{
+		impersonation.POST("/impersonation/stop", controllers.StopImpersonation)
 	}
 }
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PurposeEmailChange 變更 email 確認 token 的用途，payload 為新的 email
+	PurposeEmailChange = "email_change"
+	// EmailChangeTTL 變更 email 確認連結的有效期限
+	EmailChangeTTL = 24 * time.Hour
+)
+
+var (
+	// ErrEmailTaken email 已被其他會員使用
+	ErrEmailTaken = errors.New("email 已被使用")
+	// ErrEmailUnchanged 新 email 與目前相同
+	ErrEmailUnchanged = errors.New("新 email 與目前相同")
+	// ErrEmailChangeRequiresConfirmation 會員變更自己的 email 必須經過新信箱確認
+	ErrEmailChangeRequiresConfirmation = errors.New("變更 email 需透過確認信完成")
+	// ErrCurrentPasswordIncorrect 目前的密碼錯誤
+	ErrCurrentPasswordIncorrect = errors.New("目前的密碼錯誤")
+	// ErrPasswordNotSet 會員尚未設定密碼（例如以外部帳號登入建立），需先使用忘記密碼流程設定
+	ErrPasswordNotSet = errors.New("尚未設定密碼，請使用忘記密碼設定密碼")
+)
+
+// emailTaken 檢查 email 是否已被 excludeID 以外的會員使用；唯一索引涵蓋已刪除的會員，因此一併檢查
+func emailTaken(tx *gorm.DB, email string, excludeID uint) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.Member{}).Where("email = ? AND id <> ?", email, excludeID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkCurrentPassword 驗證會員目前的密碼
+func checkCurrentPassword(member *models.Member, password string) error {
+	if member.PasswordHash == "" {
+		return ErrPasswordNotSet
+	}
+	if !auth.CheckPassword(password, member.PasswordHash) {
+		return ErrCurrentPasswordIncorrect
+	}
+	return nil
+}
+
+// ChangePassword 以目前的密碼驗證後設定新密碼，並登出 currentSessionID 以外的所有裝置，回傳登出的裝置數。
+// 新密碼不符合密碼規則時回傳 *auth.PasswordPolicyError；API key 不受影響
+func (s *MemberService) ChangePassword(ctx context.Context, memberID, currentSessionID uint, currentPassword, newPassword string) (int, error) {
+	member, err := s.GetMemberByID(memberID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkCurrentPassword(member, currentPassword); err != nil {
+		return 0, err
+	}
+	if err := auth.ValidatePassword(newPassword, member.Name, member.Email); err != nil {
+		return 0, err
+	}
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(member).Updates(map[string]interface{}{
+			"password_hash":          hash,
+			"last_modifier_id":       memberID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+			return err
+		}
+		// 尚未綁定工作階段的舊 refresh token 無法區分裝置，一併撤銷
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("member_id = ? AND session_id = 0 AND revoked_at IS NULL", memberID).
+			Updates(map[string]interface{}{
+				"revoked_at":             &now,
+				"last_modification_time": &now,
+			}).Error; err != nil {
+			return err
+		}
+		// 密碼已變更，尚未完成的 email 變更需重新申請
+		if err := NewActionTokenService(tx).Invalidate(memberID, PurposeEmailChange); err != nil {
+			return err
+		}
+		msg, err := renderMail(tx, EventPasswordChanged, member, member.Email, map[string]interface{}{
+			"ChangedAt": now.Format("2006-01-02 15:04"),
+		})
+		if err != nil {
+			return err
+		}
+		return queueMail(tx, memberID, msg)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	revoked, err := NewSessionService(s.DB).RevokeAll(ctx, memberID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+// RequestEmailChange 以目前的密碼驗證後，寄送確認連結到新 email，並通知原 email。
+// 會員點擊確認連結後才會變更（見 ConfirmEmailChange）
+func (s *MemberService) RequestEmailChange(ctx context.Context, memberID uint, password, newEmail string) error {
+	member, err := s.GetMemberByID(memberID)
+	if err != nil {
+		return err
+	}
+	if err := checkCurrentPassword(member, password); err != nil {
+		return err
+	}
+	if newEmail == member.Email {
+		return ErrEmailUnchanged
+	}
+	taken, err := emailTaken(s.DB.WithContext(ctx), newEmail, member.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := NewActionTokenService(tx).Issue(member.ID, PurposeEmailChange, EmailChangeTTL, newEmail)
+		if err != nil {
+			return err
+		}
+
+		confirm, err := renderMail(tx, EventEmailChangeConfirm, member, newEmail, map[string]interface{}{
+			"NewEmail":       newEmail,
+			"Link":           appLink("/confirm-email-change?token=" + url.QueryEscape(token)),
+			"ExpiresInHours": int(EmailChangeTTL.Hours()),
+		})
+		if err != nil {
+			return err
+		}
+		if err := queueMail(tx, member.ID, confirm); err != nil {
+			return err
+		}
+
+		notice, err := renderMail(tx, EventEmailChangeRequested, member, member.Email, map[string]interface{}{
+			"NewEmail": newEmail,
+		})
+		if err != nil {
+			return err
+		}
+		return queueMail(tx, member.ID, notice)
+	})
+}
+
+// ConfirmEmailChange 以確認連結中的 token 完成 email 變更；新 email 視為已驗證
+func (s *MemberService) ConfirmEmailChange(ctx context.Context, token string) (*models.Member, error) {
+	var member models.Member
+	now := time.Now()
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := NewActionTokenService(tx).Consume(token, PurposeEmailChange)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("is_deleted = ?", false).First(&member, record.MemberID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrActionTokenInvalid
+			}
+			return err
+		}
+
+		taken, err := emailTaken(tx, record.Payload, member.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrEmailTaken
+		}
+
+		member.Email = record.Payload
+		member.EmailVerifiedAt = &now
+		return tx.Model(&member).Updates(map[string]interface{}{
+			"email":                  member.Email,
+			"email_verified_at":      &now,
+			"last_modifier_id":       member.ID,
+			"last_modification_time": &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
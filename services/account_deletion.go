@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnonymizedMemberName 匿名化後的會員名稱
+const AnonymizedMemberName = "已刪除的會員"
+
+var (
+	// ErrDeletionAlreadyScheduled 會員已申請刪除帳號
+	ErrDeletionAlreadyScheduled = errors.New("已申請刪除帳號")
+	// ErrDeletionNotScheduled 會員沒有待處理的刪除申請
+	ErrDeletionNotScheduled = errors.New("沒有待處理的刪除申請")
+)
+
+// AccountDeletionService 會員自行刪除帳號：申請後經過 GracePeriod 才匿名化，期間內可取消。
+// 匿名化會清除會員的個人資料與所有憑證，但保留會員紀錄本身，讓產品與稽核紀錄仍可對應
+type AccountDeletionService struct {
+	DB          *gorm.DB
+	GracePeriod time.Duration
+}
+
+func NewAccountDeletionService(db *gorm.DB, gracePeriod time.Duration) *AccountDeletionService {
+	return &AccountDeletionService{DB: db, GracePeriod: gracePeriod}
+}
+
+// anonymizedEmail 匿名化後的 email；保留唯一索引，且不會是可收信的地址
+func anonymizedEmail(memberID uint) string {
+	return fmt.Sprintf("deleted-%d@invalid", memberID)
+}
+
+// Schedule 以目前的密碼驗證後申請刪除帳號，回傳預定匿名化的時間。
+// 以外部帳號建立、尚未設定密碼的會員不需要密碼；錯誤的密碼與登入失敗一樣計入 policy 的失敗次數，
+// 鎖定期間回傳 *AccountLockedError
+func (s *AccountDeletionService) Schedule(ctx context.Context, memberID uint, password, clientIP, userAgent string, policy auth.LockoutPolicy) (time.Time, error) {
+	members := NewMemberService(s.DB)
+	member, err := members.GetMemberByID(memberID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// 先檢查是否已申請，否則可由 409 與 400 的差異得知密碼是否正確
+	if member.DeletionScheduledAt != nil {
+		return time.Time{}, ErrDeletionAlreadyScheduled
+	}
+
+	now := time.Now()
+	if member.PasswordHash != "" {
+		if err := checkNotLocked(member, now); err != nil {
+			return time.Time{}, err
+		}
+		if err := checkCurrentPassword(member, password); err != nil {
+			if errors.Is(err, ErrCurrentPasswordIncorrect) {
+				err = members.reauthFailure(ctx, memberID, policy, now, err)
+			}
+			return time.Time{}, err
+		}
+	}
+
+	scheduledAt := now.Add(s.GracePeriod)
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Member{}).
+			Where("id = ? AND deletion_scheduled_at IS NULL", memberID).
+			Updates(map[string]interface{}{
+				"deletion_scheduled_at":  &scheduledAt,
+				"last_modifier_id":       memberID,
+				"last_modification_time": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeletionAlreadyScheduled
+		}
+		if err := NewAuditService(tx).Record(ctx, &models.AuditLog{
+			ActorID:   memberID,
+			Action:    AuditAccountDeletionRequested,
+			TargetID:  memberID,
+			IP:        clientIP,
+			UserAgent: userAgent,
+		}); err != nil {
+			return err
+		}
+		msg, err := renderMail(tx, EventDeletionScheduled, member, member.Email, map[string]interface{}{
+			"ScheduledAt": scheduledAt.Format("2006-01-02 15:04"),
+		})
+		if err != nil {
+			return err
+		}
+		return queueMail(tx, memberID, msg)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return scheduledAt, nil
+}
+
+// Cancel 取消尚未到期的刪除申請
+func (s *AccountDeletionService) Cancel(ctx context.Context, memberID uint, clientIP, userAgent string) error {
+	now := time.Now()
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Member{}).
+			Where("id = ? AND is_deleted = ? AND deletion_scheduled_at > ?", memberID, false, now).
+			Updates(map[string]interface{}{
+				"deletion_scheduled_at":  nil,
+				"last_modifier_id":       memberID,
+				"last_modification_time": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDeletionNotScheduled
+		}
+		return NewAuditService(tx).Record(ctx, &models.AuditLog{
+			ActorID:   memberID,
+			Action:    AuditAccountDeletionCancelled,
+			TargetID:  memberID,
+			IP:        clientIP,
+			UserAgent: userAgent,
+		})
+	})
+}
+
+// AnonymizeDue 匿名化刪除申請已到期的會員，以及被管理員刪除超過 GracePeriod 的會員，回傳處理的數量
+func (s *AccountDeletionService) AnonymizeDue(ctx context.Context, now time.Time) (int, error) {
+	var ids []uint
+	if err := s.DB.WithContext(ctx).Model(&models.Member{}).
+		Where("anonymized_at IS NULL").
+		Where("deletion_scheduled_at <= ? OR (is_deleted = ? AND deleted_at <= ?)", now, true, now.Add(-s.GracePeriod)).
+		Order("id").
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, id := range ids {
+		if err := s.anonymize(ctx, id, now); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// anonymize 清除會員的個人資料並撤銷所有憑證；產品與稽核紀錄保留（清除其中的 IP 與 User-Agent），會員紀錄標記為已刪除
+func (s *AccountDeletionService) anonymize(ctx context.Context, memberID uint, now time.Time) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Member{}).
+			Where("id = ? AND anonymized_at IS NULL", memberID).
+			Updates(map[string]interface{}{
+				"name":                  AnonymizedMemberName,
+				"email":                 anonymizedEmail(memberID),
+				"password_hash":         "",
+				"email_verified_at":     nil,
+				"failed_login_attempts": 0,
+				"locked_until":          nil,
+				"totp_secret":           "",
+				"totp_enabled_at":       nil,
+				"totp_last_step":        0,
+				"magic_link_enabled_at": nil,
+				"timezone":              "",
+				"quiet_hours_start":     nil,
+				"quiet_hours_end":       nil,
+				"deletion_scheduled_at": nil,
+				"anonymized_at":         &now,
+				"is_deleted":            true,
+				// 保留管理員刪除的時間
+				"deleted_at":             gorm.Expr("COALESCE(deleted_at, ?)", now),
+				"last_modifier_id":       0,
+				"last_modification_time": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		personal := []interface{}{
+			&models.Identity{}, &models.RecoveryCode{}, &models.MemberRole{}, &models.ActionToken{},
+			&models.WebAuthnCredential{}, &models.Notification{}, &models.NotificationDelivery{},
+			&models.NotificationPreference{},
+		}
+		for _, model := range personal {
+			if err := tx.Where("member_id = ?", memberID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, model := range []interface{}{&models.APIKey{}, &models.Session{}, &models.RefreshToken{}} {
+			if err := tx.Model(model).
+				Where("member_id = ? AND revoked_at IS NULL", memberID).
+				Updates(map[string]interface{}{
+					"revoked_at":             &now,
+					"last_modification_time": &now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		// 工作階段與稽核紀錄的 IP 與 User-Agent 屬於個人資料
+		if err := tx.Model(&models.Session{}).
+			Where("member_id = ?", memberID).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AuditLog{}).
+			Where("actor_id = ? OR target_id = ?", memberID, memberID).
+			Updates(map[string]interface{}{"ip": "", "user_agent": ""}).Error; err != nil {
+			return err
+		}
+		// outbox 中尚未寄出、寄送失敗或保留中的郵件與通知含有會員的 email 與內容
+		if err := tx.Where("member_id = ?", memberID).Delete(&models.OutboxMessage{}).Error; err != nil {
+			return err
+		}
+
+		return NewAuditService(tx).Record(ctx, &models.AuditLog{
+			Action:   AuditAccountAnonymized,
+			TargetID: memberID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if store := auth.CurrentRevocationStore(); store != nil {
+		return store.RevokeAllForMember(ctx, int64(memberID), now)
+	}
+	return nil
+}
+
+// StartSweeper 啟動背景工作定期匿名化到期的會員，直到 ctx 結束
+func (s *AccountDeletionService) StartSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				count, err := s.AnonymizeDue(ctx, time.Now())
+				if err != nil {
+					log.Printf("Error anonymizing deleted members: %v\n", err)
+				}
+				if count > 0 {
+					log.Printf("Anonymized %d deleted members\n", count)
+				}
+			}
+		}
+	}()
+}
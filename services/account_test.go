@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"member_API/auth"
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCurrentPassword(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse")
+	require.NoError(t, err)
+
+	member := &models.Member{PasswordHash: hash}
+	assert.NoError(t, checkCurrentPassword(member, "correct horse"))
+	assert.ErrorIs(t, checkCurrentPassword(member, "wrong horse"), ErrCurrentPasswordIncorrect)
+
+	// 以外部帳號登入建立的會員沒有密碼，不可能通過驗證
+	assert.ErrorIs(t, checkCurrentPassword(&models.Member{}, ""), ErrPasswordNotSet)
+}
+
+func TestCheckDisableTOTPPassword(t *testing.T) {
+	hash, err := auth.HashPassword("correct horse")
+	require.NoError(t, err)
+
+	member := &models.Member{PasswordHash: hash}
+	assert.NoError(t, checkDisableTOTPPassword(member, "correct horse"))
+	assert.ErrorIs(t, checkDisableTOTPPassword(member, "wrong horse"), ErrInvalidCredentials)
+	assert.ErrorIs(t, checkDisableTOTPPassword(member, ""), ErrInvalidCredentials)
+
+	// 以外部帳號登入建立的會員沒有密碼，只需要第二因素
+	assert.NoError(t, checkDisableTOTPPassword(&models.Member{}, ""))
+}
+
+func TestCheckNotLocked(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.NoError(t, checkNotLocked(&models.Member{}, now))
+	assert.NoError(t, checkNotLocked(&models.Member{LockedUntil: &past}, now))
+
+	var locked *AccountLockedError
+	require.ErrorAs(t, checkNotLocked(&models.Member{LockedUntil: &future}, now), &locked)
+	assert.Equal(t, future, locked.Until)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 一次性 token 的用途
+const (
+	PurposePasswordReset = "password_reset"
+)
+
+// ErrActionTokenInvalid 一次性 token 不存在、已使用或已過期
+var ErrActionTokenInvalid = errors.New("連結無效或已過期")
+
+type ActionTokenService struct {
+	DB *gorm.DB
+}
+
+func NewActionTokenService(db *gorm.DB) *ActionTokenService {
+	return &ActionTokenService{DB: db}
+}
+
+// Issue 為會員簽發一次性 token，同一用途先前尚未使用的 token 會一併失效
+func (s *ActionTokenService) Issue(memberID uint, purpose string, ttl time.Duration, payload string) (string, error) {
+	plain, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := NewActionTokenService(tx).Invalidate(memberID, purpose); err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ActionToken{
+			Base: models.Base{
+				CreationTime: now,
+				CreatorId:    memberID,
+			},
+			MemberID:  memberID,
+			Purpose:   purpose,
+			TokenHash: auth.HashToken(plain),
+			Payload:   payload,
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// Invalidate 使會員同一用途尚未使用的 token 全部失效
+func (s *ActionTokenService) Invalidate(memberID uint, purpose string) error {
+	now := time.Now()
+	return s.DB.Model(&models.ActionToken{}).
+		Where("member_id = ? AND purpose = ? AND used_at IS NULL", memberID, purpose).
+		Updates(map[string]interface{}{
+			"used_at":                &now,
+			"last_modification_time": &now,
+		}).Error
+}
+
+// Consume 驗證並使用一次性 token，成功後 token 即失效
+func (s *ActionTokenService) Consume(plain, purpose string) (*models.ActionToken, error) {
+	var token models.ActionToken
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ?", auth.HashToken(plain), purpose).
+			First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrActionTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if token.UsedAt != nil || now.After(token.ExpiresAt) {
+			return ErrActionTokenInvalid
+		}
+
+		token.UsedAt = &now
+		return tx.Model(&token).Updates(map[string]interface{}{
+			"used_at":                &now,
+			"last_modifier_id":       token.MemberID,
+			"last_modification_time": &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
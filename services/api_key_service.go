@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxAPIKeysPerMember 每位會員可同時持有的有效 API key 數量上限
+	MaxAPIKeysPerMember = 20
+	// apiKeyUsageInterval 同一來源 IP 重複使用時更新最後使用時間的最短間隔，避免每個請求都寫入資料庫
+	apiKeyUsageInterval = time.Minute
+)
+
+var (
+	// ErrAPIKeyNotFound API key 不存在或不屬於該會員
+	ErrAPIKeyNotFound = errors.New("API key 不存在")
+	// ErrInvalidAPIKeyScope scope 為空或包含無效的 scope
+	ErrInvalidAPIKeyScope = errors.New("無效的 API key scope")
+	// ErrAPIKeyLimitReached 有效的 API key 已達上限
+	ErrAPIKeyLimitReached = errors.New("API key 數量已達上限")
+	// ErrAPIKeyExpiry 到期時間必須晚於現在
+	ErrAPIKeyExpiry = errors.New("到期時間必須晚於現在")
+)
+
+type APIKeyService struct {
+	DB *gorm.DB
+}
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{DB: db}
+}
+
+// Create 為會員建立 API key，回傳只會顯示一次的明文；expiresAt 為 nil 表示不會過期
+func (s *APIKeyService) Create(memberID uint, name string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	scopes = normalizeScopes(scopes)
+	if len(scopes) == 0 {
+		return "", nil, ErrInvalidAPIKeyScope
+	}
+	for _, scope := range scopes {
+		if !auth.IsValidScope(scope) {
+			return "", nil, ErrInvalidAPIKeyScope
+		}
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, ErrAPIKeyExpiry
+	}
+
+	plain, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key := &models.APIKey{
+		Base: models.Base{
+			CreationTime: now,
+			CreatorId:    memberID,
+		},
+		MemberID:  memberID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    prefix,
+		KeyHash:   auth.HashToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockMember(tx, memberID); err != nil {
+			return err
+		}
+
+		var active int64
+		if err := activeAPIKeys(tx, memberID, now).Count(&active).Error; err != nil {
+			return err
+		}
+		if active >= MaxAPIKeysPerMember {
+			return ErrAPIKeyLimitReached
+		}
+
+		return tx.Create(key).Error
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return plain, key, nil
+}
+
+// normalizeScopes 去除空白與重複的 scope 並排序
+func normalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope = strings.TrimSpace(scope); scope != "" && !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// activeAPIKeys 會員尚未撤銷且未過期的 API key
+func activeAPIKeys(tx *gorm.DB, memberID uint, now time.Time) *gorm.DB {
+	return tx.Model(&models.APIKey{}).
+		Where("member_id = ? AND revoked_at IS NULL AND is_deleted = ?", memberID, false).
+		Where("expires_at IS NULL OR expires_at > ?", now)
+}
+
+// List 列出會員所有的 API key（包含已撤銷與已過期的），依建立時間由新到舊排序
+func (s *APIKeyService) List(memberID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.DB.Where("member_id = ? AND is_deleted = ?", memberID, false).
+		Order("creation_time DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke 撤銷會員的 API key，已撤銷的 key 不做任何變更
+func (s *APIKeyService) Revoke(memberID, keyID uint) error {
+	var key models.APIKey
+	if err := s.DB.Where("member_id = ? AND is_deleted = ?", memberID, false).First(&key, keyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	return s.DB.Model(&key).Updates(map[string]interface{}{
+		"revoked_at":             &now,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	}).Error
+}
+
+// AuthenticateAPIKey 驗證 API key 並記錄最後使用時間與來源 IP，實作 auth.APIKeyStore
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plain, clientIP string) (*auth.APIKeyPrincipal, error) {
+	db := s.DB.WithContext(ctx)
+
+	var key models.APIKey
+	if err := db.Where("key_hash = ? AND is_deleted = ?", auth.HashToken(plain), false).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	var member models.Member
+	if err := db.Where("is_deleted = ?", false).First(&member, key.MemberID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	roles, err := NewMemberService(db).GetRoles(member.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || key.LastUsedIP != clientIP || now.Sub(*key.LastUsedAt) >= apiKeyUsageInterval {
+		// 使用紀錄只是輔助資訊，寫入失敗不影響認證
+		_ = db.Model(&key).UpdateColumns(map[string]interface{}{
+			"last_used_at": &now,
+			"last_used_ip": clientIP,
+		}).Error
+	}
+
+	return &auth.APIKeyPrincipal{
+		KeyID:         key.ID,
+		MemberID:      int64(member.ID),
+		Email:         member.Email,
+		Roles:         roles,
+		EmailVerified: member.EmailVerifiedAt != nil,
+		Scopes:        key.Scopes,
+	}, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 稽核紀錄的動作名稱
+const (
+	AuditImpersonationStart = "impersonation.start"
+	AuditImpersonationStop  = "impersonation.stop"
+
+	AuditAccountExport            = "account.export"
+	AuditAccountDeletionRequested = "account.deletion_requested"
+	AuditAccountDeletionCancelled = "account.deletion_cancelled"
+	AuditAccountAnonymized        = "account.anonymized"
+)
+
+// maxAuditReasonLength 與 models.AuditLog.Reason 欄位長度一致
+const maxAuditReasonLength = 500
+
+// AuditService 寫入與查詢稽核紀錄
+type AuditService struct {
+	DB *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{DB: db}
+}
+
+// AuditFilter 查詢稽核紀錄的條件，零值欄位表示不篩選
+type AuditFilter struct {
+	Action   string
+	ActorID  uint
+	TargetID uint
+	Limit    int
+	Offset   int
+}
+
+// Record 寫入一筆稽核紀錄
+func (s *AuditService) Record(ctx context.Context, entry *models.AuditLog) error {
+	if entry.CreationTime.IsZero() {
+		entry.CreationTime = time.Now()
+	}
+	entry.CreatorId = entry.ActorID
+	if len(entry.Reason) > maxAuditReasonLength {
+		entry.Reason = entry.Reason[:maxAuditReasonLength]
+	}
+	entry.UserAgent = truncateUserAgent(entry.UserAgent)
+	return s.DB.WithContext(ctx).Create(entry).Error
+}
+
+// List 依條件查詢稽核紀錄（新到舊），並回傳符合條件的總數
+func (s *AuditService) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error) {
+	query := s.DB.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"member_API/models"
+	"time"
+)
+
+// MemberExport 會員個人資料匯出內容，包含我們保存的所有與該會員相關的資料；密碼雜湊與各種 token 不匯出
+type MemberExport struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	Profile       ExportProfile         `json:"profile"`
+	Identities    []ExportIdentity      `json:"identities"`
+	Sessions      []ExportSession       `json:"sessions"`
+	APIKeys       []ExportAPIKey        `json:"api_keys"`
+	Passkeys      []ExportPasskey       `json:"passkeys"`
+	Products      []models.Product      `json:"products"`
+	Notifications []models.Notification `json:"notifications"`
+	// NotificationPreferences 只包含會員變更過的類型與管道
+	NotificationPreferences []models.NotificationPreference `json:"notification_preferences"`
+	AuditLogs               []models.AuditLog               `json:"audit_logs"`
+}
+
+// ExportProfile 會員基本資料
+type ExportProfile struct {
+	ID                  uint       `json:"id"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+	TOTPEnabledAt       *time.Time `json:"totp_enabled_at"`
+	MagicLinkEnabledAt  *time.Time `json:"magic_link_enabled_at"`
+	Roles               []string   `json:"roles"`
+	Locale              string     `json:"locale"`
+	Timezone            string     `json:"timezone"`
+	QuietHoursStart     string     `json:"quiet_hours_start,omitempty"`
+	QuietHoursEnd       string     `json:"quiet_hours_end,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+// ExportIdentity 連結的外部帳號
+type ExportIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportSession 登入工作階段
+type ExportSession struct {
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ExportAPIKey API key（不含 key 本身）
+type ExportAPIKey struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// ExportPasskey 已註冊的 passkey（不含公鑰）
+type ExportPasskey struct {
+	Name       string     `json:"name"`
+	AAGUID     string     `json:"aaguid"`
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// ExportData 收集會員的個人資料，並寫入稽核紀錄
+func (s *MemberService) ExportData(ctx context.Context, memberID uint, clientIP, userAgent string) (*MemberExport, error) {
+	member, err := s.GetMemberByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.GetRoles(memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &MemberExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: ExportProfile{
+			ID:                  member.ID,
+			Name:                member.Name,
+			Email:               member.Email,
+			EmailVerifiedAt:     member.EmailVerifiedAt,
+			TOTPEnabledAt:       member.TOTPEnabledAt,
+			MagicLinkEnabledAt:  member.MagicLinkEnabledAt,
+			Roles:               roles,
+			Locale:              member.Locale,
+			Timezone:            member.Timezone,
+			CreatedAt:           member.CreationTime,
+			DeletionScheduledAt: member.DeletionScheduledAt,
+		},
+		Identities:    []ExportIdentity{},
+		Sessions:      []ExportSession{},
+		APIKeys:       []ExportAPIKey{},
+		Passkeys:      []ExportPasskey{},
+		Products:      []models.Product{},
+		Notifications: []models.Notification{},
+		AuditLogs:     []models.AuditLog{},
+
+		NotificationPreferences: []models.NotificationPreference{},
+	}
+	if member.QuietHoursStart != nil && member.QuietHoursEnd != nil {
+		export.Profile.QuietHoursStart = FormatClock(*member.QuietHoursStart)
+		export.Profile.QuietHoursEnd = FormatClock(*member.QuietHoursEnd)
+	}
+
+	tx := s.DB.WithContext(ctx)
+
+	var identities []models.Identity
+	if err := tx.Where("member_id = ?", memberID).Order("id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, ExportIdentity{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreationTime,
+		})
+	}
+
+	var sessions []models.Session
+	if err := tx.Where("member_id = ?", memberID).Order("id").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, ExportSession{
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreationTime,
+			LastSeenAt: session.LastSeenAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	var keys []models.APIKey
+	if err := tx.Where("member_id = ?", memberID).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		export.APIKeys = append(export.APIKeys, ExportAPIKey{
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreationTime,
+			LastUsedAt: key.LastUsedAt,
+			LastUsedIP: key.LastUsedIP,
+			RevokedAt:  key.RevokedAt,
+		})
+	}
+
+	var passkeys []models.WebAuthnCredential
+	if err := tx.Where("member_id = ?", memberID).Order("id").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	for _, passkey := range passkeys {
+		export.Passkeys = append(export.Passkeys, ExportPasskey{
+			Name:       passkey.Name,
+			AAGUID:     passkey.AAGUID,
+			Transports: passkey.Transports,
+			CreatedAt:  passkey.CreationTime,
+			LastUsedAt: passkey.LastUsedAt,
+		})
+	}
+
+	if err := tx.Where("creator_id = ? AND is_deleted = ?", memberID, false).Order("id").Find(&export.Products).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("member_id = ? AND is_deleted = ?", memberID, false).Order("id").Find(&export.Notifications).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("member_id = ?", memberID).Order("id").Find(&export.NotificationPreferences).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("actor_id = ? OR target_id = ?", memberID, memberID).Order("id").Find(&export.AuditLogs).Error; err != nil {
+		return nil, err
+	}
+
+	err = NewAuditService(s.DB).Record(ctx, &models.AuditLog{
+		ActorID:   memberID,
+		Action:    AuditAccountExport,
+		TargetID:  memberID,
+		IP:        clientIP,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// WriteZip 將匯出內容寫成 ZIP，每個區塊一個 JSON 檔案
+func (e *MemberExport) WriteZip(w io.Writer) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", struct {
+			ExportedAt time.Time     `json:"exported_at"`
+			Profile    ExportProfile `json:"profile"`
+		}{e.ExportedAt, e.Profile}},
+		{"identities.json", e.Identities},
+		{"sessions.json", e.Sessions},
+		{"api_keys.json", e.APIKeys},
+		{"passkeys.json", e.Passkeys},
+		{"products.json", e.Products},
+		{"notifications.json", e.Notifications},
+		{"notification_preferences.json", e.NotificationPreferences},
+		{"audit_logs.json", e.AuditLogs},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberExportWriteZip(t *testing.T) {
+	export := &MemberExport{
+		ExportedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Profile:       ExportProfile{ID: 7, Name: "張三", Email: "zhang@example.com", Roles: []string{"member"}},
+		Identities:    []ExportIdentity{},
+		Sessions:      []ExportSession{{IP: "203.0.113.1"}},
+		APIKeys:       []ExportAPIKey{},
+		Passkeys:      []ExportPasskey{},
+		Products:      []models.Product{{ProductName: "iPhone 15 Pro"}},
+		Notifications: []models.Notification{},
+		AuditLogs:     []models.AuditLog{},
+
+		NotificationPreferences: []models.NotificationPreference{{Type: NotificationTypeAnnouncement, Channel: "email", Enabled: true}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteZip(&buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		var content bytes.Buffer
+		_, err = content.ReadFrom(r)
+		require.NoError(t, err)
+		r.Close()
+		files[f.Name] = content.Bytes()
+	}
+
+	assert.Len(t, files, 9)
+	for _, name := range []string{"profile.json", "identities.json", "sessions.json", "api_keys.json", "passkeys.json", "products.json", "notifications.json", "notification_preferences.json", "audit_logs.json"} {
+		assert.Contains(t, files, name)
+	}
+
+	var profile struct {
+		ExportedAt time.Time     `json:"exported_at"`
+		Profile    ExportProfile `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, "zhang@example.com", profile.Profile.Email)
+	assert.True(t, export.ExportedAt.Equal(profile.ExportedAt))
+
+	// 空的區塊輸出為空陣列而非 null
+	assert.JSONEq(t, "[]", string(files["identities.json"]))
+
+	var products []models.Product
+	require.NoError(t, json.Unmarshal(files["products.json"], &products))
+	require.Len(t, products, 1)
+	assert.Equal(t, "iPhone 15 Pro", products[0].ProductName)
+}
+
+func TestAnonymizedEmail(t *testing.T) {
+	assert.Equal(t, "deleted-42@invalid", anonymizedEmail(42))
+	assert.NotEqual(t, anonymizedEmail(1), anonymizedEmail(11))
+}
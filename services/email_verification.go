@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PurposeEmailVerification email 驗證 token 的用途
+	PurposeEmailVerification = "email_verification"
+	// EmailVerificationTTL email 驗證連結的有效期限
+	EmailVerificationTTL = 48 * time.Hour
+)
+
+var (
+	// ErrEmailVerificationInvalid 驗證連結簽章錯誤、已過期或 email 已變更
+	ErrEmailVerificationInvalid = errors.New("驗證連結無效或已過期")
+	// ErrEmailAlreadyVerified 會員的 email 已完成驗證
+	ErrEmailAlreadyVerified = errors.New("email 已完成驗證")
+)
+
+// SendVerificationEmail 寄送含簽章驗證連結的 email 驗證信（寫入 outbox，由背景 worker 寄送）
+func (s *MemberService) SendVerificationEmail(ctx context.Context, member *models.Member) error {
+	return queueVerificationEmail(s.DB.WithContext(ctx), member)
+}
+
+// queueVerificationEmail 在 tx 中將 email 驗證信寫入 outbox
+func queueVerificationEmail(tx *gorm.DB, member *models.Member) error {
+	token, err := auth.GeneratePurposeToken(PurposeEmailVerification, int64(member.ID), member.Email, EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	msg, err := renderMail(tx, EventEmailVerification, member, member.Email, map[string]interface{}{
+		"Link":           appLink("/verify-email?token=" + url.QueryEscape(token)),
+		"ExpiresInHours": int(EmailVerificationTTL.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+	return queueMail(tx, member.ID, msg)
+}
+
+// ResendVerificationEmail 重新寄送驗證信給尚未驗證的會員
+func (s *MemberService) ResendVerificationEmail(ctx context.Context, memberID uint) error {
+	member, err := s.GetMemberByID(memberID)
+	if err != nil {
+		return err
+	}
+	if member.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	return s.SendVerificationEmail(ctx, member)
+}
+
+// VerifyEmail 以驗證連結中的 token 完成 email 驗證。
+// token 綁定簽發當時的 email，會員變更 email 後舊連結即失效；重複驗證不會報錯。
+func (s *MemberService) VerifyEmail(ctx context.Context, token string) (*models.Member, error) {
+	claims, err := auth.ValidatePurposeToken(token, PurposeEmailVerification)
+	if err != nil {
+		return nil, ErrEmailVerificationInvalid
+	}
+
+	var member models.Member
+	if err := s.DB.WithContext(ctx).Where("is_deleted = ?", false).First(&member, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEmailVerificationInvalid
+		}
+		return nil, err
+	}
+	if member.Email != claims.Email {
+		return nil, ErrEmailVerificationInvalid
+	}
+	if member.EmailVerifiedAt != nil {
+		return &member, nil
+	}
+
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).Model(&member).Updates(map[string]interface{}{
+		"email_verified_at":      &now,
+		"last_modifier_id":       member.ID,
+		"last_modification_time": &now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	member.EmailVerifiedAt = &now
+
+	return &member, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrIdentityNotLinked 外部帳號尚未連結會員，且 provider 未開啟自動建立
+var ErrIdentityNotLinked = errors.New("外部帳號尚未連結任何會員")
+
+// ExternalIdentity 外部 IdP 驗證後提供的使用者資訊
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// IdentityLoginOptions 外部帳號第一次登入時的處理方式，依 provider 設定
+type IdentityLoginOptions struct {
+	// AutoLink 以 IdP 已驗證的 email 連結相同 email 的既有會員
+	AutoLink bool
+	// AutoProvision 找不到相同 email 的會員時建立新會員
+	AutoProvision bool
+}
+
+// LoginWithIdentity 以外部帳號登入並回傳對應的會員。依序：
+//  1. 已連結的外部帳號直接登入
+//  2. AutoLink 開啟、IdP 已驗證 email 且有相同 email 的會員時，連結至該會員；
+//     管理員、工作人員與已啟用兩步驟驗證的會員不自動連結
+//  3. AutoProvision 開啟時建立新會員（不設定密碼）並連結
+//
+// 連結至 email 尚未驗證的既有會員時，會清除其密碼並撤銷所有 refresh token，
+// 避免他人預先以該 email 註冊後在連結後仍能以密碼登入
+func (s *MemberService) LoginWithIdentity(ctx context.Context, ext ExternalIdentity, opts IdentityLoginOptions) (*models.Member, error) {
+	var (
+		member  models.Member
+		claimed bool
+	)
+	now := time.Now()
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var identity models.Identity
+		err := tx.Where("provider = ? AND subject = ? AND is_deleted = ?", ext.Provider, ext.Subject, false).
+			First(&identity).Error
+		switch {
+		case err == nil:
+			if err := tx.Where("is_deleted = ?", false).First(&member, identity.MemberID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrIdentityNotLinked
+				}
+				return err
+			}
+			if identity.Email != ext.Email {
+				return tx.Model(&identity).Updates(map[string]interface{}{
+					"email":                  ext.Email,
+					"last_modification_time": &now,
+				}).Error
+			}
+			return nil
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if ext.Email == "" || !ext.EmailVerified {
+			return ErrIdentityNotLinked
+		}
+
+		err = tx.Where("email = ? AND is_deleted = ?", ext.Email, false).First(&member).Error
+		switch {
+		case err == nil:
+			if !opts.AutoLink {
+				return ErrIdentityNotLinked
+			}
+			var roles []string
+			if err := tx.Model(&models.MemberRole{}).Where("member_id = ?", member.ID).Pluck("role", &roles).Error; err != nil {
+				return err
+			}
+			if !canAutoLink(&member, roles) {
+				return ErrIdentityNotLinked
+			}
+			claimed = member.EmailVerifiedAt == nil
+			if claimed {
+				if err := claimUnverifiedMember(tx, &member, now); err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound) && opts.AutoProvision:
+			if err := provisionMember(tx, &member, ext, now); err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return ErrIdentityNotLinked
+		default:
+			return err
+		}
+
+		return tx.Create(&models.Identity{
+			Base:     models.Base{CreationTime: now, CreatorId: member.ID},
+			MemberID: member.ID,
+			Provider: ext.Provider,
+			Subject:  ext.Subject,
+			Email:    ext.Email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claimed {
+		if err := revokeAccessTokensForReissue(ctx, member.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if member.LockedUntil != nil && now.Before(*member.LockedUntil) {
+		return nil, &AccountLockedError{Until: *member.LockedUntil}
+	}
+
+	return &member, nil
+}
+
+// canAutoLink 檢查既有會員可否僅憑 IdP 驗證的 email 連結。管理員與工作人員的帳號，
+// 以及已啟用兩步驟驗證的會員一律不自動連結
+func canAutoLink(member *models.Member, roles []string) bool {
+	return member.TOTPEnabledAt == nil && !auth.HasRole(roles, auth.RoleAdmin, auth.RoleStaff)
+}
+
+// claimUnverifiedMember 外部帳號連結到 email 尚未驗證的會員時，視為已驗證並清除原本的密碼與登入狀態
+func claimUnverifiedMember(tx *gorm.DB, member *models.Member, now time.Time) error {
+	if err := tx.Model(member).Updates(map[string]interface{}{
+		"email_verified_at":      &now,
+		"password_hash":          "",
+		"last_modification_time": &now,
+	}).Error; err != nil {
+		return err
+	}
+	member.EmailVerifiedAt = &now
+	member.PasswordHash = ""
+
+	return NewRefreshTokenService(tx).RevokeAllForMember(member.ID)
+}
+
+// provisionMember 依外部帳號資訊建立新會員，email 由 IdP 驗證因此直接標記為已驗證
+func provisionMember(tx *gorm.DB, member *models.Member, ext ExternalIdentity, now time.Time) error {
+	name := strings.TrimSpace(ext.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(ext.Email, "@")
+	}
+
+	*member = models.Member{
+		Base:            models.Base{CreationTime: now},
+		Name:            name,
+		Email:           ext.Email,
+		EmailVerifiedAt: &now,
+	}
+	return tx.Create(member).Error
+}
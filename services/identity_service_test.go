@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"member_API/auth"
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanAutoLink(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		member   *models.Member
+		roles    []string
+		expected bool
+	}{
+		{name: "一般會員", member: &models.Member{}, expected: true},
+		{name: "管理員", member: &models.Member{}, roles: []string{auth.RoleAdmin}, expected: false},
+		{name: "工作人員", member: &models.Member{}, roles: []string{auth.RoleStaff}, expected: false},
+		{name: "已啟用兩步驟驗證", member: &models.Member{TOTPEnabledAt: &now}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, canAutoLink(tt.member, tt.roles))
+		})
+	}
+}
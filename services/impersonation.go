@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrImpersonateSelf 管理員不可模擬自己
+	ErrImpersonateSelf = errors.New("不可模擬自己")
+	// ErrImpersonateAdmin 不可模擬其他管理員，避免取得對方的管理權限
+	ErrImpersonateAdmin = errors.New("不可模擬管理員")
+	// ErrNotImpersonating 目前的 token 不是模擬登入
+	ErrNotImpersonating = errors.New("目前不是模擬登入")
+)
+
+// Impersonation 模擬登入簽發的 token
+type Impersonation struct {
+	Token     string
+	ExpiresAt time.Time
+	Member    *models.Member
+}
+
+// ImpersonationService 管理員以會員身分檢視（唯讀）的模擬登入，開始與結束皆寫入稽核紀錄
+type ImpersonationService struct {
+	DB *gorm.DB
+}
+
+func NewImpersonationService(db *gorm.DB) *ImpersonationService {
+	return &ImpersonationService{DB: db}
+}
+
+// Start 為 admin 簽發代表 targetID 的短期 token；稽核紀錄寫入失敗時不簽發
+func (s *ImpersonationService) Start(ctx context.Context, admin *auth.Claims, targetID uint, reason, clientIP, userAgent string) (*Impersonation, error) {
+	if admin.IsImpersonated() {
+		return nil, ErrForbidden
+	}
+	if int64(targetID) == admin.UserID {
+		return nil, ErrImpersonateSelf
+	}
+
+	members := NewMemberService(s.DB.WithContext(ctx))
+	member, err := members.GetMemberByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := members.GetRoles(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if auth.HasRole(roles, auth.RoleAdmin) {
+		return nil, ErrImpersonateAdmin
+	}
+
+	// 模擬 token 不綁定工作階段、不代表通過兩步驟驗證，也不會有 refresh token
+	token, err := auth.GenerateToken(int64(member.ID), member.Email,
+		auth.WithRoles(roles...),
+		auth.WithEmailVerified(member.EmailVerifiedAt != nil),
+		auth.WithImpersonation(admin.UserID, admin.Email))
+	if err != nil {
+		return nil, err
+	}
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	err = NewAuditService(s.DB).Record(ctx, &models.AuditLog{
+		ActorID:   uint(admin.UserID),
+		Action:    AuditImpersonationStart,
+		TargetID:  member.ID,
+		Reason:    reason,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Metadata: map[string]string{
+			"jti":        claims.ID,
+			"expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Impersonation{Token: token, ExpiresAt: claims.ExpiresAt.Time, Member: member}, nil
+}
+
+// Stop 撤銷模擬登入 token 並寫入稽核紀錄；token 自然過期時不會有結束紀錄
+func (s *ImpersonationService) Stop(ctx context.Context, claims *auth.Claims, clientIP, userAgent string) error {
+	if !claims.IsImpersonated() {
+		return ErrNotImpersonating
+	}
+
+	if store := auth.CurrentRevocationStore(); store != nil && claims.ID != "" {
+		if err := store.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	return NewAuditService(s.DB).Record(ctx, &models.AuditLog{
+		ActorID:   uint(claims.Actor.UserID),
+		Action:    AuditImpersonationStop,
+		TargetID:  uint(claims.UserID),
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Metadata: map[string]string{
+			"jti":      claims.ID,
+			"duration": time.Since(claims.IssuedAt.Time).Round(time.Second).String(),
+		},
+	})
+}
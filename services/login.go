@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCredentials email 不存在或密碼錯誤
+var ErrInvalidCredentials = errors.New("電子郵件或密碼錯誤")
+
+// AccountLockedError 帳號因連續登入失敗而暫時鎖定
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "帳號已暫時鎖定，請稍後再試"
+}
+
+// Authenticate 驗證 email 與密碼。密碼錯誤時累計失敗次數，達到 policy 門檻後暫時鎖定帳號；
+// 鎖定期間即使密碼正確也會回傳 *AccountLockedError
+func (s *MemberService) Authenticate(ctx context.Context, email, password string, policy auth.LockoutPolicy) (*models.Member, error) {
+	var member models.Member
+	if err := s.DB.WithContext(ctx).Where("email = ? AND is_deleted = ?", email, false).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if member.LockedUntil != nil && now.Before(*member.LockedUntil) {
+		return nil, &AccountLockedError{Until: *member.LockedUntil}
+	}
+
+	if !auth.CheckPassword(password, member.PasswordHash) {
+		until, err := s.recordLoginFailure(ctx, member.ID, policy, now)
+		if err != nil {
+			return nil, err
+		}
+		if until != nil {
+			return nil, &AccountLockedError{Until: *until}
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if member.FailedLoginAttempts > 0 || member.LockedUntil != nil {
+		if err := s.DB.WithContext(ctx).Model(&member).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+			return nil, err
+		}
+		member.FailedLoginAttempts = 0
+		member.LockedUntil = nil
+	}
+
+	// 密碼正確時順便將舊演算法或舊參數的雜湊升級，失敗不影響登入
+	if auth.NeedsRehash(member.PasswordHash) {
+		if err := s.rehashPassword(ctx, &member, password); err != nil {
+			log.Printf("Warning: failed to rehash password for member %d: %v\n", member.ID, err)
+		}
+	}
+
+	return &member, nil
+}
+
+// rehashPassword 以目前的雜湊設定重新雜湊密碼。只在雜湊未被同時變更時才更新
+func (s *MemberService) rehashPassword(ctx context.Context, member *models.Member, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.WithContext(ctx).Model(&models.Member{}).
+		Where("id = ? AND password_hash = ?", member.ID, member.PasswordHash).
+		Update("password_hash", hash).Error; err != nil {
+		return err
+	}
+	member.PasswordHash = hash
+	return nil
+}
+
+// recordLoginFailure 累計登入失敗次數，達到門檻時設定鎖定期限並回傳
+func (s *MemberService) recordLoginFailure(ctx context.Context, memberID uint, policy auth.LockoutPolicy, now time.Time) (*time.Time, error) {
+	var lockedUntil *time.Time
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var member models.Member
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "failed_login_attempts").
+			First(&member, memberID).Error; err != nil {
+			return err
+		}
+
+		failures := member.FailedLoginAttempts + 1
+		updates := map[string]interface{}{"failed_login_attempts": failures}
+		if duration := policy.LockDuration(failures); duration > 0 {
+			until := now.Add(duration)
+			lockedUntil = &until
+			updates["locked_until"] = &until
+		}
+		return tx.Model(&member).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lockedUntil, nil
+}
+
+// checkNotLocked 會員在鎖定期間內時回傳 *AccountLockedError
+func checkNotLocked(member *models.Member, now time.Time) error {
+	if member.LockedUntil != nil && now.Before(*member.LockedUntil) {
+		return &AccountLockedError{Until: *member.LockedUntil}
+	}
+	return nil
+}
+
+// reauthFailure 已登入的會員再次驗證密碼或第二因素失敗時，與登入失敗一樣累計次數；
+// 達到 policy 門檻時回傳 *AccountLockedError，否則回傳 cause
+func (s *MemberService) reauthFailure(ctx context.Context, memberID uint, policy auth.LockoutPolicy, now time.Time, cause error) error {
+	until, err := s.recordLoginFailure(ctx, memberID, policy, now)
+	if err != nil {
+		return err
+	}
+	if until != nil {
+		return &AccountLockedError{Until: *until}
+	}
+	return cause
+}
+
+// UnlockMember 解除會員的登入鎖定並清除失敗次數
+func (s *MemberService) UnlockMember(memberID, modifierId uint) error {
+	if _, err := s.GetMemberByID(memberID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.DB.Model(&models.Member{}).
+		Where("id = ?", memberID).
+		Updates(map[string]interface{}{
+			"failed_login_attempts":  0,
+			"locked_until":           nil,
+			"last_modifier_id":       modifierId,
+			"last_modification_time": &now,
+		}).Error
+}
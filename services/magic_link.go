@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/mailer"
+	"member_API/models"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// PurposeMagicLogin 免密碼登入連結 token 的用途
+	PurposeMagicLogin = "magic_login"
+	// MagicLinkTTL 免密碼登入連結的有效期限
+	MagicLinkTTL = 15 * time.Minute
+)
+
+// ErrMagicLinkInvalid 登入連結簽章錯誤、已過期、已使用或 email 已變更
+var ErrMagicLinkInvalid = errors.New("登入連結無效或已過期")
+
+// SetMagicLinkLogin 由會員自行選擇是否允許以登入連結免密碼登入；停用後尚未使用的連結隨即失效
+func (s *MemberService) SetMagicLinkLogin(ctx context.Context, memberID uint, enabled bool) (*models.Member, error) {
+	var member *models.Member
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if member, err = lockMember(tx, memberID); err != nil {
+			return err
+		}
+		if (member.MagicLinkEnabledAt != nil) == enabled {
+			return nil
+		}
+
+		now := time.Now()
+		var enabledAt *time.Time
+		if enabled {
+			enabledAt = &now
+		}
+		member.MagicLinkEnabledAt = enabledAt
+		return tx.Model(member).Updates(map[string]interface{}{
+			"magic_link_enabled_at":  enabledAt,
+			"last_modifier_id":       memberID,
+			"last_modification_time": &now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RequestMagicLink 寄送免密碼登入連結。email 不存在或會員未選擇使用登入連結時同樣回傳成功，避免洩漏帳號是否存在
+func (s *MemberService) RequestMagicLink(ctx context.Context, email string) error {
+	var member models.Member
+	if err := s.DB.WithContext(ctx).
+		Where("email = ? AND is_deleted = ? AND magic_link_enabled_at IS NOT NULL", email, false).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	tx := s.DB.WithContext(ctx)
+	msg, err := magicLinkMail(tx, &member)
+	if err != nil {
+		return err
+	}
+	return queueMail(tx, member.ID, msg)
+}
+
+// magicLinkMail 產生含簽章登入連結的郵件；token 綁定目前的 email，會員變更 email 後舊連結即失效
+func magicLinkMail(db *gorm.DB, member *models.Member) (mailer.Message, error) {
+	token, err := auth.GeneratePurposeToken(PurposeMagicLogin, int64(member.ID), member.Email, MagicLinkTTL)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+
+	return renderMail(db, EventMagicLink, member, member.Email, map[string]interface{}{
+		"Link":             appLink("/magic-login?token=" + url.QueryEscape(token)),
+		"ExpiresInMinutes": int(MagicLinkTTL.Minutes()),
+	})
+}
+
+// ConsumeMagicLink 驗證並使用登入連結中的 token，成功後 token 即失效；會員已停用登入連結時連結無效。
+// 連結寄到會員的信箱，使用成功即表示 email 已驗證；帳號鎖定期間回傳 *AccountLockedError
+func (s *MemberService) ConsumeMagicLink(ctx context.Context, token string) (*models.Member, error) {
+	claims, err := auth.ValidatePurposeToken(token, PurposeMagicLogin)
+	if err != nil || claims.ID == "" {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	var member *models.Member
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		member, err = consumeMagicLink(gormMagicLinkStore{tx: tx}, claims, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// magicLinkStore 使用登入連結時需要的資料存取，實作需在同一交易中執行
+type magicLinkStore interface {
+	// lockMember 鎖定並讀取會員，不存在或已刪除時回傳 ErrMemberNotFound
+	lockMember(memberID uint) (*models.Member, error)
+	// markUsed 記錄已使用的 token ID，同一 token 已使用過時回傳 false
+	markUsed(member *models.Member, tokenID string, expiresAt, now time.Time) (bool, error)
+	// markEmailVerified 將會員的 email 標記為已驗證
+	markEmailVerified(member *models.Member, now time.Time) error
+}
+
+// consumeMagicLink 檢查簽章已驗證的登入連結 claims 並標記連結已使用
+func consumeMagicLink(store magicLinkStore, claims *auth.Claims, now time.Time) (*models.Member, error) {
+	member, err := store.lockMember(uint(claims.UserID))
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+	if member.Email != claims.Email || member.MagicLinkEnabledAt == nil {
+		return nil, ErrMagicLinkInvalid
+	}
+	if err := checkNotLocked(member, now); err != nil {
+		return nil, err
+	}
+
+	ok, err := store.markUsed(member, claims.ID, claims.ExpiresAt.Time, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	if member.EmailVerifiedAt == nil {
+		if err := store.markEmailVerified(member, now); err != nil {
+			return nil, err
+		}
+		member.EmailVerifiedAt = &now
+	}
+	return member, nil
+}
+
+// gormMagicLinkStore 以資料庫交易實作 magicLinkStore
+type gormMagicLinkStore struct {
+	tx *gorm.DB
+}
+
+func (s gormMagicLinkStore) lockMember(memberID uint) (*models.Member, error) {
+	return lockMember(s.tx, memberID)
+}
+
+// markUsed 以 token ID 記錄已使用的連結，唯一索引確保同一連結只能登入一次
+func (s gormMagicLinkStore) markUsed(member *models.Member, tokenID string, expiresAt, now time.Time) (bool, error) {
+	result := s.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ActionToken{
+		Base: models.Base{
+			CreationTime: now,
+			CreatorId:    member.ID,
+		},
+		MemberID:  member.ID,
+		Purpose:   PurposeMagicLogin,
+		TokenHash: auth.HashToken(tokenID),
+		ExpiresAt: expiresAt,
+		UsedAt:    &now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (s gormMagicLinkStore) markEmailVerified(member *models.Member, now time.Time) error {
+	return s.tx.Model(member).Updates(map[string]interface{}{
+		"email_verified_at":      &now,
+		"last_modifier_id":       member.ID,
+		"last_modification_time": &now,
+	}).Error
+}
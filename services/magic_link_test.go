@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"member_API/auth"
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicLinkMail(t *testing.T) {
+	SetupMail(nil, "https://app.example.com/")
+	t.Cleanup(func() { SetupMail(nil, "http://localhost:8080") })
+	auth.SetSecret("test-secret-key")
+	t.Cleanup(func() { auth.SetSecret("") })
+
+	member := &models.Member{Name: "張三", Email: "zhang@example.com", Base: models.Base{ID: 7}}
+	msg, err := magicLinkMail(nil, member)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zhang@example.com"}, msg.To)
+
+	match := regexp.MustCompile(`https://app\.example\.com/magic-login\?token=(\S+)`).FindStringSubmatch(msg.Text)
+	require.Len(t, match, 2, "郵件應包含登入連結")
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+
+	claims, err := auth.ValidatePurposeToken(token, PurposeMagicLogin)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.UserID)
+	assert.Equal(t, "zhang@example.com", claims.Email)
+	assert.NotEmpty(t, claims.ID, "需要 token ID 才能限制只使用一次")
+	assert.WithinDuration(t, time.Now().Add(MagicLinkTTL), claims.ExpiresAt.Time, 5*time.Second)
+
+	// 登入連結不能直接當作 access token 或其他用途的 token
+	_, err = auth.ValidateToken(token)
+	assert.ErrorIs(t, err, auth.ErrTokenPurpose)
+	_, err = auth.ValidatePurposeToken(token, PurposePasswordReset)
+	assert.ErrorIs(t, err, auth.ErrTokenPurpose)
+}
+
+// memoryMagicLinkStore 以記憶體實作 magicLinkStore
+type memoryMagicLinkStore struct {
+	members map[uint]*models.Member
+	used    map[string]bool
+}
+
+func newMemoryMagicLinkStore(members ...*models.Member) *memoryMagicLinkStore {
+	store := &memoryMagicLinkStore{members: map[uint]*models.Member{}, used: map[string]bool{}}
+	for _, member := range members {
+		store.members[member.ID] = member
+	}
+	return store
+}
+
+func (s *memoryMagicLinkStore) lockMember(memberID uint) (*models.Member, error) {
+	member, ok := s.members[memberID]
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+	copied := *member
+	return &copied, nil
+}
+
+func (s *memoryMagicLinkStore) markUsed(_ *models.Member, tokenID string, _, _ time.Time) (bool, error) {
+	if s.used[tokenID] {
+		return false, nil
+	}
+	s.used[tokenID] = true
+	return true, nil
+}
+
+func (s *memoryMagicLinkStore) markEmailVerified(member *models.Member, now time.Time) error {
+	s.members[member.ID].EmailVerifiedAt = &now
+	return nil
+}
+
+// magicLinkClaims 簽發登入連結 token 並回傳驗證後的 claims
+func magicLinkClaims(t *testing.T, member *models.Member) *auth.Claims {
+	t.Helper()
+	token, err := auth.GeneratePurposeToken(PurposeMagicLogin, int64(member.ID), member.Email, MagicLinkTTL)
+	require.NoError(t, err)
+	claims, err := auth.ValidatePurposeToken(token, PurposeMagicLogin)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestConsumeMagicLink(t *testing.T) {
+	auth.SetSecret("test-secret-key")
+	t.Cleanup(func() { auth.SetSecret("") })
+
+	now := time.Now()
+	enabledAt := now.Add(-time.Hour)
+
+	t.Run("成功登入並視為已驗證 email", func(t *testing.T) {
+		member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &enabledAt, Base: models.Base{ID: 7}}
+		store := newMemoryMagicLinkStore(member)
+
+		got, err := consumeMagicLink(store, magicLinkClaims(t, member), now)
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), got.ID)
+		assert.NotNil(t, got.EmailVerifiedAt)
+		assert.NotNil(t, store.members[7].EmailVerifiedAt)
+	})
+
+	t.Run("會員未選擇使用登入連結", func(t *testing.T) {
+		member := &models.Member{Email: "zhang@example.com", Base: models.Base{ID: 7}}
+		store := newMemoryMagicLinkStore(member)
+
+		_, err := consumeMagicLink(store, magicLinkClaims(t, member), now)
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+		assert.Empty(t, store.used, "未選擇使用時不應消耗連結")
+	})
+
+	t.Run("同一連結只能使用一次", func(t *testing.T) {
+		member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &enabledAt, Base: models.Base{ID: 7}}
+		store := newMemoryMagicLinkStore(member)
+		claims := magicLinkClaims(t, member)
+
+		_, err := consumeMagicLink(store, claims, now)
+		require.NoError(t, err)
+		_, err = consumeMagicLink(store, claims, now)
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+	})
+
+	t.Run("email 變更後舊連結失效", func(t *testing.T) {
+		member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &enabledAt, Base: models.Base{ID: 7}}
+		claims := magicLinkClaims(t, member)
+		member.Email = "new@example.com"
+
+		_, err := consumeMagicLink(newMemoryMagicLinkStore(member), claims, now)
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+	})
+
+	t.Run("會員不存在", func(t *testing.T) {
+		member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &enabledAt, Base: models.Base{ID: 7}}
+
+		_, err := consumeMagicLink(newMemoryMagicLinkStore(), magicLinkClaims(t, member), now)
+		assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+	})
+
+	t.Run("帳號鎖定期間", func(t *testing.T) {
+		lockedUntil := now.Add(time.Minute)
+		member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &enabledAt, LockedUntil: &lockedUntil, Base: models.Base{ID: 7}}
+
+		_, err := consumeMagicLink(newMemoryMagicLinkStore(member), magicLinkClaims(t, member), now)
+		var locked *AccountLockedError
+		require.ErrorAs(t, err, &locked)
+		assert.Equal(t, lockedUntil, locked.Until)
+	})
+}
+
+func TestConsumeMagicLinkRejectsInvalidToken(t *testing.T) {
+	auth.SetSecret("test-secret-key")
+	t.Cleanup(func() { auth.SetSecret("") })
+
+	expired, err := auth.GeneratePurposeToken(PurposeMagicLogin, 7, "zhang@example.com", -time.Minute)
+	require.NoError(t, err)
+	reset, err := auth.GeneratePurposeToken(PurposePasswordReset, 7, "zhang@example.com", MagicLinkTTL)
+	require.NoError(t, err)
+
+	// token 驗證失敗時不會存取資料庫
+	svc := &MemberService{}
+	for name, token := range map[string]string{"已過期": expired, "其他用途": reset, "格式錯誤": "not-a-token"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.ConsumeMagicLink(context.Background(), token)
+			assert.ErrorIs(t, err, ErrMagicLinkInvalid)
+		})
+	}
+}
+
+func TestConsumeMagicLinkWithTOTP(t *testing.T) {
+	auth.SetSecret("test-secret-key")
+	t.Cleanup(func() { auth.SetSecret("") })
+
+	now := time.Now()
+	member := &models.Member{Email: "zhang@example.com", MagicLinkEnabledAt: &now, TOTPEnabledAt: &now, Base: models.Base{ID: 7}}
+
+	got, err := consumeMagicLink(newMemoryMagicLinkStore(member), magicLinkClaims(t, member), now)
+	require.NoError(t, err)
+	require.NotNil(t, got.TOTPEnabledAt, "已啟用兩步驟驗證的會員需再完成第二因素")
+
+	// 登入連結之後簽發的是第二階段的 mfa_token，不能當作 access token 使用
+	challenge, err := IssueMFAChallenge(got.ID, got.Email)
+	require.NoError(t, err)
+	claims, err := auth.ValidatePurposeToken(challenge, PurposeMFAChallenge)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.UserID)
+	assert.Equal(t, "zhang@example.com", claims.Email)
+	_, err = auth.ValidateToken(challenge)
+	assert.ErrorIs(t, err, auth.ErrTokenPurpose)
+}
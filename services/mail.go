@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"member_API/mailer"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	mailSender mailer.Mailer
+	appBaseURL = "http://localhost:8080"
+)
+
+// SetupMail 設定服務層寄送郵件使用的 Mailer 與郵件連結的前端網址
+func SetupMail(m mailer.Mailer, baseURL string) {
+	mailSender = m
+	if baseURL != "" {
+		appBaseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// appLink 組成郵件中的前端連結
+func appLink(path string) string {
+	return appBaseURL + path
+}
+
+// sendMail 寄送郵件；未設定 Mailer 時僅記錄警告
+func sendMail(ctx context.Context, msg mailer.Message) error {
+	if mailSender == nil {
+		log.Printf("Warning: mailer not configured, dropping mail %q to %v\n", msg.Subject, msg.To)
+		return nil
+	}
+	return mailSender.Send(ctx, msg)
+}
+
+// queueMail 在 tx 中將寄給會員的郵件寫入 outbox，提交後由背景 worker 寄送
+func queueMail(tx *gorm.DB, memberID uint, msg mailer.Message) error {
+	return EnqueueOutbox(tx, memberID, OutboxTopicMail, msg)
+}
+
+// deliverQueuedMail 寄送 outbox 中的郵件
+func deliverQueuedMail(ctx context.Context, payload []byte) error {
+	var msg mailer.Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("%w: %v", ErrOutboxPermanent, err)
+	}
+	return sendMail(ctx, msg)
+}
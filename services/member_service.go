@@ -7,6 +7,14 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidRole 角色不存在或不可授予
+	ErrInvalidRole = errors.New("無效的角色")
+	// ErrMemberNotFound 會員不存在或已刪除
+	ErrMemberNotFound = errors.New("會員不存在")
 )
 
 type MemberService struct {
@@ -17,12 +25,20 @@ func NewMemberService(db *gorm.DB) *MemberService {
 	return &MemberService{DB: db}
 }
 
-// CreateMember 建立新會員
-func (s *MemberService) CreateMember(name, email, password string, creatorId uint) (*models.Member, error) {
+// CreateMember 建立新會員；locale 決定郵件與通知的語系，會對應到支援的語系
+func (s *MemberService) CreateMember(name, email, password, locale string, creatorId uint) (*models.Member, error) {
 	// 檢查 email 是否已存在
-	var exists models.Member
-	if err := s.DB.Where("email = ? AND is_deleted = ?", email, false).First(&exists).Error; err == nil {
-		return nil, errors.New("email 已被使用")
+	taken, err := emailTaken(s.DB, email, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrEmailTaken
+	}
+
+	// 檢查密碼規則
+	if err := auth.ValidatePassword(password, name, email); err != nil {
+		return nil, err
 	}
 
 	// 加密密碼
@@ -41,30 +57,57 @@ func (s *MemberService) CreateMember(name, email, password string, creatorId uin
 		Name:         name,
 		Email:        email,
 		PasswordHash: hash,
+		Locale:       NormalizeLocale(locale),
 	}
 
-	if err := s.DB.Create(member).Error; err != nil {
+	// 驗證信與會員在同一個 transaction 寫入 outbox，由背景 worker 寄送
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+		return queueVerificationEmail(tx, member)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return member, nil
 }
 
-// UpdateMember 更新會員資訊
-func (s *MemberService) UpdateMember(id uint, name, email string, modifierId uint) (*models.Member, error) {
+// UpdateMember 更新會員資訊，僅本人或具備 member:update 權限者可修改
+func (s *MemberService) UpdateMember(actor *auth.Identity, id uint, name, email string) (*models.Member, error) {
 	var member models.Member
 	if err := s.DB.Where("is_deleted = ?", false).First(&member, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("會員不存在")
+			return nil, ErrMemberNotFound
 		}
 		return nil, err
 	}
 
+	if err := AuthorizeMemberUpdate(actor, &member); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
+	if email != member.Email {
+		// 會員自己變更 email 需經過新信箱確認；具備 member:update 權限者可直接變更，新 email 視為未驗證
+		if !actor.HasPermission(auth.PermMemberUpdate) {
+			return nil, ErrEmailChangeRequiresConfirmation
+		}
+		taken, err := emailTaken(s.DB, email, member.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrEmailTaken
+		}
+		member.EmailVerifiedAt = nil
+	}
+
 	member.Name = name
 	member.Email = email
 	member.LastModificationTime = &now
-	member.LastModifierId = modifierId
+	member.LastModifierId = actorID(actor)
 
 	if err := s.DB.Save(&member).Error; err != nil {
 		return nil, err
@@ -73,26 +116,60 @@ func (s *MemberService) UpdateMember(id uint, name, email string, modifierId uin
 	return &member, nil
 }
 
-// DeleteMember 軟刪除會員
-func (s *MemberService) DeleteMember(id uint, deleterId uint) error {
-	now := time.Now()
-	result := s.DB.Model(&models.Member{}).
-		Where("id = ? AND is_deleted = ?", id, false).
-		Updates(map[string]interface{}{
-			"is_deleted":             true,
-			"deleted_at":             &now,
-			"last_modifier_id":       deleterId,
-			"last_modification_time": &now,
-		})
+// DeleteMember 軟刪除會員，需要 member:delete 權限
+func (s *MemberService) DeleteMember(actor *auth.Identity, id uint) error {
+	var member models.Member
+	if err := s.DB.Where("is_deleted = ?", false).First(&member, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
 
-	if result.Error != nil {
-		return result.Error
+	if err := AuthorizeMemberDelete(actor, &member); err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
-		return errors.New("會員不存在或已被刪除")
+	now := time.Now()
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Member{}).
+			Where("id = ? AND is_deleted = ?", id, false).
+			Updates(map[string]interface{}{
+				"is_deleted":             true,
+				"deleted_at":             &now,
+				"last_modifier_id":       actorID(actor),
+				"last_modification_time": &now,
+			})
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrMemberNotFound
+		}
+
+		// 撤銷會員的 API key、工作階段與 refresh token
+		for _, model := range []interface{}{&models.APIKey{}, &models.Session{}, &models.RefreshToken{}} {
+			if err := tx.Model(model).
+				Where("member_id = ? AND revoked_at IS NULL", id).
+				Updates(map[string]interface{}{
+					"revoked_at":             &now,
+					"last_modification_time": &now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	// 已簽發的 access token 在到期前仍有效，需一併撤銷
+	if store := auth.CurrentRevocationStore(); store != nil {
+		return store.RevokeAllForMember(s.DB.Statement.Context, int64(id), now)
+	}
 	return nil
 }
 
@@ -101,7 +178,7 @@ func (s *MemberService) GetMemberByID(id uint) (*models.Member, error) {
 	var member models.Member
 	if err := s.DB.Where("is_deleted = ?", false).First(&member, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("會員不存在")
+			return nil, ErrMemberNotFound
 		}
 		return nil, err
 	}
@@ -116,3 +193,63 @@ func (s *MemberService) GetMembers(limit int) ([]models.Member, error) {
 	}
 	return members, nil
 }
+
+// GetRoles 取得會員的角色名稱（包含隱含的 member 角色）
+func (s *MemberService) GetRoles(memberID uint) ([]string, error) {
+	var roles []string
+	if err := s.DB.Model(&models.MemberRole{}).
+		Where("member_id = ?", memberID).
+		Order("role ASC").
+		Pluck("role", &roles).Error; err != nil {
+		return nil, err
+	}
+	return append(roles, auth.RoleMember), nil
+}
+
+// GrantRole 授予會員角色，已擁有該角色時不做任何變更
+func (s *MemberService) GrantRole(memberID uint, role string, granterId uint) error {
+	if !auth.IsAssignableRole(role) {
+		return ErrInvalidRole
+	}
+
+	if _, err := s.GetMemberByID(memberID); err != nil {
+		return err
+	}
+
+	memberRole := &models.MemberRole{
+		Base: models.Base{
+			CreationTime: time.Now(),
+			CreatorId:    granterId,
+		},
+		MemberID: memberID,
+		Role:     role,
+	}
+
+	return s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(memberRole).Error
+}
+
+// RevokeRole 撤銷會員角色
+func (s *MemberService) RevokeRole(memberID uint, role string) error {
+	if !auth.IsAssignableRole(role) {
+		return ErrInvalidRole
+	}
+
+	if _, err := s.GetMemberByID(memberID); err != nil {
+		return err
+	}
+
+	return s.DB.Where("member_id = ? AND role = ?", memberID, role).
+		Delete(&models.MemberRole{}).Error
+}
+
+// EnsureRoleByEmail 確保指定 email 的會員擁有角色，用於啟動時建立初始管理員
+func (s *MemberService) EnsureRoleByEmail(email, role string) error {
+	var member models.Member
+	if err := s.DB.Where("email = ? AND is_deleted = ?", email, false).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return s.GrantRole(member.ID, role, 0)
+}
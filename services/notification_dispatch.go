@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"member_API/models"
+	"member_API/notify"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDeliveryErrorLength 與 models.NotificationDelivery.Error 欄位長度一致
+const maxDeliveryErrorLength = 1000
+
+var notifier *notify.Dispatcher
+
+// SetupNotifications 設定服務層傳送通知使用的 Dispatcher
+func SetupNotifications(d *notify.Dispatcher) {
+	notifier = d
+}
+
+// Recipient 取得會員的通知收件資訊，已刪除的會員回傳「會員不存在」
+func (s *NotificationService) Recipient(ctx context.Context, memberID uint) (notify.Recipient, error) {
+	member, err := NewMemberService(s.DB.WithContext(ctx)).GetMemberByID(memberID)
+	if err != nil {
+		return notify.Recipient{}, err
+	}
+	return notify.Recipient{
+		MemberID:      member.ID,
+		Name:          member.Name,
+		Email:         member.Email,
+		EmailVerified: member.EmailVerifiedAt != nil,
+		Locale:        NormalizeLocale(member.Locale),
+	}, nil
+}
+
+// queuedNotification outbox 中單一管道的通知
+type queuedNotification struct {
+	MemberID uint           `json:"member_id"`
+	Channel  string         `json:"channel"`
+	Message  notify.Message `json:"message"`
+}
+
+// dispatcher 回傳設定的 Dispatcher，未設定時只放入站內收件匣
+func (s *NotificationService) dispatcher() *notify.Dispatcher {
+	if notifier != nil {
+		return notifier
+	}
+	d := notify.NewDispatcher(s, notify.NewInboxChannel(s))
+	d.Policy = NewNotificationPreferenceService(s.DB)
+	return d
+}
+
+// EnqueueNotification 在 tx 中為每個管道寫入一則 outbox 訊息，提交後由背景 worker 傳送，
+// 各管道分別重試；回傳通知 ID。msg.ID 與 msg.CreatedAt 為空時自動產生
+func EnqueueNotification(tx *gorm.DB, memberID uint, msg notify.Message) (string, error) {
+	if msg.ID == "" {
+		id, err := notify.NewMessageID()
+		if err != nil {
+			return "", err
+		}
+		msg.ID = id
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+
+	for _, channel := range NewNotificationService(tx).dispatcher().Channels() {
+		if err := EnqueueOutbox(tx, memberID, OutboxTopicNotification, queuedNotification{
+			MemberID: memberID,
+			Channel:  channel,
+			Message:  msg,
+		}); err != nil {
+			return "", err
+		}
+	}
+	return msg.ID, nil
+}
+
+// Queue 確認會員存在後將通知寫入 outbox，回傳通知 ID
+func (s *NotificationService) Queue(ctx context.Context, memberID uint, msg notify.Message) (string, error) {
+	var id string
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockMember(tx, memberID); err != nil {
+			return err
+		}
+		var err error
+		id, err = EnqueueNotification(tx, memberID, msg)
+		return err
+	})
+	return id, err
+}
+
+// deliverQueued 透過 outbox 訊息指定的管道傳送通知；會員已刪除時放棄傳送
+func (s *NotificationService) deliverQueued(ctx context.Context, payload []byte) error {
+	var queued queuedNotification
+	if err := json.Unmarshal(payload, &queued); err != nil {
+		return fmt.Errorf("%w: %v", ErrOutboxPermanent, err)
+	}
+
+	recipient, err := s.Recipient(ctx, queued.MemberID)
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	msg, err := s.localize(ctx, recipient, queued.Channel, queued.Message)
+	if err != nil {
+		return err
+	}
+	_, err = s.dispatcher().Send(ctx, recipient, msg, queued.Channel)
+	var deferred *notify.DeferredError
+	if errors.As(err, &deferred) {
+		return &OutboxDeferError{Until: deferred.Until}
+	}
+	return err
+}
+
+// localize 事件在該管道有範本時，以會員語系的範本、通知原本的標題與內文（Title、Body）及 Payload 產生標題與內文；
+// 沒有範本時維持原本的內容。渲染失敗（例如 Payload 缺少範本使用的變數）不再重試
+func (s *NotificationService) localize(ctx context.Context, recipient notify.Recipient, channel string, msg notify.Message) (notify.Message, error) {
+	data := map[string]interface{}{"Name": recipient.Name, "Title": msg.Title, "Body": msg.Body}
+	for name, value := range msg.Payload {
+		data[name] = value
+	}
+
+	rendered, err := NewNotificationTemplateService(s.DB).Render(ctx, notify.TemplateKey{
+		Type:    msg.Type,
+		Channel: channel,
+		Locale:  recipient.Locale,
+	}, data)
+	if errors.Is(err, ErrTemplateNotFound) {
+		return msg, nil
+	}
+	if err != nil {
+		var templateErr *notify.TemplateError
+		if errors.As(err, &templateErr) {
+			return msg, fmt.Errorf("%w: %v", ErrOutboxPermanent, err)
+		}
+		return msg, err
+	}
+
+	msg.Title = rendered.Subject
+	msg.Body = strings.TrimRight(rendered.Text, "\n")
+	msg.HTML = rendered.HTML
+	return msg, nil
+}
+
+// DeliverToInbox 將通知放入會員的收件匣，實作 notify.Inbox；同一 msg.ID 重複傳送時不會重複建立
+func (s *NotificationService) DeliverToInbox(ctx context.Context, memberID uint, msg notify.Message) error {
+	notification := &models.Notification{
+		Base: models.Base{
+			CreationTime: msg.CreatedAt,
+		},
+		MemberID:  memberID,
+		MessageID: msg.ID,
+		Type:      msg.Type,
+		Title:     msg.Title,
+		Body:      msg.Body,
+		Payload:   msg.Payload,
+	}
+	if notification.MessageID == "" {
+		return s.Create(ctx, notification)
+	}
+	if notification.CreationTime.IsZero() {
+		notification.CreationTime = time.Now()
+	}
+	return s.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "message_id"}}, DoNothing: true}).
+		Create(notification).Error
+}
+
+// RecordDeliveries 保存每個管道的傳送結果，實作 notify.Recorder
+func (s *NotificationService) RecordDeliveries(ctx context.Context, recipient notify.Recipient, msg notify.Message, results []notify.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+	now := time.Now()
+	rows := make([]models.NotificationDelivery, len(results))
+	for i, result := range results {
+		if len(result.Error) > maxDeliveryErrorLength {
+			result.Error = result.Error[:maxDeliveryErrorLength]
+		}
+		rows[i] = models.NotificationDelivery{
+			Base: models.Base{
+				CreationTime: now,
+			},
+			MessageID: msg.ID,
+			MemberID:  recipient.MemberID,
+			Type:      msg.Type,
+			Channel:   result.Channel,
+			Status:    result.Status,
+			Error:     result.Error,
+		}
+	}
+	return s.DB.WithContext(ctx).Create(&rows).Error
+}
+
+// OutboxHandlers 回傳各 outbox 主題的處理器
+func OutboxHandlers(db *gorm.DB) map[string]OutboxHandler {
+	return map[string]OutboxHandler{
+		OutboxTopicMail:         deliverQueuedMail,
+		OutboxTopicNotification: NewNotificationService(db).deliverQueued,
+	}
+}
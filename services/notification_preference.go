@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"member_API/models"
+	"member_API/notify"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 會員可設定偏好的通知類型
+const (
+	NotificationTypeSecurity     = "account.security"
+	NotificationTypeAdminMessage = "admin.message"
+	NotificationTypeAnnouncement = "announcement"
+)
+
+var (
+	ErrUnknownNotificationType = errors.New("未知的通知類型")
+	ErrUnknownChannel          = errors.New("未知的通知管道")
+	ErrMandatoryNotification   = errors.New("此類型的通知無法關閉")
+	ErrInvalidTimezone         = errors.New("無效的時區")
+	ErrInvalidQuietHours       = errors.New("勿擾時段的開始與結束時間必須為不同的 HH:MM")
+)
+
+// NotificationType 會員可設定偏好的通知類型
+type NotificationType struct {
+	Type        string `json:"type" example:"admin.message"`
+	Description string `json:"description" example:"管理員訊息"`
+	// Mandatory 的通知無法關閉
+	Mandatory bool `json:"mandatory" example:"false"`
+	// DefaultEnabled 會員未設定時是否接收；false 表示需要會員選擇接收（opt-in）
+	DefaultEnabled bool `json:"default_enabled" example:"true"`
+}
+
+var notificationTypes = []NotificationType{
+	{Type: NotificationTypeSecurity, Description: "帳號安全通知", Mandatory: true, DefaultEnabled: true},
+	{Type: NotificationTypeAdminMessage, Description: "管理員訊息", DefaultEnabled: true},
+	{Type: NotificationTypeAnnouncement, Description: "產品公告與活動訊息", DefaultEnabled: false},
+}
+
+// notificationChannels 可設定偏好的管道
+var notificationChannels = []string{notify.ChannelInbox, notify.ChannelEmail, notify.ChannelWebhook}
+
+// NotificationTypes 回傳會員可設定偏好的通知類型
+func NotificationTypes() []NotificationType {
+	return slices.Clone(notificationTypes)
+}
+
+// notificationType 取得通知類型的設定；account. 開頭的事件都屬於帳號安全通知。
+// 未列出的類型（例如管理員自訂的類型）預設接收，但不接受偏好設定
+func notificationType(name string) (NotificationType, bool) {
+	if strings.HasPrefix(name, "account.") {
+		name = NotificationTypeSecurity
+	}
+	for _, t := range notificationTypes {
+		if t.Type == name {
+			return t, true
+		}
+	}
+	return NotificationType{Type: name, DefaultEnabled: true}, false
+}
+
+// QuietHours 勿擾時段，以會員時區中當天零時起算的分鐘數表示；End 小於 Start 表示跨越午夜
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours 解析 HH:MM 格式的勿擾時段
+func ParseQuietHours(start, end string) (QuietHours, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return QuietHours{}, ErrInvalidQuietHours
+	}
+	e, err := parseClock(end)
+	if err != nil || s == e {
+		return QuietHours{}, ErrInvalidQuietHours
+	}
+	return QuietHours{Start: s, End: e}, nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatClock 將當天零時起算的分鐘數格式化為 HH:MM
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// Until 若 now 落在勿擾時段內，回傳時段結束的時間；時段依 loc 的當地時間計算，跨越午夜時延續到隔天
+func (q QuietHours) Until(now time.Time, loc *time.Location) (time.Time, bool) {
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endOn := func(days int) time.Time {
+		y, m, d := local.Date()
+		return time.Date(y, m, d+days, q.End/60, q.End%60, 0, 0, loc)
+	}
+
+	if q.Start < q.End {
+		if minute >= q.Start && minute < q.End {
+			return endOn(0), true
+		}
+		return time.Time{}, false
+	}
+	if minute >= q.Start {
+		return endOn(1), true
+	}
+	if minute < q.End {
+		return endOn(0), true
+	}
+	return time.Time{}, false
+}
+
+// NotificationChannelPreference 單一管道是否接收
+type NotificationChannelPreference struct {
+	Channel string `json:"channel" example:"email"`
+	Enabled bool   `json:"enabled" example:"true"`
+}
+
+// NotificationTypePreference 單一通知類型在各管道的偏好
+type NotificationTypePreference struct {
+	NotificationType
+	Channels []NotificationChannelPreference `json:"channels"`
+}
+
+// NotificationPreferences 會員的通知偏好；QuietHoursStart 與 QuietHoursEnd 為 HH:MM，未設定勿擾時段時為空
+type NotificationPreferences struct {
+	Locale          string                       `json:"locale" example:"zh-TW"`
+	Timezone        string                       `json:"timezone" example:"Asia/Taipei"`
+	QuietHoursStart string                       `json:"quiet_hours_start,omitempty" example:"22:00"`
+	QuietHoursEnd   string                       `json:"quiet_hours_end,omitempty" example:"07:00"`
+	Types           []NotificationTypePreference `json:"types"`
+}
+
+// NotificationPreferenceUpdate 變更單一通知類型與管道的偏好
+type NotificationPreferenceUpdate struct {
+	Type    string `json:"type" binding:"required" example:"announcement"`
+	Channel string `json:"channel" binding:"required" example:"email"`
+	Enabled bool   `json:"enabled" example:"true"`
+}
+
+// NotificationPreferenceService 管理會員的通知偏好與勿擾時段，並實作 notify.Policy 供 Dispatcher 使用
+type NotificationPreferenceService struct {
+	DB *gorm.DB
+}
+
+func NewNotificationPreferenceService(db *gorm.DB) *NotificationPreferenceService {
+	return &NotificationPreferenceService{DB: db}
+}
+
+// loadLocation 載入會員的時區，未設定時使用 UTC
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// Get 取得會員的通知偏好；未設定的類型與管道顯示預設值，無法關閉的類型一律顯示為接收
+func (s *NotificationPreferenceService) Get(ctx context.Context, memberID uint) (*NotificationPreferences, error) {
+	member, err := NewMemberService(s.DB.WithContext(ctx)).GetMemberByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []models.NotificationPreference
+	if err := s.DB.WithContext(ctx).Where("member_id = ?", memberID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	stored := make(map[[2]string]bool, len(rows))
+	for _, row := range rows {
+		stored[[2]string{row.Type, row.Channel}] = row.Enabled
+	}
+
+	prefs := &NotificationPreferences{
+		Locale:   NormalizeLocale(member.Locale),
+		Timezone: member.Timezone,
+		Types:    make([]NotificationTypePreference, 0, len(notificationTypes)),
+	}
+	if prefs.Timezone == "" {
+		prefs.Timezone = "UTC"
+	}
+	if member.QuietHoursStart != nil && member.QuietHoursEnd != nil {
+		prefs.QuietHoursStart = FormatClock(*member.QuietHoursStart)
+		prefs.QuietHoursEnd = FormatClock(*member.QuietHoursEnd)
+	}
+
+	for _, t := range notificationTypes {
+		pref := NotificationTypePreference{NotificationType: t}
+		for _, channel := range notificationChannels {
+			enabled, ok := stored[[2]string{t.Type, channel}]
+			if !ok || t.Mandatory {
+				enabled = t.DefaultEnabled || t.Mandatory
+			}
+			pref.Channels = append(pref.Channels, NotificationChannelPreference{Channel: channel, Enabled: enabled})
+		}
+		prefs.Types = append(prefs.Types, pref)
+	}
+	return prefs, nil
+}
+
+// Update 變更會員的語系、時區與各通知類型的偏好；locale 與 timezone 為空時不變更。
+// 關閉無法關閉的類型時回傳 ErrMandatoryNotification，且不會套用任何變更
+func (s *NotificationPreferenceService) Update(ctx context.Context, memberID uint, locale, timezone string, updates []NotificationPreferenceUpdate) (*NotificationPreferences, error) {
+	if locale != "" && !slices.Contains(SupportedLocales, locale) {
+		return nil, ErrUnsupportedLocale
+	}
+	if timezone != "" {
+		if _, err := loadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+	for _, update := range updates {
+		t, known := notificationType(update.Type)
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownNotificationType, update.Type)
+		}
+		if !slices.Contains(notificationChannels, update.Channel) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, update.Channel)
+		}
+		if t.Mandatory && !update.Enabled {
+			return nil, fmt.Errorf("%w: %s", ErrMandatoryNotification, update.Type)
+		}
+	}
+
+	now := time.Now()
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockMember(tx, memberID); err != nil {
+			return err
+		}
+
+		fields := map[string]interface{}{}
+		if locale != "" {
+			fields["locale"] = locale
+		}
+		if timezone != "" {
+			fields["timezone"] = timezone
+		}
+		if len(fields) > 0 {
+			fields["last_modifier_id"] = memberID
+			fields["last_modification_time"] = &now
+			if err := tx.Model(&models.Member{}).Where("id = ?", memberID).Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, update := range updates {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "member_id"}, {Name: "type"}, {Name: "channel"}},
+				DoUpdates: clause.AssignmentColumns([]string{"enabled", "last_modifier_id", "last_modification_time"}),
+			}).Create(&models.NotificationPreference{
+				Base: models.Base{
+					CreationTime:         now,
+					CreatorId:            memberID,
+					LastModificationTime: &now,
+					LastModifierId:       memberID,
+				},
+				MemberID: memberID,
+				Type:     update.Type,
+				Channel:  update.Channel,
+				Enabled:  update.Enabled,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, memberID)
+}
+
+// SetQuietHours 設定勿擾時段並可同時變更時區（timezone 為空時不變更）；hours 為 nil 時取消勿擾時段
+func (s *NotificationPreferenceService) SetQuietHours(ctx context.Context, memberID uint, hours *QuietHours, timezone string) (*NotificationPreferences, error) {
+	if timezone != "" {
+		if _, err := loadLocation(timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"quiet_hours_start":      nil,
+		"quiet_hours_end":        nil,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	}
+	if hours != nil {
+		fields["quiet_hours_start"] = hours.Start
+		fields["quiet_hours_end"] = hours.End
+	}
+	if timezone != "" {
+		fields["timezone"] = timezone
+	}
+
+	result := s.DB.WithContext(ctx).Model(&models.Member{}).
+		Where("id = ? AND is_deleted = ?", memberID, false).
+		Updates(fields)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMemberNotFound
+	}
+
+	return s.Get(ctx, memberID)
+}
+
+// enabled 會員是否接收該類型在該管道的通知
+func (s *NotificationPreferenceService) enabled(ctx context.Context, memberID uint, typeName, channel string) (bool, error) {
+	t, _ := notificationType(typeName)
+	if t.Mandatory {
+		return true, nil
+	}
+
+	var row models.NotificationPreference
+	err := s.DB.WithContext(ctx).
+		Where("member_id = ? AND type = ? AND channel = ?", memberID, typeName, channel).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return t.DefaultEnabled, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return row.Enabled, nil
+}
+
+// Decide 實作 notify.Policy：會員關閉的類型與管道不傳送；勿擾時段內非緊急的通知延後到時段結束，
+// 站內收件匣不會打擾會員，不受勿擾時段影響
+func (s *NotificationPreferenceService) Decide(ctx context.Context, recipient notify.Recipient, msg notify.Message, channel string) (notify.Decision, error) {
+	enabled, err := s.enabled(ctx, recipient.MemberID, msg.Type, channel)
+	if err != nil {
+		return notify.Decision{}, err
+	}
+	if !enabled {
+		return notify.Decision{Reason: "disabled by member preference"}, nil
+	}
+	if msg.Urgent || channel == notify.ChannelInbox {
+		return notify.Decision{Allow: true}, nil
+	}
+
+	var member models.Member
+	if err := s.DB.WithContext(ctx).
+		Select("id", "timezone", "quiet_hours_start", "quiet_hours_end").
+		First(&member, recipient.MemberID).Error; err != nil {
+		return notify.Decision{}, err
+	}
+	return quietHoursDecision(&member, time.Now()), nil
+}
+
+// quietHoursDecision 會員在 now 時處於勿擾時段時延後到時段結束
+func quietHoursDecision(member *models.Member, now time.Time) notify.Decision {
+	if member.QuietHoursStart == nil || member.QuietHoursEnd == nil {
+		return notify.Decision{Allow: true}
+	}
+	loc, err := loadLocation(member.Timezone)
+	if err != nil {
+		// 時區在儲存時已驗證，載入失敗（例如系統缺少時區資料）時以 UTC 計算
+		loc = time.UTC
+	}
+
+	hours := QuietHours{Start: *member.QuietHoursStart, End: *member.QuietHoursEnd}
+	if until, quiet := hours.Until(now, loc); quiet {
+		return notify.Decision{Allow: true, DeferUntil: until}
+	}
+	return notify.Decision{Allow: true}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"member_API/models"
+	"member_API/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		start   string
+		end     string
+		want    QuietHours
+		wantErr bool
+	}{
+		{"22:00", "07:00", QuietHours{Start: 22 * 60, End: 7 * 60}, false},
+		{"00:00", "23:59", QuietHours{Start: 0, End: 23*60 + 59}, false},
+		{"13:30", "14:45", QuietHours{Start: 13*60 + 30, End: 14*60 + 45}, false},
+		{"22:00", "22:00", QuietHours{}, true},
+		{"24:00", "07:00", QuietHours{}, true},
+		{"22:00", "7am", QuietHours{}, true},
+		{"", "07:00", QuietHours{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"-"+tt.end, func(t *testing.T) {
+			hours, err := ParseQuietHours(tt.start, tt.end)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidQuietHours)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, hours)
+			assert.Equal(t, tt.start, FormatClock(hours.Start))
+			assert.Equal(t, tt.end, FormatClock(hours.End))
+		})
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+	taipei, err := time.LoadLocation("Asia/Taipei")
+	require.NoError(t, err)
+	overnight := QuietHours{Start: 22 * 60, End: 7 * 60}
+	lunch := QuietHours{Start: 12 * 60, End: 13 * 60}
+
+	tests := []struct {
+		name      string
+		hours     QuietHours
+		now       time.Time
+		wantQuiet bool
+		wantUntil time.Time
+	}{
+		{"跨午夜時段開始前", overnight, time.Date(2025, 1, 1, 21, 59, 0, 0, taipei), false, time.Time{}},
+		{"跨午夜時段的當晚", overnight, time.Date(2025, 1, 1, 22, 0, 0, 0, taipei), true, time.Date(2025, 1, 2, 7, 0, 0, 0, taipei)},
+		{"跨午夜時段的隔天清晨", overnight, time.Date(2025, 1, 2, 6, 59, 0, 0, taipei), true, time.Date(2025, 1, 2, 7, 0, 0, 0, taipei)},
+		{"跨午夜時段結束", overnight, time.Date(2025, 1, 2, 7, 0, 0, 0, taipei), false, time.Time{}},
+		{"以會員時區判斷", overnight, time.Date(2025, 1, 1, 15, 30, 0, 0, time.UTC), true, time.Date(2025, 1, 2, 7, 0, 0, 0, taipei)},
+		{"當天時段內", lunch, time.Date(2025, 1, 1, 12, 30, 0, 0, taipei), true, time.Date(2025, 1, 1, 13, 0, 0, 0, taipei)},
+		{"當天時段外", lunch, time.Date(2025, 1, 1, 23, 0, 0, 0, taipei), false, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := tt.hours.Until(tt.now, taipei)
+			assert.Equal(t, tt.wantQuiet, quiet)
+			assert.True(t, tt.wantUntil.Equal(until), "until = %v，預期 %v", until, tt.wantUntil)
+		})
+	}
+}
+
+func TestQuietHoursDecision(t *testing.T) {
+	start, end := 22*60, 7*60
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	t.Run("未設定勿擾時段", func(t *testing.T) {
+		assert.Equal(t, notify.Decision{Allow: true}, quietHoursDecision(&models.Member{}, now))
+	})
+
+	t.Run("未設定時區時以 UTC 計算", func(t *testing.T) {
+		decision := quietHoursDecision(&models.Member{QuietHoursStart: &start, QuietHoursEnd: &end}, now)
+		assert.True(t, decision.Allow)
+		assert.True(t, time.Date(2025, 1, 2, 7, 0, 0, 0, time.UTC).Equal(decision.DeferUntil))
+	})
+
+	t.Run("會員時區不在勿擾時段", func(t *testing.T) {
+		// UTC 23:00 為台北 07:00
+		decision := quietHoursDecision(&models.Member{Timezone: "Asia/Taipei", QuietHoursStart: &start, QuietHoursEnd: &end}, now)
+		assert.Equal(t, notify.Decision{Allow: true}, decision)
+	})
+}
+
+func TestNotificationType(t *testing.T) {
+	tests := []struct {
+		name          string
+		wantKnown     bool
+		wantMandatory bool
+		wantDefault   bool
+	}{
+		{NotificationTypeSecurity, true, true, true},
+		{EventPasswordChanged, true, true, true},
+		{NotificationTypeAdminMessage, true, false, true},
+		{NotificationTypeAnnouncement, true, false, false},
+		{"custom.event", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nt, known := notificationType(tt.name)
+			assert.Equal(t, tt.wantKnown, known)
+			assert.Equal(t, tt.wantMandatory, nt.Mandatory)
+			assert.Equal(t, tt.wantDefault, nt.DefaultEnabled)
+		})
+	}
+}
+
+func TestNotificationPreferenceValidation(t *testing.T) {
+	// 驗證失敗時不會存取資料庫
+	svc := NewNotificationPreferenceService(nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		locale   string
+		timezone string
+		updates  []NotificationPreferenceUpdate
+		wantErr  error
+	}{
+		{"不支援的語系", "fr", "", nil, ErrUnsupportedLocale},
+		{"無效的時區", "", "Mars/Olympus", nil, ErrInvalidTimezone},
+		{"未知的通知類型", "", "", []NotificationPreferenceUpdate{{Type: "custom.event", Channel: notify.ChannelEmail}}, ErrUnknownNotificationType},
+		{"未知的管道", "", "", []NotificationPreferenceUpdate{{Type: NotificationTypeAnnouncement, Channel: "sms", Enabled: true}}, ErrUnknownChannel},
+		{"關閉無法關閉的類型", "", "", []NotificationPreferenceUpdate{{Type: NotificationTypeSecurity, Channel: notify.ChannelEmail}}, ErrMandatoryNotification},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Update(ctx, 7, tt.locale, tt.timezone, tt.updates)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("設定勿擾時段時驗證時區", func(t *testing.T) {
+		_, err := svc.SetQuietHours(ctx, 7, &QuietHours{Start: 0, End: 60}, "Mars/Olympus")
+		assert.ErrorIs(t, err, ErrInvalidTimezone)
+	})
+}
+
+func TestDecideMandatoryInbox(t *testing.T) {
+	// 帳號安全通知無法關閉，站內收件匣不受勿擾時段影響，因此不需查詢資料庫
+	svc := NewNotificationPreferenceService(nil)
+	decision, err := svc.Decide(context.Background(), notify.Recipient{MemberID: 7}, notify.Message{Type: EventPasswordChanged}, notify.ChannelInbox)
+	require.NoError(t, err)
+	assert.Equal(t, notify.Decision{Allow: true}, decision)
+}
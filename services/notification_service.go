@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/models"
+	"member_API/notify"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotificationNotFound 通知不存在或不屬於該會員
+var ErrNotificationNotFound = errors.New("通知不存在")
+
+// NotificationService 管理會員的通知收件匣
+type NotificationService struct {
+	DB *gorm.DB
+}
+
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{DB: db}
+}
+
+// NotificationFilter 查詢收件匣的條件；Unread 與 Archived 為 nil 表示不篩選
+type NotificationFilter struct {
+	Unread   *bool
+	Archived *bool
+	Type     string
+	Limit    int
+	Offset   int
+}
+
+// Create 將通知放入會員的收件匣，未指定 MessageID 時自動產生
+func (s *NotificationService) Create(ctx context.Context, notification *models.Notification) error {
+	if notification.MessageID == "" {
+		id, err := notify.NewMessageID()
+		if err != nil {
+			return err
+		}
+		notification.MessageID = id
+	}
+	if notification.CreationTime.IsZero() {
+		notification.CreationTime = time.Now()
+	}
+	return s.DB.WithContext(ctx).Create(notification).Error
+}
+
+// inbox 會員未刪除的通知
+func (s *NotificationService) inbox(ctx context.Context, memberID uint) *gorm.DB {
+	return s.DB.WithContext(ctx).Model(&models.Notification{}).
+		Where("member_id = ? AND is_deleted = ?", memberID, false)
+}
+
+// List 依條件查詢會員的通知（新到舊），並回傳符合條件的總數
+func (s *NotificationService) List(ctx context.Context, memberID uint, filter NotificationFilter) ([]models.Notification, int64, error) {
+	query := s.inbox(ctx, memberID)
+	if filter.Unread != nil {
+		if *filter.Unread {
+			query = query.Where("read_at IS NULL")
+		} else {
+			query = query.Where("read_at IS NOT NULL")
+		}
+	}
+	if filter.Archived != nil {
+		if *filter.Archived {
+			query = query.Where("archived_at IS NOT NULL")
+		} else {
+			query = query.Where("archived_at IS NULL")
+		}
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	notifications := []models.Notification{}
+	if err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// UnreadCount 收件匣中（未封存）的未讀通知數量
+func (s *NotificationService) UnreadCount(ctx context.Context, memberID uint) (int64, error) {
+	var count int64
+	err := s.inbox(ctx, memberID).Where("read_at IS NULL AND archived_at IS NULL").Count(&count).Error
+	return count, err
+}
+
+// Get 取得會員的單一通知
+func (s *NotificationService) Get(ctx context.Context, memberID, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := s.inbox(ctx, memberID).First(&notification, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkRead 將通知標記為已讀（read 為 false 時標記為未讀），回傳更新後的通知；狀態未改變時不寫入
+func (s *NotificationService) MarkRead(ctx context.Context, memberID, id uint, read bool) (*models.Notification, error) {
+	return s.setTimestamp(ctx, memberID, id, "read_at", read)
+}
+
+// Archive 將通知移出收件匣（archived 為 false 時移回收件匣），回傳更新後的通知；狀態未改變時不寫入
+func (s *NotificationService) Archive(ctx context.Context, memberID, id uint, archived bool) (*models.Notification, error) {
+	return s.setTimestamp(ctx, memberID, id, "archived_at", archived)
+}
+
+// setTimestamp 設定或清除通知的狀態時間欄位
+func (s *NotificationService) setTimestamp(ctx context.Context, memberID, id uint, column string, set bool) (*models.Notification, error) {
+	notification, err := s.Get(ctx, memberID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	current := notification.ReadAt
+	if column == "archived_at" {
+		current = notification.ArchivedAt
+	}
+	if (current != nil) == set {
+		return notification, nil
+	}
+
+	now := time.Now()
+	var value *time.Time
+	if set {
+		value = &now
+	}
+	if err := s.DB.WithContext(ctx).Model(notification).Updates(map[string]interface{}{
+		column:                   value,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	if column == "archived_at" {
+		notification.ArchivedAt = value
+	} else {
+		notification.ReadAt = value
+	}
+	return notification, nil
+}
+
+// MarkAllRead 將會員所有未讀通知標記為已讀，回傳更新的數量
+func (s *NotificationService) MarkAllRead(ctx context.Context, memberID uint) (int64, error) {
+	now := time.Now()
+	result := s.inbox(ctx, memberID).Where("read_at IS NULL").Updates(map[string]interface{}{
+		"read_at":                &now,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// Delete 刪除會員的通知（軟刪除）
+func (s *NotificationService) Delete(ctx context.Context, memberID, id uint) error {
+	now := time.Now()
+	result := s.inbox(ctx, memberID).Where("id = ?", id).Updates(map[string]interface{}{
+		"is_deleted":             true,
+		"deleted_at":             &now,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"io/fs"
+	"member_API/mailer"
+	"member_API/models"
+	"member_API/notify"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 以範本產生內容的事件類型
+const (
+	EventEmailVerification    = "account.email_verification"
+	EventPasswordReset        = "account.password_reset"
+	EventPasswordChanged      = "account.password_changed"
+	EventEmailChangeConfirm   = "account.email_change_confirm"
+	EventEmailChangeRequested = "account.email_change_requested"
+	EventDeletionScheduled    = "account.deletion_scheduled"
+	EventMagicLink            = "account.magic_link"
+	EventAdminMessage         = NotificationTypeAdminMessage
+	EventAnnouncement         = NotificationTypeAnnouncement
+)
+
+// DefaultLocale 會員未設定語系或範本沒有該語系時使用的語系
+const DefaultLocale = "zh-TW"
+
+// SupportedLocales 提供範本的語系
+var SupportedLocales = []string{"zh-TW", "en"}
+
+var (
+	ErrTemplateNotFound         = errors.New("通知範本不存在")
+	ErrUnknownNotificationEvent = errors.New("此事件類型不支援範本")
+	ErrUnsupportedChannel       = errors.New("此事件不支援該管道")
+	ErrUnsupportedLocale        = errors.New("不支援的語系")
+	ErrHTMLNotSupported         = errors.New("只有 email 管道支援 HTML")
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// defaultTemplates 內建的範本，路徑格式見 notify.LoadTemplates
+var defaultTemplates = mustLoadTemplates()
+
+func mustLoadTemplates() map[notify.TemplateKey]notify.Template {
+	sub, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+	templates, err := notify.LoadTemplates(sub)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// NotificationEvent 可套用範本的事件。Sample 的 key 即範本可使用的變數，並作為預覽時的範例資料；
+// 所有事件都提供收件會員的名稱 Name
+type NotificationEvent struct {
+	Type     string                 `json:"type" example:"account.password_changed"`
+	Channels []string               `json:"channels" example:"email"`
+	Sample   map[string]interface{} `json:"sample"`
+}
+
+// Variables 範本可使用的變數（依名稱排序）
+func (e NotificationEvent) Variables() []string {
+	variables := make([]string, 0, len(e.Sample))
+	for name := range e.Sample {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+var notificationEvents = map[string]NotificationEvent{
+	EventEmailVerification: {
+		Type:     EventEmailVerification,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":           "張三",
+			"Link":           "https://app.example.com/verify-email?token=sample",
+			"ExpiresInHours": 24,
+		},
+	},
+	EventPasswordReset: {
+		Type:     EventPasswordReset,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":             "張三",
+			"Link":             "https://app.example.com/reset-password?token=sample",
+			"ExpiresInMinutes": 30,
+		},
+	},
+	EventPasswordChanged: {
+		Type:     EventPasswordChanged,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":      "張三",
+			"ChangedAt": "2025-01-01 12:00",
+		},
+	},
+	EventEmailChangeConfirm: {
+		Type:     EventEmailChangeConfirm,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":           "張三",
+			"NewEmail":       "new@example.com",
+			"Link":           "https://app.example.com/confirm-email-change?token=sample",
+			"ExpiresInHours": 24,
+		},
+	},
+	EventEmailChangeRequested: {
+		Type:     EventEmailChangeRequested,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":     "張三",
+			"NewEmail": "new@example.com",
+		},
+	},
+	EventDeletionScheduled: {
+		Type:     EventDeletionScheduled,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":        "張三",
+			"ScheduledAt": "2025-01-31 12:00",
+		},
+	},
+	EventMagicLink: {
+		Type:     EventMagicLink,
+		Channels: []string{notify.ChannelEmail},
+		Sample: map[string]interface{}{
+			"Name":             "張三",
+			"Link":             "https://app.example.com/magic-login?token=sample",
+			"ExpiresInMinutes": 15,
+		},
+	},
+	EventAdminMessage: {
+		Type:     EventAdminMessage,
+		Channels: notificationChannels,
+		Sample: map[string]interface{}{
+			"Name":  "張三",
+			"Title": "系統維護通知",
+			"Body":  "系統將於 2025-01-01 02:00 進行維護。",
+		},
+	},
+	EventAnnouncement: {
+		Type:     EventAnnouncement,
+		Channels: notificationChannels,
+		Sample: map[string]interface{}{
+			"Name":  "張三",
+			"Title": "新功能上線",
+			"Body":  "歡迎試用全新的通知設定。",
+		},
+	},
+}
+
+// NotificationEvents 回傳所有可套用範本的事件（依類型排序）
+func NotificationEvents() []NotificationEvent {
+	events := make([]NotificationEvent, 0, len(notificationEvents))
+	for _, event := range notificationEvents {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Type < events[j].Type })
+	return events
+}
+
+// NormalizeLocale 將語系標籤（例如 Accept-Language 的第一個值 "en-US,en;q=0.9"）對應到支援的語系，
+// 無法對應時回傳 DefaultLocale
+func NormalizeLocale(tag string) string {
+	tag, _, _ = strings.Cut(tag, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, locale := range SupportedLocales {
+		if tag == strings.ToLower(locale) {
+			return locale
+		}
+	}
+	switch {
+	case strings.HasPrefix(tag, "en"):
+		return "en"
+	case strings.HasPrefix(tag, "zh"):
+		return "zh-TW"
+	}
+	return DefaultLocale
+}
+
+// NotificationTemplateEntry 範本目前的內容；Overridden 表示使用資料庫中的自訂範本而非內建範本
+type NotificationTemplateEntry struct {
+	notify.TemplateKey
+	notify.Template
+	Overridden bool     `json:"overridden"`
+	Variables  []string `json:"variables"`
+}
+
+// NotificationTemplateService 管理通知範本：內建範本嵌入在程式中，管理員可依事件類型、管道與語系以資料庫中的範本覆寫。
+// DB 為 nil 時只使用內建範本
+type NotificationTemplateService struct {
+	DB *gorm.DB
+}
+
+func NewNotificationTemplateService(db *gorm.DB) *NotificationTemplateService {
+	return &NotificationTemplateService{DB: db}
+}
+
+// checkTemplateKey 確認事件支援範本、管道與語系
+func checkTemplateKey(key notify.TemplateKey) (NotificationEvent, error) {
+	event, ok := notificationEvents[key.Type]
+	if !ok {
+		return NotificationEvent{}, ErrUnknownNotificationEvent
+	}
+	if !slices.Contains(event.Channels, key.Channel) {
+		return NotificationEvent{}, ErrUnsupportedChannel
+	}
+	if !slices.Contains(SupportedLocales, key.Locale) {
+		return NotificationEvent{}, ErrUnsupportedLocale
+	}
+	return event, nil
+}
+
+// validateTemplate 檢查範本語法與變數；HTML 只用於 email，其他管道的範本不可提供
+func validateTemplate(event NotificationEvent, key notify.TemplateKey, t notify.Template) error {
+	if t.HTML != "" && key.Channel != notify.ChannelEmail {
+		return &notify.TemplateError{Part: "html", Err: ErrHTMLNotSupported}
+	}
+	return t.Validate(event.Variables())
+}
+
+// override 取得資料庫中的自訂範本，沒有時回傳 nil
+func (s *NotificationTemplateService) override(ctx context.Context, key notify.TemplateKey) (*models.NotificationTemplate, error) {
+	if s.DB == nil {
+		return nil, nil
+	}
+	var record models.NotificationTemplate
+	err := s.DB.WithContext(ctx).
+		Where("type = ? AND channel = ? AND locale = ?", key.Type, key.Channel, key.Locale).
+		First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// entry 取得單一語系目前的範本，不會改用預設語系
+func (s *NotificationTemplateService) entry(ctx context.Context, key notify.TemplateKey) (*NotificationTemplateEntry, error) {
+	entry := &NotificationTemplateEntry{TemplateKey: key}
+	if event, ok := notificationEvents[key.Type]; ok {
+		entry.Variables = event.Variables()
+	}
+
+	record, err := s.override(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		entry.Template = notify.Template{Subject: record.Subject, Text: record.Text, HTML: record.HTML}
+		entry.Overridden = true
+		return entry, nil
+	}
+	if t, ok := defaultTemplates[key]; ok {
+		entry.Template = t
+		return entry, nil
+	}
+	return nil, ErrTemplateNotFound
+}
+
+// Lookup 取得範本：依序使用該語系的自訂範本、該語系的內建範本，再改用 DefaultLocale 的範本
+func (s *NotificationTemplateService) Lookup(ctx context.Context, key notify.TemplateKey) (notify.Template, error) {
+	key.Locale = NormalizeLocale(key.Locale)
+	locales := []string{key.Locale}
+	if key.Locale != DefaultLocale {
+		locales = append(locales, DefaultLocale)
+	}
+	for _, locale := range locales {
+		key.Locale = locale
+		entry, err := s.entry(ctx, key)
+		if errors.Is(err, ErrTemplateNotFound) {
+			continue
+		}
+		if err != nil {
+			return notify.Template{}, err
+		}
+		return entry.Template, nil
+	}
+	return notify.Template{}, ErrTemplateNotFound
+}
+
+// Render 以 data 渲染事件在該管道與語系的範本
+func (s *NotificationTemplateService) Render(ctx context.Context, key notify.TemplateKey, data map[string]interface{}) (notify.Rendered, error) {
+	t, err := s.Lookup(ctx, key)
+	if err != nil {
+		return notify.Rendered{}, err
+	}
+	return t.Render(data)
+}
+
+// List 列出所有事件、管道與語系目前的範本
+func (s *NotificationTemplateService) List(ctx context.Context) ([]NotificationTemplateEntry, error) {
+	entries := []NotificationTemplateEntry{}
+	for _, event := range NotificationEvents() {
+		for _, channel := range event.Channels {
+			for _, locale := range SupportedLocales {
+				entry, err := s.entry(ctx, notify.TemplateKey{Type: event.Type, Channel: channel, Locale: locale})
+				if errors.Is(err, ErrTemplateNotFound) {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, *entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Save 檢查範本語法與變數後儲存為自訂範本，取代內建範本；
+// 範本錯誤時回傳 *notify.TemplateError
+func (s *NotificationTemplateService) Save(ctx context.Context, key notify.TemplateKey, t notify.Template, actorID uint) (*NotificationTemplateEntry, error) {
+	event, err := checkTemplateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(event, key, t); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.NotificationTemplate{
+		Base: models.Base{
+			CreationTime:         now,
+			CreatorId:            actorID,
+			LastModificationTime: &now,
+			LastModifierId:       actorID,
+		},
+		Type:    key.Type,
+		Channel: key.Channel,
+		Locale:  key.Locale,
+		Subject: t.Subject,
+		Text:    t.Text,
+		HTML:    t.HTML,
+	}
+	if err := s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "type"}, {Name: "channel"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "text", "html", "last_modifier_id", "last_modification_time"}),
+	}).Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	return &NotificationTemplateEntry{
+		TemplateKey: key,
+		Template:    t,
+		Overridden:  true,
+		Variables:   event.Variables(),
+	}, nil
+}
+
+// Reset 刪除自訂範本，改回使用內建範本
+func (s *NotificationTemplateService) Reset(ctx context.Context, key notify.TemplateKey) error {
+	if _, err := checkTemplateKey(key); err != nil {
+		return err
+	}
+	result := s.DB.WithContext(ctx).
+		Where("type = ? AND channel = ? AND locale = ?", key.Type, key.Channel, key.Locale).
+		Delete(&models.NotificationTemplate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// Preview 以事件的範例資料渲染範本；t 為 nil 時渲染目前使用的範本，否則先檢查 t 再渲染。
+// data 中的值會取代範例資料中同名的變數
+func (s *NotificationTemplateService) Preview(ctx context.Context, key notify.TemplateKey, t *notify.Template, data map[string]interface{}) (notify.Rendered, error) {
+	event, err := checkTemplateKey(key)
+	if err != nil {
+		return notify.Rendered{}, err
+	}
+
+	var tmpl notify.Template
+	if t != nil {
+		if err := validateTemplate(event, key, *t); err != nil {
+			return notify.Rendered{}, err
+		}
+		tmpl = *t
+	} else if tmpl, err = s.Lookup(ctx, key); err != nil {
+		return notify.Rendered{}, err
+	}
+
+	sample := make(map[string]interface{}, len(event.Sample))
+	for name, value := range event.Sample {
+		sample[name] = value
+	}
+	for name, value := range data {
+		sample[name] = value
+	}
+	return tmpl.Render(sample)
+}
+
+// renderMail 以收件會員語系的 email 範本產生郵件；data 不需包含會員名稱
+func renderMail(tx *gorm.DB, eventType string, member *models.Member, to string, data map[string]interface{}) (mailer.Message, error) {
+	ctx := context.Background()
+	if tx != nil {
+		ctx = tx.Statement.Context
+	}
+	vars := map[string]interface{}{"Name": member.Name}
+	for name, value := range data {
+		vars[name] = value
+	}
+
+	rendered, err := NewNotificationTemplateService(tx).Render(ctx, notify.TemplateKey{
+		Type:    eventType,
+		Channel: notify.ChannelEmail,
+		Locale:  member.Locale,
+	}, vars)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		To:      []string{to},
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	}, nil
+}
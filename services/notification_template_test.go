@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"member_API/models"
+	"member_API/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTemplates(t *testing.T) {
+	for _, event := range NotificationEvents() {
+		assert.Contains(t, event.Variables(), "Name", "%s 應提供會員名稱", event.Type)
+		for _, channel := range event.Channels {
+			for _, locale := range SupportedLocales {
+				key := notify.TemplateKey{Type: event.Type, Channel: channel, Locale: locale}
+				tmpl, ok := defaultTemplates[key]
+				if !assert.True(t, ok, "缺少內建範本 %+v", key) {
+					continue
+				}
+				assert.NoError(t, tmpl.Validate(event.Variables()), "%+v", key)
+				_, err := tmpl.Render(event.Sample)
+				assert.NoError(t, err, "%+v", key)
+			}
+		}
+	}
+
+	// 每個內建範本都必須屬於可套用範本的事件與管道，否則管理員無法覆寫
+	for key := range defaultTemplates {
+		_, err := checkTemplateKey(key)
+		assert.NoError(t, err, "%+v", key)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"", DefaultLocale},
+		{"zh-TW", "zh-TW"},
+		{"zh-tw", "zh-TW"},
+		{"zh-Hant-TW", "zh-TW"},
+		{"en", "en"},
+		{"en-US,en;q=0.9", "en"},
+		{"EN-gb", "en"},
+		{"fr-FR", DefaultLocale},
+		{"ja;q=0.8", DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeLocale(tt.tag))
+		})
+	}
+}
+
+func TestRenderMail(t *testing.T) {
+	member := &models.Member{Name: "張三", Email: "zhang@example.com"}
+	data := map[string]interface{}{"ChangedAt": "2025-01-01 12:00"}
+
+	t.Run("預設語系", func(t *testing.T) {
+		msg, err := renderMail(nil, EventPasswordChanged, member, member.Email, data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"zhang@example.com"}, msg.To)
+		assert.Equal(t, "您的密碼已變更", msg.Subject)
+		assert.Equal(t, "張三 您好：\n\n您的帳號密碼已於 2025-01-01 12:00 變更，其他裝置已登出。\n\n如果這不是您本人的操作，請立即使用忘記密碼重設密碼。\n", msg.Text)
+		assert.Contains(t, msg.HTML, "<p>張三 您好：</p>")
+	})
+
+	t.Run("會員語系", func(t *testing.T) {
+		en := *member
+		en.Locale = "en"
+		msg, err := renderMail(nil, EventPasswordChanged, &en, "other@example.com", data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"other@example.com"}, msg.To)
+		assert.Equal(t, "Your password was changed", msg.Subject)
+		assert.Contains(t, msg.Text, "Hi 張三,")
+	})
+
+	t.Run("不支援的語系改用預設語系", func(t *testing.T) {
+		fr := *member
+		fr.Locale = "fr"
+		msg, err := renderMail(nil, EventPasswordChanged, &fr, fr.Email, data)
+		require.NoError(t, err)
+		assert.Equal(t, "您的密碼已變更", msg.Subject)
+	})
+
+	t.Run("沒有範本的事件", func(t *testing.T) {
+		_, err := renderMail(nil, "custom.event", member, member.Email, nil)
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+	})
+}
+
+func TestPreviewNotificationTemplate(t *testing.T) {
+	svc := NewNotificationTemplateService(nil)
+	key := notify.TemplateKey{Type: EventPasswordReset, Channel: notify.ChannelEmail, Locale: "en"}
+
+	t.Run("目前的範本與範例資料", func(t *testing.T) {
+		rendered, err := svc.Preview(context.Background(), key, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Reset your password", rendered.Subject)
+		assert.Contains(t, rendered.Text, "https://app.example.com/reset-password?token=sample")
+		assert.Contains(t, rendered.Text, "within 30 minutes")
+	})
+
+	t.Run("以 data 取代範例資料", func(t *testing.T) {
+		rendered, err := svc.Preview(context.Background(), key, nil, map[string]interface{}{"Name": "Alice"})
+		require.NoError(t, err)
+		assert.Contains(t, rendered.Text, "Hi Alice,")
+	})
+
+	t.Run("尚未儲存的範本", func(t *testing.T) {
+		rendered, err := svc.Preview(context.Background(), key, &notify.Template{
+			Subject: "Reset for {{.Name}}",
+			Text:    "{{.Link}}",
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Reset for 張三", rendered.Subject)
+	})
+
+	tests := []struct {
+		name     string
+		key      notify.TemplateKey
+		template *notify.Template
+		wantErr  error
+	}{
+		{"引用未知的變數", key, &notify.Template{Subject: "hi", Text: "{{.Password}}"}, notify.ErrUnknownVariable},
+		{"不支援範本的事件", notify.TemplateKey{Type: "custom.event", Channel: notify.ChannelEmail, Locale: "en"}, nil, ErrUnknownNotificationEvent},
+		{"事件不支援的管道", notify.TemplateKey{Type: EventPasswordReset, Channel: notify.ChannelWebhook, Locale: "en"}, nil, ErrUnsupportedChannel},
+		{"email 以外的管道不支援 HTML", notify.TemplateKey{Type: EventAdminMessage, Channel: notify.ChannelInbox, Locale: "en"}, &notify.Template{Subject: "{{.Title}}", Text: "{{.Body}}", HTML: "<p>{{.Body}}</p>"}, ErrHTMLNotSupported},
+		{"不支援的語系", notify.TemplateKey{Type: EventPasswordReset, Channel: notify.ChannelEmail, Locale: "fr"}, nil, ErrUnsupportedLocale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Preview(context.Background(), tt.key, tt.template, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPreviewNotificationTemplateChannels(t *testing.T) {
+	svc := NewNotificationTemplateService(nil)
+	data := map[string]interface{}{"Title": "新功能上線", "Body": "歡迎試用"}
+
+	tests := []struct {
+		channel string
+		locale  string
+		subject string
+		text    string
+		html    bool
+	}{
+		{notify.ChannelInbox, "zh-TW", "【公告】新功能上線", "歡迎試用\n", false},
+		{notify.ChannelWebhook, "en", "[Announcement] 新功能上線", "歡迎試用\n", false},
+		{notify.ChannelEmail, "en", "[Announcement] 新功能上線", "Hi 張三,", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			key := notify.TemplateKey{Type: EventAnnouncement, Channel: tt.channel, Locale: tt.locale}
+			rendered, err := svc.Preview(context.Background(), key, nil, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.subject, rendered.Subject)
+			assert.Contains(t, rendered.Text, tt.text)
+			assert.Equal(t, tt.html, rendered.HTML != "")
+
+			// 尚未儲存的範本同樣依管道檢查
+			_, err = svc.Preview(context.Background(), key, &notify.Template{Subject: "{{.Title}}", Text: "{{.Link}}"}, nil)
+			assert.ErrorIs(t, err, notify.ErrUnknownVariable)
+		})
+	}
+}
+
+func TestLocalizeNotification(t *testing.T) {
+	svc := NewNotificationService(nil)
+	recipient := notify.Recipient{MemberID: 7, Name: "Alice", Locale: "en"}
+
+	t.Run("以會員語系的站內範本產生標題", func(t *testing.T) {
+		msg, err := svc.localize(context.Background(), recipient, notify.ChannelInbox, notify.Message{
+			Type: EventAnnouncement, Title: "New feature", Body: "Try it out",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "[Announcement] New feature", msg.Title)
+		assert.Equal(t, "Try it out", msg.Body)
+		assert.Empty(t, msg.HTML)
+	})
+
+	t.Run("沒有範本的事件維持原本的內容", func(t *testing.T) {
+		msg, err := svc.localize(context.Background(), recipient, notify.ChannelInbox, notify.Message{
+			Type: "custom.event", Title: "Hello", Body: "World",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", msg.Title)
+		assert.Equal(t, "World", msg.Body)
+	})
+}
+
+func TestNotificationEventVariables(t *testing.T) {
+	event := notificationEvents[EventEmailChangeConfirm]
+	variables := event.Variables()
+	assert.True(t, slices.IsSorted(variables))
+	assert.Equal(t, []string{"ExpiresInHours", "Link", "Name", "NewEmail"}, variables)
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"member_API/auth"
+	"member_API/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outbox 訊息的狀態
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxDead      = "dead"
+)
+
+// outbox 訊息的主題
+const (
+	OutboxTopicMail         = "mail"
+	OutboxTopicNotification = "notification"
+)
+
+const (
+	// outboxBatchSize 每次認領的訊息數量
+	outboxBatchSize = 10
+	// outboxLeaseMargin 認領期限比處理器逾時多出的時間，確保處理器結束並寫回結果前訊息不會被再次認領
+	outboxLeaseMargin = time.Minute
+	// maxOutboxErrorLength 與 models.OutboxMessage.LastError 欄位長度一致
+	maxOutboxErrorLength = 1000
+)
+
+// ErrOutboxPermanent 處理器回傳包裝此錯誤的 error 時不再重試，訊息直接進入 dead 狀態
+var ErrOutboxPermanent = errors.New("permanent outbox failure")
+
+// OutboxDeferError 處理器回傳此錯誤時訊息延後到 Until 再處理，不計入嘗試次數
+type OutboxDeferError struct {
+	Until time.Time
+}
+
+func (e *OutboxDeferError) Error() string {
+	return "deferred until " + e.Until.UTC().Format(time.RFC3339)
+}
+
+// OutboxHandler 處理一則 outbox 訊息；回傳 error 時依退避時間重試
+type OutboxHandler func(ctx context.Context, payload []byte) error
+
+// EnqueueOutbox 在 tx 中寫入一則待處理的 outbox 訊息，與業務資料一併提交或回滾；
+// memberID 為訊息內容相關的會員（0 表示無），會員匿名化時一併刪除
+func EnqueueOutbox(tx *gorm.DB, memberID uint, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return tx.Create(&models.OutboxMessage{
+		Base: models.Base{
+			CreationTime: now,
+		},
+		MemberID:      memberID,
+		Topic:         topic,
+		Payload:       string(data),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+	}).Error
+}
+
+// OutboxOptions outbox worker 的設定，零值欄位使用預設值
+type OutboxOptions struct {
+	// Workers 同時處理訊息的 goroutine 數量，預設 4
+	Workers int
+	// PollInterval 沒有待處理訊息時的輪詢間隔，預設 1 秒
+	PollInterval time.Duration
+	// MaxAttempts 最多嘗試次數，超過後訊息進入 dead 狀態，預設 8
+	MaxAttempts int
+	// BaseBackoff 第一次失敗後的等待時間，之後每次加倍（加上隨機抖動），最長 MaxBackoff；預設 10 秒與 1 小時
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Retention 已送達的訊息保留多久後刪除（內容可能含 email 等個人資料），預設 7 天；dead 狀態的訊息保留供檢查
+	Retention time.Duration
+	// HandlerTimeout 單則訊息的處理期限，預設 1 分鐘；認領期限為此值加上 outboxLeaseMargin，
+	// worker 在認領期限內未回報結果（例如程序中止）時訊息會再次被認領
+	HandlerTimeout time.Duration
+}
+
+// OutboxWorker 以 SELECT ... FOR UPDATE SKIP LOCKED 認領到期的 outbox 訊息並交給對應主題的處理器，
+// 多個 worker（或多個程序）可同時執行而不會重複處理同一則訊息
+type OutboxWorker struct {
+	DB       *gorm.DB
+	Handlers map[string]OutboxHandler
+	Options  OutboxOptions
+
+	now    func() time.Time
+	jitter func(n int64) int64
+
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewOutboxWorker 建立 outbox worker；handlers 以主題為 key
+func NewOutboxWorker(db *gorm.DB, handlers map[string]OutboxHandler, opts OutboxOptions) *OutboxWorker {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 10 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.MaxBackoff < opts.BaseBackoff {
+		opts.MaxBackoff = opts.BaseBackoff
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.HandlerTimeout <= 0 {
+		opts.HandlerTimeout = time.Minute
+	}
+	return &OutboxWorker{
+		DB:       db,
+		Handlers: handlers,
+		Options:  opts,
+		now:      time.Now,
+		jitter:   rand.Int64N,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start 啟動 worker，直到呼叫 Shutdown
+func (w *OutboxWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	for range w.Options.Workers {
+		w.wg.Add(1)
+		go w.run(ctx)
+	}
+	w.wg.Add(1)
+	go w.purgeLoop(ctx)
+}
+
+// Shutdown 停止認領新的訊息，並等待處理中的訊息完成；ctx 結束時中斷處理中的訊息後返回，
+// 未完成的訊息在認領期限過後會再次被處理
+func (w *OutboxWorker) Shutdown(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if w.cancel != nil {
+			w.cancel()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (w *OutboxWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		messages, err := w.claim(ctx)
+		if err != nil {
+			log.Printf("Error claiming outbox messages: %v\n", err)
+		}
+		for i := range messages {
+			w.process(ctx, &messages[i])
+		}
+		if len(messages) == outboxBatchSize {
+			continue
+		}
+
+		select {
+		case <-w.stop:
+			return
+		case <-time.After(w.Options.PollInterval):
+		}
+	}
+}
+
+// purgeLoop 每小時刪除超過保留期限的已送達訊息
+func (w *OutboxWorker) purgeLoop(ctx context.Context) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if _, err := w.Purge(ctx); err != nil {
+				log.Printf("Error purging outbox messages: %v\n", err)
+			}
+		}
+	}
+}
+
+// Purge 刪除超過保留期限的已送達訊息，回傳刪除的數量
+func (w *OutboxWorker) Purge(ctx context.Context) (int64, error) {
+	result := w.DB.WithContext(ctx).
+		Where("status = ? AND processed_at < ?", OutboxDelivered, w.now().Add(-w.Options.Retention)).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
+
+// lease 認領期限，比處理器逾時長，處理中的訊息不會被其他 worker 再次認領
+func (w *OutboxWorker) lease() time.Duration {
+	return w.Options.HandlerTimeout + outboxLeaseMargin
+}
+
+// claim 認領一批到期的訊息：鎖定其他 worker 尚未鎖定的資料列，增加嘗試次數、寫入這次認領的 token，
+// 並將下次處理時間延到認領期限之後
+func (w *OutboxWorker) claim(ctx context.Context) ([]models.OutboxMessage, error) {
+	token, err := auth.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []models.OutboxMessage
+	now := w.now()
+	err = w.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxPending, now).
+			Order("next_attempt_at, id").
+			Limit(outboxBatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+			messages[i].Attempts++
+			messages[i].ClaimToken = token
+		}
+		return tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"claim_token":     token,
+				"next_attempt_at": now.Add(w.lease()),
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// process 交給處理器並記錄結果；訊息已被其他 worker 重新認領時捨棄結果
+func (w *OutboxWorker) process(ctx context.Context, msg *models.OutboxMessage) {
+	err := w.handle(ctx, msg)
+	now := w.now()
+
+	updates := map[string]interface{}{"last_error": "", "claim_token": ""}
+	var deferred *OutboxDeferError
+	switch {
+	case err == nil:
+		updates["status"] = OutboxDelivered
+		updates["processed_at"] = &now
+	case errors.As(err, &deferred):
+		// 延後不是失敗，認領時增加的嘗試次數需扣回
+		updates["next_attempt_at"] = deferred.Until
+		updates["attempts"] = gorm.Expr("attempts - 1")
+	case errors.Is(err, ErrOutboxPermanent) || msg.Attempts >= w.Options.MaxAttempts:
+		updates["status"] = OutboxDead
+		updates["processed_at"] = &now
+		log.Printf("Error: outbox message %d (%s) dead-lettered after %d attempts: %v\n", msg.ID, msg.Topic, msg.Attempts, err)
+	default:
+		updates["next_attempt_at"] = now.Add(w.backoff(msg.Attempts))
+	}
+	if err != nil && deferred == nil {
+		lastError := err.Error()
+		if len(lastError) > maxOutboxErrorLength {
+			lastError = lastError[:maxOutboxErrorLength]
+		}
+		updates["last_error"] = lastError
+	}
+
+	// 處理器可能因 Shutdown 逾時被中斷，結果仍需寫回
+	result := w.DB.WithContext(context.WithoutCancel(ctx)).Model(&models.OutboxMessage{}).
+		Where("id = ? AND claim_token = ?", msg.ID, msg.ClaimToken).
+		Updates(updates)
+	if result.Error != nil {
+		log.Printf("Error updating outbox message %d: %v\n", msg.ID, result.Error)
+	} else if result.RowsAffected == 0 {
+		log.Printf("Warning: outbox message %d was reclaimed before its result was recorded\n", msg.ID)
+	}
+}
+
+// handle 在 HandlerTimeout 內呼叫主題對應的處理器，處理器 panic 時視為失敗
+func (w *OutboxWorker) handle(ctx context.Context, msg *models.OutboxMessage) (err error) {
+	handler, ok := w.Handlers[msg.Topic]
+	if !ok {
+		return fmt.Errorf("no handler for outbox topic %q", msg.Topic)
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.Options.HandlerTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("outbox handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, []byte(msg.Payload))
+}
+
+// backoff 第 attempts 次失敗後的等待時間：BaseBackoff 乘以 2^(attempts-1)，最長 MaxBackoff，
+// 再取其一半加上隨機的另一半（equal jitter），避免大量訊息同時重試
+func (w *OutboxWorker) backoff(attempts int) time.Duration {
+	delay := w.Options.MaxBackoff
+	if attempts < 1 {
+		attempts = 1
+	}
+	if shift := attempts - 1; shift < 32 {
+		if d := w.Options.BaseBackoff << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(w.jitter(int64(delay-half)+1))
+}
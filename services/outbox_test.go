@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOutboxWorkerDefaults(t *testing.T) {
+	w := NewOutboxWorker(nil, nil, OutboxOptions{})
+	assert.Equal(t, 4, w.Options.Workers)
+	assert.Equal(t, time.Second, w.Options.PollInterval)
+	assert.Equal(t, 8, w.Options.MaxAttempts)
+	assert.Equal(t, 10*time.Second, w.Options.BaseBackoff)
+	assert.Equal(t, time.Hour, w.Options.MaxBackoff)
+	assert.Equal(t, 7*24*time.Hour, w.Options.Retention)
+	assert.Equal(t, time.Minute, w.Options.HandlerTimeout)
+	assert.Greater(t, w.lease(), w.Options.HandlerTimeout, "認領期限必須比處理器逾時長")
+
+	// 最長等待時間不可小於第一次的等待時間
+	w = NewOutboxWorker(nil, nil, OutboxOptions{BaseBackoff: time.Minute, MaxBackoff: time.Second})
+	assert.Equal(t, time.Minute, w.Options.MaxBackoff)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	w := NewOutboxWorker(nil, nil, OutboxOptions{BaseBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+
+	tests := []struct {
+		name     string
+		attempts int
+		min      time.Duration
+		max      time.Duration
+	}{
+		{"第一次失敗", 1, 5 * time.Second, 10 * time.Second},
+		{"第二次失敗加倍", 2, 10 * time.Second, 20 * time.Second},
+		{"第三次失敗", 3, 20 * time.Second, 40 * time.Second},
+		{"超過上限", 4, 30 * time.Second, time.Minute},
+		{"大量失敗不溢位", 100, 30 * time.Second, time.Minute},
+		{"嘗試次數為 0 視為第一次", 0, 5 * time.Second, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.jitter = func(n int64) int64 { return 0 }
+			assert.Equal(t, tt.min, w.backoff(tt.attempts), "最少等待一半")
+
+			w.jitter = func(n int64) int64 { return n - 1 }
+			assert.Equal(t, tt.max, w.backoff(tt.attempts), "最多等待完整時間")
+		})
+	}
+}
+
+func TestOutboxHandle(t *testing.T) {
+	errHandler := errors.New("smtp unavailable")
+	w := NewOutboxWorker(nil, map[string]OutboxHandler{
+		"ok": func(ctx context.Context, payload []byte) error {
+			if string(payload) != `{"a":1}` {
+				return errors.New("unexpected payload")
+			}
+			return nil
+		},
+		"fail":  func(ctx context.Context, payload []byte) error { return errHandler },
+		"panic": func(ctx context.Context, payload []byte) error { panic("boom") },
+		"slow": func(ctx context.Context, payload []byte) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}, OutboxOptions{HandlerTimeout: 10 * time.Millisecond})
+
+	tests := []struct {
+		name    string
+		topic   string
+		wantErr string
+	}{
+		{"成功", "ok", ""},
+		{"處理器回傳錯誤", "fail", "smtp unavailable"},
+		{"處理器 panic", "panic", "outbox handler panic: boom"},
+		{"未知的主題", "unknown", `no handler for outbox topic "unknown"`},
+		{"超過處理期限", "slow", context.DeadlineExceeded.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.handle(context.Background(), &models.OutboxMessage{Topic: tt.topic, Payload: `{"a":1}`})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestDeliverQueuedMailInvalidPayload(t *testing.T) {
+	err := deliverQueuedMail(context.Background(), []byte("not json"))
+	assert.ErrorIs(t, err, ErrOutboxPermanent, "無法解析的訊息不應重試")
+}
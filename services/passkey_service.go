@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"member_API/models"
+	"member_API/webauthn"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxPasskeysPerMember 每位會員可註冊的 passkey 數量上限
+const MaxPasskeysPerMember = 10
+
+var (
+	// ErrPasskeyNotFound passkey 不存在或不屬於該會員
+	ErrPasskeyNotFound = errors.New("passkey 不存在")
+	// ErrPasskeyExists 此認證器已註冊過
+	ErrPasskeyExists = errors.New("此認證器已註冊")
+	// ErrPasskeyLimitReached passkey 已達上限
+	ErrPasskeyLimitReached = errors.New("passkey 數量已達上限")
+	// ErrPasskeyInvalid 認證器的回應驗證失敗，或 passkey 未註冊
+	ErrPasskeyInvalid = errors.New("passkey 驗證失敗")
+)
+
+// PasskeyService 管理會員的 passkey（WebAuthn credential），並以 passkey 登入
+type PasskeyService struct {
+	DB *gorm.DB
+}
+
+func NewPasskeyService(db *gorm.DB) *PasskeyService {
+	return &PasskeyService{DB: db}
+}
+
+// UserHandle 會員在 WebAuthn 中的 user handle，為 8 位元組的會員 ID，不含個人資料
+func UserHandle(memberID uint) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(memberID))
+}
+
+// descriptors 將會員的 passkey 轉為 credential descriptor
+func descriptors(credentials []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	out := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		out = append(out, webauthn.NewCredentialDescriptor(credential.CredentialID, credential.Transports))
+	}
+	return out
+}
+
+// List 列出會員的 passkey
+func (s *PasskeyService) List(memberID uint) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	if err := s.DB.Where("member_id = ?", memberID).Order("id").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// BeginRegistration 為會員產生註冊選項；已註冊的認證器會被排除
+func (s *PasskeyService) BeginRegistration(rp *webauthn.RelyingParty, memberID uint) (*webauthn.CreationOptions, *webauthn.SessionData, error) {
+	member, err := NewMemberService(s.DB).GetMemberByID(memberID)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := s.List(memberID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(existing) >= MaxPasskeysPerMember {
+		return nil, nil, ErrPasskeyLimitReached
+	}
+
+	options, session, err := rp.BeginRegistration(webauthn.User{
+		ID:          UserHandle(member.ID),
+		Name:        member.Email,
+		DisplayName: member.Name,
+	}, descriptors(existing))
+	if err != nil {
+		return nil, nil, err
+	}
+	session.MemberID = member.ID
+	return options, session, nil
+}
+
+// FinishRegistration 驗證認證器的回應並保存 passkey
+func (s *PasskeyService) FinishRegistration(rp *webauthn.RelyingParty, session *webauthn.SessionData, memberID uint, name string, resp *webauthn.AttestationResponse) (*models.WebAuthnCredential, error) {
+	if session.MemberID != memberID {
+		return nil, ErrPasskeyInvalid
+	}
+	verified, err := rp.FinishRegistration(session, resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPasskeyInvalid, err)
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	credential := &models.WebAuthnCredential{
+		Base: models.Base{
+			CreationTime: time.Now(),
+			CreatorId:    memberID,
+		},
+		MemberID:       memberID,
+		Name:           name,
+		CredentialID:   verified.ID,
+		PublicKey:      verified.PublicKey,
+		Algorithm:      verified.Algorithm,
+		SignCount:      verified.SignCount,
+		AAGUID:         verified.AAGUID,
+		Transports:     verified.Transports,
+		BackupEligible: verified.BackupEligible,
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockMember(tx, memberID); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.WebAuthnCredential{}).Where("member_id = ?", memberID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= MaxPasskeysPerMember {
+			return ErrPasskeyLimitReached
+		}
+		if err := tx.Model(&models.WebAuthnCredential{}).Where("credential_id = ?", verified.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrPasskeyExists
+		}
+		return tx.Create(credential).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// Delete 刪除會員的 passkey
+func (s *PasskeyService) Delete(memberID, id uint) error {
+	result := s.DB.Where("id = ? AND member_id = ?", id, memberID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// BeginLogin 產生登入選項。提供 email 時只允許該會員的 passkey；
+// email 不存在或沒有 passkey 時同樣回傳選項，避免洩漏帳號是否存在
+func (s *PasskeyService) BeginLogin(ctx context.Context, rp *webauthn.RelyingParty, email string) (*webauthn.RequestOptions, *webauthn.SessionData, error) {
+	var (
+		memberID uint
+		allow    []webauthn.CredentialDescriptor
+	)
+	if email != "" {
+		var member models.Member
+		err := s.DB.WithContext(ctx).Where("email = ? AND is_deleted = ?", email, false).First(&member).Error
+		switch {
+		case err == nil:
+			credentials, err := s.List(member.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(credentials) > 0 {
+				memberID = member.ID
+				allow = descriptors(credentials)
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, nil, err
+		}
+	}
+
+	options, session, err := rp.BeginLogin(allow)
+	if err != nil {
+		return nil, nil, err
+	}
+	session.MemberID = memberID
+	return options, session, nil
+}
+
+// FinishLogin 驗證認證器的回應，成功時更新簽章計數器並回傳會員。帳號鎖定期間回傳 *AccountLockedError
+func (s *PasskeyService) FinishLogin(ctx context.Context, rp *webauthn.RelyingParty, session *webauthn.SessionData, resp *webauthn.AssertionResponse) (*models.Member, error) {
+	var credential models.WebAuthnCredential
+	if err := s.DB.WithContext(ctx).Where("credential_id = ?", []byte(resp.RawID)).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasskeyInvalid
+		}
+		return nil, err
+	}
+	if session.MemberID != 0 && credential.MemberID != session.MemberID {
+		return nil, ErrPasskeyInvalid
+	}
+	if len(resp.Response.UserHandle) > 0 && !bytes.Equal(resp.Response.UserHandle, UserHandle(credential.MemberID)) {
+		return nil, ErrPasskeyInvalid
+	}
+
+	result, err := rp.FinishLogin(session, webauthn.StoredCredential{
+		PublicKey: credential.PublicKey,
+		SignCount: credential.SignCount,
+	}, resp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPasskeyInvalid, err)
+	}
+
+	now := time.Now()
+	var member *models.Member
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if member, err = lockMember(tx, credential.MemberID); err != nil {
+			return err
+		}
+		if member.LockedUntil != nil && now.Before(*member.LockedUntil) {
+			return &AccountLockedError{Until: *member.LockedUntil}
+		}
+
+		// 以原本的計數器作為條件，同時送出的相同回應只有一個能成功
+		update := tx.Model(&models.WebAuthnCredential{}).
+			Where("id = ? AND sign_count = ?", credential.ID, credential.SignCount).
+			Updates(map[string]interface{}{
+				"sign_count":             result.SignCount,
+				"last_used_at":           &now,
+				"last_modification_time": &now,
+			})
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return ErrPasskeyInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil, ErrPasskeyInvalid
+		}
+		return nil, err
+	}
+	return member, nil
+}
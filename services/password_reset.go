@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetTTL 重設密碼連結的有效期限
+const PasswordResetTTL = time.Hour
+
+// RequestPasswordReset 寄送重設密碼信。email 不存在時同樣回傳成功，避免洩漏帳號是否存在
+func (s *MemberService) RequestPasswordReset(ctx context.Context, email string) error {
+	var member models.Member
+	if err := s.DB.WithContext(ctx).Where("email = ? AND is_deleted = ?", email, false).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := NewActionTokenService(tx).Issue(member.ID, PurposePasswordReset, PasswordResetTTL, "")
+		if err != nil {
+			return err
+		}
+
+		msg, err := renderMail(tx, EventPasswordReset, &member, member.Email, map[string]interface{}{
+			"Link":             appLink("/reset-password?token=" + url.QueryEscape(token)),
+			"ExpiresInMinutes": int(PasswordResetTTL.Minutes()),
+		})
+		if err != nil {
+			return err
+		}
+		return queueMail(tx, member.ID, msg)
+	})
+}
+
+// ResetPassword 以重設密碼 token 設定新密碼，並撤銷該會員所有已簽發的 token。
+// 新密碼不符合密碼規則時回傳 *auth.PasswordPolicyError，token 不會被消耗
+func (s *MemberService) ResetPassword(ctx context.Context, token, password string) error {
+	now := time.Now()
+	var memberID uint
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		record, err := NewActionTokenService(tx).Consume(token, PurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		memberID = record.MemberID
+
+		var member models.Member
+		if err := tx.Where("is_deleted = ?", false).First(&member, memberID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrActionTokenInvalid
+			}
+			return err
+		}
+
+		if err := auth.ValidatePassword(password, member.Name, member.Email); err != nil {
+			return err
+		}
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&member).Updates(map[string]interface{}{
+			"password_hash":          hash,
+			"last_modifier_id":       memberID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 帳號可能已遭他人使用，尚未完成的 email 變更一併失效
+		if err := NewActionTokenService(tx).Invalidate(memberID, PurposeEmailChange); err != nil {
+			return err
+		}
+
+		return NewRefreshTokenService(tx).RevokeAllForMember(memberID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if store := auth.CurrentRevocationStore(); store != nil {
+		return store.RevokeAllForMember(ctx, int64(memberID), now)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+)
+
+// ErrForbidden 呼叫者既不是資源擁有者，也沒有管理他人資源的權限
+var ErrForbidden = errors.New("權限不足")
+
+// 資源操作的授權規則，REST controller 與 GraphQL resolver 皆透過 service 方法套用：
+//   - 產品：建立者（Base.CreatorId）可修改與刪除，其他人需要 product:update / product:delete
+//   - 會員：本人可修改自己的資料，其他人需要 member:update；刪除他人需要 member:delete
+//
+// actor 為 nil（未認證）時一律拒絕。
+
+// AuthorizeProductUpdate 檢查 actor 可否修改產品
+func AuthorizeProductUpdate(actor *auth.Identity, product *models.Product) error {
+	return authorizeOwned(actor, product.CreatorId, auth.PermProductUpdate)
+}
+
+// AuthorizeProductDelete 檢查 actor 可否刪除產品
+func AuthorizeProductDelete(actor *auth.Identity, product *models.Product) error {
+	return authorizeOwned(actor, product.CreatorId, auth.PermProductDelete)
+}
+
+// AuthorizeMemberUpdate 檢查 actor 可否修改會員資料
+func AuthorizeMemberUpdate(actor *auth.Identity, member *models.Member) error {
+	return authorizeOwned(actor, member.ID, auth.PermMemberUpdate)
+}
+
+// AuthorizeMemberDelete 檢查 actor 可否刪除會員
+func AuthorizeMemberDelete(actor *auth.Identity, member *models.Member) error {
+	if actor == nil || !actor.HasPermission(auth.PermMemberDelete) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeOwned 擁有者或具備 perm 的呼叫者可操作資源；ownerID 為 0 表示沒有擁有者（例如系統建立）
+func authorizeOwned(actor *auth.Identity, ownerID uint, perm auth.Permission) error {
+	if actor == nil {
+		return ErrForbidden
+	}
+	if ownerID != 0 && actorID(actor) == ownerID {
+		return nil
+	}
+	if actor.HasPermission(perm) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// actorID 回傳執行操作的會員 ID，未認證時為 0
+func actorID(actor *auth.Identity) uint {
+	if actor == nil || actor.Claims.UserID <= 0 {
+		return 0
+	}
+	return uint(actor.Claims.UserID)
+}
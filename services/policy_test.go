@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"member_API/auth"
+	"member_API/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourcePolicy(t *testing.T) {
+	owner := &auth.Identity{Claims: &auth.Claims{UserID: 1, Roles: []string{auth.RoleMember}}}
+	other := &auth.Identity{Claims: &auth.Claims{UserID: 2, Roles: []string{auth.RoleMember}}}
+	staff := &auth.Identity{Claims: &auth.Claims{UserID: 3, Roles: []string{auth.RoleStaff}}}
+	admin := &auth.Identity{Claims: &auth.Claims{UserID: 4, Roles: []string{auth.RoleAdmin}}}
+	ownerKey := &auth.Identity{Claims: &auth.Claims{UserID: 1, Roles: []string{auth.RoleMember}}, APIKeyID: 1, Scopes: []string{auth.ScopeWrite}}
+	staffKey := &auth.Identity{Claims: &auth.Claims{UserID: 3, Roles: []string{auth.RoleStaff}}, APIKeyID: 2, Scopes: []string{auth.ScopeWrite}}
+
+	product := &models.Product{Base: models.Base{ID: 10, CreatorId: 1}}
+	orphan := &models.Product{Base: models.Base{ID: 11}}
+	member := &models.Member{Base: models.Base{ID: 1}}
+
+	tests := []struct {
+		name    string
+		check   func() error
+		wantErr bool
+	}{
+		{name: "建立者可修改產品", check: func() error { return AuthorizeProductUpdate(owner, product) }},
+		{name: "建立者可刪除產品", check: func() error { return AuthorizeProductDelete(owner, product) }},
+		{name: "其他會員不可修改產品", check: func() error { return AuthorizeProductUpdate(other, product) }, wantErr: true},
+		{name: "其他會員不可刪除產品", check: func() error { return AuthorizeProductDelete(other, product) }, wantErr: true},
+		{name: "staff 可修改他人產品", check: func() error { return AuthorizeProductUpdate(staff, product) }},
+		{name: "staff 可刪除他人產品", check: func() error { return AuthorizeProductDelete(staff, product) }},
+		{name: "沒有建立者的產品不屬於任何會員", check: func() error { return AuthorizeProductUpdate(&auth.Identity{Claims: &auth.Claims{}}, orphan) }, wantErr: true},
+		{name: "未認證", check: func() error { return AuthorizeProductUpdate(nil, product) }, wantErr: true},
+		{name: "建立者的 API key 不需要權限 scope", check: func() error { return AuthorizeProductUpdate(ownerKey, product) }},
+		{name: "API key 修改他人產品需要權限 scope", check: func() error { return AuthorizeProductUpdate(staffKey, product) }, wantErr: true},
+		{name: "本人可修改會員資料", check: func() error { return AuthorizeMemberUpdate(owner, member) }},
+		{name: "其他會員不可修改會員資料", check: func() error { return AuthorizeMemberUpdate(other, member) }, wantErr: true},
+		{name: "staff 不可修改會員資料", check: func() error { return AuthorizeMemberUpdate(staff, member) }, wantErr: true},
+		{name: "管理員可修改會員資料", check: func() error { return AuthorizeMemberUpdate(admin, member) }},
+		{name: "本人不可直接刪除會員", check: func() error { return AuthorizeMemberDelete(owner, member) }, wantErr: true},
+		{name: "管理員可刪除會員", check: func() error { return AuthorizeMemberDelete(admin, member) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrForbidden)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
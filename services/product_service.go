@@ -2,12 +2,16 @@ package services
 
 import (
 	"errors"
+	"member_API/auth"
 	"member_API/models"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrProductNotFound 產品不存在或已刪除
+var ErrProductNotFound = errors.New("產品不存在")
+
 type ProductService struct {
 	DB *gorm.DB
 }
@@ -39,19 +43,23 @@ func (s *ProductService) CreateProduct(name string, price float64, description,
 	return product, nil
 }
 
-// UpdateProduct 更新產品資訊
-func (s *ProductService) UpdateProduct(id uint, updates map[string]interface{}, modifierId uint) (*models.Product, error) {
+// UpdateProduct 更新產品資訊，僅建立者或具備 product:update 權限者可修改
+func (s *ProductService) UpdateProduct(actor *auth.Identity, id uint, updates map[string]interface{}) (*models.Product, error) {
 	var product models.Product
 	if err := s.DB.Where("is_deleted = ?", false).First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("產品不存在")
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
 
+	if err := AuthorizeProductUpdate(actor, &product); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	updates["last_modification_time"] = &now
-	updates["last_modifier_id"] = modifierId
+	updates["last_modifier_id"] = actorID(actor)
 
 	if err := s.DB.Model(&product).Updates(updates).Error; err != nil {
 		return nil, err
@@ -65,15 +73,27 @@ func (s *ProductService) UpdateProduct(id uint, updates map[string]interface{},
 	return &product, nil
 }
 
-// DeleteProduct 軟刪除產品
-func (s *ProductService) DeleteProduct(id uint, deleterId uint) error {
+// DeleteProduct 軟刪除產品，僅建立者或具備 product:delete 權限者可刪除
+func (s *ProductService) DeleteProduct(actor *auth.Identity, id uint) error {
+	var product models.Product
+	if err := s.DB.Where("is_deleted = ?", false).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+
+	if err := AuthorizeProductDelete(actor, &product); err != nil {
+		return err
+	}
+
 	now := time.Now()
 	result := s.DB.Model(&models.Product{}).
 		Where("id = ? AND is_deleted = ?", id, false).
 		Updates(map[string]interface{}{
 			"is_deleted":             true,
 			"deleted_at":             &now,
-			"last_modifier_id":       deleterId,
+			"last_modifier_id":       actorID(actor),
 			"last_modification_time": &now,
 		})
 
@@ -82,7 +102,7 @@ func (s *ProductService) DeleteProduct(id uint, deleterId uint) error {
 	}
 
 	if result.RowsAffected == 0 {
-		return errors.New("產品不存在或已被刪除")
+		return ErrProductNotFound
 	}
 
 	return nil
@@ -93,7 +113,7 @@ func (s *ProductService) GetProductByID(id uint) (*models.Product, error) {
 	var product models.Product
 	if err := s.DB.Where("is_deleted = ?", false).First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("產品不存在")
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
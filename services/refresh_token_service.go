@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenInvalid refresh token 不存在、已撤銷或已過期
+	ErrRefreshTokenInvalid = errors.New("refresh token 無效或已過期")
+	// ErrRefreshTokenReused 已輪替過的 refresh token 被再次使用
+	ErrRefreshTokenReused = errors.New("refresh token 已被重複使用")
+)
+
+type RefreshTokenService struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenService(db *gorm.DB) *RefreshTokenService {
+	return &RefreshTokenService{DB: db}
+}
+
+// issue 簽發綁定到工作階段的 refresh token，familyID 為空時建立新的 token 家族
+func (s *RefreshTokenService) issue(tx *gorm.DB, memberID, sessionID uint, familyID string) (string, error) {
+	if familyID == "" {
+		id, err := auth.GenerateRandomToken(16)
+		if err != nil {
+			return "", err
+		}
+		familyID = id
+	}
+
+	plain, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	token := &models.RefreshToken{
+		Base: models.Base{
+			CreationTime: now,
+			CreatorId:    memberID,
+		},
+		MemberID:  memberID,
+		TokenHash: auth.HashToken(plain),
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		ExpiresAt: now.Add(auth.RefreshTokenTTL),
+	}
+
+	if err := tx.Create(token).Error; err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// Rotate 以舊的 refresh token 換發新的 refresh token，並回傳新 token 的紀錄（含會員與工作階段 ID）。
+// 換發時更新工作階段的最後使用時間與有效期限。
+// 若舊 token 先前已被輪替過（重放攻擊），整個 token 家族與其工作階段都會被撤銷。
+func (s *RefreshTokenService) Rotate(ctx context.Context, plain, userAgent, clientIP string) (string, *models.RefreshToken, error) {
+	var (
+		next   string
+		issued models.RefreshToken
+		reused *models.RefreshToken
+	)
+	now := time.Now()
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND is_deleted = ?", auth.HashToken(plain), false).
+			First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.UsedAt != nil {
+			// 已使用過的 token 再次出現，撤銷整個家族與工作階段；需提交交易因此不回傳錯誤
+			reused = &current
+			if current.SessionID != 0 {
+				if err := revokeSessions(tx, []uint{current.SessionID}, current.MemberID, now); err != nil {
+					return err
+				}
+			}
+			return revokeFamily(tx, current.FamilyID, now)
+		}
+
+		if current.RevokedAt != nil || now.After(current.ExpiresAt) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"used_at":                &now,
+			"last_modifier_id":       current.MemberID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		sessionID, err := refreshSession(tx, &current, userAgent, clientIP, now)
+		if err != nil {
+			return err
+		}
+
+		token, err := s.issue(tx, current.MemberID, sessionID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		next = token
+		issued = models.RefreshToken{MemberID: current.MemberID, FamilyID: current.FamilyID, SessionID: sessionID}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if reused != nil {
+		if reused.SessionID != 0 {
+			if err := revokeSessionAccessTokens(ctx, reused.MemberID, []uint{reused.SessionID}, now); err != nil {
+				return "", nil, err
+			}
+		}
+		return "", nil, ErrRefreshTokenReused
+	}
+
+	return next, &issued, nil
+}
+
+// refreshSession 更新 refresh token 所屬工作階段的使用紀錄與有效期限，回傳工作階段 ID。
+// 工作階段功能上線前簽發的 refresh token 沒有工作階段，換發時補建
+func refreshSession(tx *gorm.DB, current *models.RefreshToken, userAgent, clientIP string, now time.Time) (uint, error) {
+	if current.SessionID == 0 {
+		session, err := createSession(tx, current.MemberID, userAgent, clientIP, false, now)
+		if err != nil {
+			return 0, err
+		}
+		return session.ID, nil
+	}
+
+	result := tx.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", current.SessionID).
+		Updates(map[string]interface{}{
+			"user_agent":   truncateUserAgent(userAgent),
+			"ip":           clientIP,
+			"last_seen_at": now,
+			"expires_at":   now.Add(auth.RefreshTokenTTL),
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrRefreshTokenInvalid
+	}
+	return current.SessionID, nil
+}
+
+// revokeFamily 撤銷同一家族中所有尚未撤銷的 refresh token
+func revokeFamily(tx *gorm.DB, familyID string, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":             &now,
+			"last_modification_time": &now,
+		}).Error
+}
+
+// Revoke 撤銷 refresh token 所屬的整個家族與工作階段（例如登出時）
+func (s *RefreshTokenService) Revoke(plain string, memberID uint) error {
+	var token models.RefreshToken
+	if err := s.DB.Where("token_hash = ? AND member_id = ?", auth.HashToken(plain), memberID).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+
+	now := time.Now()
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if token.SessionID != 0 {
+			if err := revokeSessions(tx, []uint{token.SessionID}, memberID, now); err != nil {
+				return err
+			}
+		}
+		return revokeFamily(tx, token.FamilyID, now)
+	})
+}
+
+// RevokeAllForMember 撤銷會員所有尚未撤銷的 refresh token 與工作階段
+func (s *RefreshTokenService) RevokeAllForMember(memberID uint) error {
+	now := time.Now()
+	if err := s.DB.Model(&models.Session{}).
+		Where("member_id = ? AND revoked_at IS NULL", memberID).
+		Updates(map[string]interface{}{
+			"revoked_at":             &now,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+		return err
+	}
+	return s.DB.Model(&models.RefreshToken{}).
+		Where("member_id = ? AND revoked_at IS NULL", memberID).
+		Updates(map[string]interface{}{
+			"revoked_at":             &now,
+			"last_modification_time": &now,
+		}).Error
+}
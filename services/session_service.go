@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"member_API/auth"
+	"member_API/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionTouchInterval 同一工作階段更新最後使用時間的最短間隔，避免每個請求都寫入資料庫
+	sessionTouchInterval = 5 * time.Minute
+	// maxUserAgentLength 與 models.Session.UserAgent 欄位長度一致
+	maxUserAgentLength = 512
+)
+
+// ErrSessionNotFound 工作階段不存在、不屬於該會員或已失效
+var ErrSessionNotFound = errors.New("工作階段不存在")
+
+// SessionService 管理會員的登入工作階段（每次登入的裝置）。
+// 實作 auth.SessionTracker，由 AuthMiddleware 更新最後使用時間。
+type SessionService struct {
+	DB *gorm.DB
+
+	mu        sync.Mutex
+	touches   map[uint]time.Time
+	lastPurge time.Time
+}
+
+func NewSessionService(db *gorm.DB) *SessionService {
+	return &SessionService{DB: db}
+}
+
+// truncateUserAgent 截斷過長的 User-Agent
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > maxUserAgentLength {
+		return userAgent[:maxUserAgentLength]
+	}
+	return userAgent
+}
+
+// Start 為登入建立新的工作階段，並簽發綁定該工作階段的 refresh token
+func (s *SessionService) Start(memberID uint, userAgent, clientIP string) (*models.Session, string, error) {
+	return s.start(memberID, userAgent, clientIP, false)
+}
+
+// StartPasskey 為以 passkey 登入建立工作階段；passkey 經過使用者驗證，此工作階段簽發的 access token 視為通過多因素驗證
+func (s *SessionService) StartPasskey(memberID uint, userAgent, clientIP string) (*models.Session, string, error) {
+	return s.start(memberID, userAgent, clientIP, true)
+}
+
+func (s *SessionService) start(memberID uint, userAgent, clientIP string, passkey bool) (*models.Session, string, error) {
+	var (
+		session *models.Session
+		token   string
+	)
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if session, err = createSession(tx, memberID, userAgent, clientIP, passkey, time.Now()); err != nil {
+			return err
+		}
+		token, err = NewRefreshTokenService(tx).issue(tx, memberID, session.ID, "")
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return session, token, nil
+}
+
+// Get 取得工作階段（包含已撤銷的）
+func (s *SessionService) Get(sessionID uint) (*models.Session, error) {
+	var session models.Session
+	if err := s.DB.First(&session, sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// createSession 建立工作階段，有效期限與 refresh token 相同
+func createSession(tx *gorm.DB, memberID uint, userAgent, clientIP string, passkey bool, now time.Time) (*models.Session, error) {
+	session := &models.Session{
+		Base: models.Base{
+			CreationTime: now,
+			CreatorId:    memberID,
+		},
+		MemberID:   memberID,
+		UserAgent:  truncateUserAgent(userAgent),
+		IP:         clientIP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(auth.RefreshTokenTTL),
+		Passkey:    passkey,
+	}
+	if err := tx.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// List 列出會員目前有效的工作階段，依最後使用時間由新到舊排序
+func (s *SessionService) List(memberID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.DB.Where("member_id = ? AND revoked_at IS NULL AND expires_at > ? AND is_deleted = ?", memberID, time.Now(), false).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Revoke 撤銷會員的單一工作階段，該裝置的 refresh token 與 access token 立即失效
+func (s *SessionService) Revoke(ctx context.Context, memberID, sessionID uint) error {
+	var session models.Session
+	if err := s.DB.WithContext(ctx).
+		Where("member_id = ? AND revoked_at IS NULL AND is_deleted = ?", memberID, false).
+		First(&session, sessionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return revokeSessions(tx, []uint{session.ID}, memberID, now)
+	}); err != nil {
+		return err
+	}
+	return revokeSessionAccessTokens(ctx, memberID, []uint{session.ID}, now)
+}
+
+// RevokeAll 撤銷會員所有有效的工作階段，exceptSessionID 不為 0 時保留該工作階段，回傳撤銷的數量
+func (s *SessionService) RevokeAll(ctx context.Context, memberID, exceptSessionID uint) (int, error) {
+	var ids []uint
+	if err := s.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("member_id = ? AND revoked_at IS NULL AND expires_at > ? AND id <> ? AND is_deleted = ?", memberID, time.Now(), exceptSessionID, false).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return revokeSessions(tx, ids, memberID, now)
+	}); err != nil {
+		return 0, err
+	}
+	return len(ids), revokeSessionAccessTokens(ctx, memberID, ids, now)
+}
+
+// revokeSessions 標記工作階段已撤銷，並撤銷其所有 refresh token
+func revokeSessions(tx *gorm.DB, ids []uint, modifierID uint, now time.Time) error {
+	if err := tx.Model(&models.Session{}).
+		Where("id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":             &now,
+			"last_modifier_id":       modifierID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.RefreshToken{}).
+		Where("session_id IN ? AND revoked_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"revoked_at":             &now,
+			"last_modification_time": &now,
+		}).Error
+}
+
+// revokeSessionAccessTokens 撤銷綁定到工作階段的 access token；token 最晚在簽發後 AccessTokenTTL 過期
+func revokeSessionAccessTokens(ctx context.Context, memberID uint, ids []uint, now time.Time) error {
+	store := auth.CurrentRevocationStore()
+	if store == nil {
+		return nil
+	}
+	for _, id := range ids {
+		if err := store.RevokeSession(ctx, id, int64(memberID), now.Add(auth.AccessTokenTTL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TouchSession 更新工作階段最後使用的時間、IP 與 User-Agent，實作 auth.SessionTracker。
+// 同一工作階段在 sessionTouchInterval 內只會寫入一次，失敗時只記錄警告
+func (s *SessionService) TouchSession(ctx context.Context, claims *auth.Claims, clientIP, userAgent string) {
+	now := time.Now()
+	if !s.shouldTouch(claims.SessionID, now) {
+		return
+	}
+
+	if err := s.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND member_id = ? AND revoked_at IS NULL", claims.SessionID, claims.UserID).
+		UpdateColumns(map[string]interface{}{
+			"last_seen_at": now,
+			"ip":           clientIP,
+			"user_agent":   truncateUserAgent(userAgent),
+		}).Error; err != nil {
+		log.Printf("Warning: failed to update session %d: %v\n", claims.SessionID, err)
+	}
+}
+
+// shouldTouch 判斷工作階段距離上次更新是否已超過 sessionTouchInterval，並順便清除過舊的紀錄
+func (s *SessionService) shouldTouch(sessionID uint, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.touches == nil {
+		s.touches = make(map[uint]time.Time)
+	}
+	if now.Sub(s.lastPurge) >= sessionTouchInterval {
+		for id, last := range s.touches {
+			if now.Sub(last) >= sessionTouchInterval {
+				delete(s.touches, id)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	if last, ok := s.touches[sessionID]; ok && now.Sub(last) < sessionTouchInterval {
+		return false
+	}
+	s.touches[sessionID] = now
+	return true
+}
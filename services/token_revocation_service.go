@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"log"
+	"member_API/auth"
+	"member_API/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// revocationSyncSkew 同步時往前多讀的時間，避免不同實例間的時鐘誤差漏掉紀錄
+const revocationSyncSkew = time.Minute
+
+// TokenRevocationService 以 PostgreSQL 保存 token 撤銷紀錄，並以記憶體快取供每次請求查詢。
+// 實作 auth.RevocationStore；多個實例之間透過定期 Sync 取得彼此寫入的紀錄。
+type TokenRevocationService struct {
+	DB       *gorm.DB
+	cache    *auth.MemoryRevocationStore
+	mu       sync.Mutex
+	lastSync time.Time
+}
+
+func NewTokenRevocationService(db *gorm.DB) *TokenRevocationService {
+	return &TokenRevocationService{
+		DB:    db,
+		cache: auth.NewMemoryRevocationStore(),
+	}
+}
+
+// RevokeToken 撤銷單一 access token
+func (s *TokenRevocationService) RevokeToken(ctx context.Context, tokenID string, memberID int64, expiresAt time.Time) error {
+	record := &models.TokenRevocation{
+		Base: models.Base{
+			CreationTime: time.Now(),
+			CreatorId:    uint(memberID),
+		},
+		JTI:       tokenID,
+		MemberID:  uint(memberID),
+		ExpiresAt: expiresAt,
+		Reason:    "logout",
+	}
+	if err := s.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	return s.cache.RevokeToken(ctx, tokenID, memberID, expiresAt)
+}
+
+// RevokeAllForMember 撤銷會員在 before 之前簽發的所有 access token
+func (s *TokenRevocationService) RevokeAllForMember(ctx context.Context, memberID int64, before time.Time) error {
+	record := &models.TokenRevocation{
+		Base: models.Base{
+			CreationTime: time.Now(),
+			CreatorId:    uint(memberID),
+		},
+		MemberID:      uint(memberID),
+		RevokedBefore: &before,
+		ExpiresAt:     before.Add(auth.AccessTokenTTL),
+		Reason:        "revoke_all",
+	}
+	if err := s.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	return s.cache.RevokeAllForMember(ctx, memberID, before)
+}
+
+// RevokeSession 撤銷綁定到登入工作階段的所有 access token
+func (s *TokenRevocationService) RevokeSession(ctx context.Context, sessionID uint, memberID int64, expiresAt time.Time) error {
+	record := &models.TokenRevocation{
+		Base: models.Base{
+			CreationTime: time.Now(),
+			CreatorId:    uint(memberID),
+		},
+		MemberID:  uint(memberID),
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+		Reason:    "revoke_session",
+	}
+	if err := s.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return err
+	}
+	return s.cache.RevokeSession(ctx, sessionID, memberID, expiresAt)
+}
+
+// IsRevoked 從記憶體快取判斷 token 是否已被撤銷
+func (s *TokenRevocationService) IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	return s.cache.IsRevoked(ctx, claims)
+}
+
+// Sync 將資料庫中上次同步後新增、且尚未過期的撤銷紀錄載入快取
+func (s *TokenRevocationService) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	query := s.DB.WithContext(ctx).Where("expires_at > ?", now)
+	if !s.lastSync.IsZero() {
+		query = query.Where("creation_time > ?", s.lastSync.Add(-revocationSyncSkew))
+	}
+
+	var records []models.TokenRevocation
+	if err := query.Find(&records).Error; err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.JTI != "" {
+			_ = s.cache.RevokeToken(ctx, record.JTI, int64(record.MemberID), record.ExpiresAt)
+		}
+		if record.SessionID != 0 {
+			_ = s.cache.RevokeSession(ctx, record.SessionID, int64(record.MemberID), record.ExpiresAt)
+		}
+		if record.RevokedBefore != nil {
+			_ = s.cache.RevokeAllForMember(ctx, int64(record.MemberID), *record.RevokedBefore)
+		}
+	}
+
+	s.lastSync = now
+	return nil
+}
+
+// Purge 清除已過期的撤銷紀錄
+func (s *TokenRevocationService) Purge(ctx context.Context) error {
+	now := time.Now()
+	s.cache.Purge(now)
+	return s.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.TokenRevocation{}).Error
+}
+
+// StartSync 啟動背景同步，直到 ctx 結束
+func (s *TokenRevocationService) StartSync(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Sync(ctx); err != nil {
+					log.Printf("Error syncing token revocations: %v\n", err)
+				}
+				if err := s.Purge(ctx); err != nil {
+					log.Printf("Error purging token revocations: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// revokeAccessTokensForReissue 撤銷會員先前簽發的所有 access token，供撤銷後立即重新簽發的流程使用。
+// iat 只精確到秒，若以 now 為截止點，同一秒內重新簽發的 token 也會被視為已撤銷，因此截止點提前一秒
+func revokeAccessTokensForReissue(ctx context.Context, memberID uint, now time.Time) error {
+	store := auth.CurrentRevocationStore()
+	if store == nil {
+		return nil
+	}
+	return store.RevokeAllForMember(ctx, int64(memberID), now.Add(-time.Second))
+}
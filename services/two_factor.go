@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"member_API/auth"
+	"member_API/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// TOTPIssuer 顯示在驗證器 App 中的服務名稱
+	TOTPIssuer = "Member API"
+	// PurposeMFAChallenge 兩步驟登入第二階段 token 的用途
+	PurposeMFAChallenge = "mfa_challenge"
+	// MFAChallengeTTL 輸入第二因素的期限
+	MFAChallengeTTL = 5 * time.Minute
+	// RecoveryCodeCount 每次產生的復原碼數量
+	RecoveryCodeCount = 10
+)
+
+var (
+	// ErrTOTPAlreadyEnabled 會員已啟用兩步驟驗證
+	ErrTOTPAlreadyEnabled = errors.New("已啟用兩步驟驗證")
+	// ErrTOTPNotEnrolled 會員尚未申請或啟用兩步驟驗證
+	ErrTOTPNotEnrolled = errors.New("尚未設定兩步驟驗證")
+	// ErrInvalidMFACode 驗證碼或復原碼錯誤
+	ErrInvalidMFACode = errors.New("驗證碼錯誤")
+	// ErrMFAChallengeInvalid 兩步驟登入的 token 無效或已過期
+	ErrMFAChallengeInvalid = errors.New("登入驗證已過期，請重新登入")
+)
+
+// EnrollTOTP 為會員產生新的 TOTP 金鑰，需以 ConfirmTOTP 驗證後才會啟用
+func (s *MemberService) EnrollTOTP(ctx context.Context, memberID uint) (secret, uri string, err error) {
+	member, err := s.GetMemberByID(memberID)
+	if err != nil {
+		return "", "", err
+	}
+	if member.TOTPEnabledAt != nil {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+
+	if secret, err = auth.GenerateTOTPSecret(); err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	if err := s.DB.WithContext(ctx).Model(member).Updates(map[string]interface{}{
+		"totp_secret":            secret,
+		"totp_last_step":         0,
+		"last_modifier_id":       memberID,
+		"last_modification_time": &now,
+	}).Error; err != nil {
+		return "", "", err
+	}
+
+	return secret, auth.TOTPURI(TOTPIssuer, member.Email, secret), nil
+}
+
+// ConfirmTOTP 以驗證碼確認金鑰並啟用兩步驟驗證，回傳只會顯示一次的復原碼。
+// 啟用後會撤銷該會員先前簽發的所有 token，確保之後的登入都經過第二因素
+func (s *MemberService) ConfirmTOTP(ctx context.Context, memberID uint, code string) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		member, err := lockMember(tx, memberID)
+		if err != nil {
+			return err
+		}
+		if member.TOTPEnabledAt != nil {
+			return ErrTOTPAlreadyEnabled
+		}
+		if member.TOTPSecret == "" {
+			return ErrTOTPNotEnrolled
+		}
+
+		step, ok := auth.ValidateTOTP(member.TOTPSecret, code, now)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		if err := tx.Model(member).Updates(map[string]interface{}{
+			"totp_enabled_at":        &now,
+			"totp_last_step":         step,
+			"last_modifier_id":       memberID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := replaceRecoveryCodes(tx, memberID, codes, now); err != nil {
+			return err
+		}
+
+		return NewRefreshTokenService(tx).RevokeAllForMember(memberID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 呼叫端會立即簽發新的 token，不能撤銷同一秒內簽發的 token
+	if err := revokeAccessTokensForReissue(ctx, memberID, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP 以密碼加上驗證碼或復原碼停用兩步驟驗證，並刪除剩餘的復原碼。
+// 以外部帳號建立、尚未設定密碼的會員只需要驗證碼或復原碼。
+// 錯誤的密碼或驗證碼與登入失敗一樣計入 policy 的失敗次數，鎖定期間回傳 *AccountLockedError
+func (s *MemberService) DisableTOTP(ctx context.Context, memberID uint, password, code string, policy auth.LockoutPolicy) error {
+	now := time.Now()
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		member, err := lockMember(tx, memberID)
+		if err != nil {
+			return err
+		}
+		if member.TOTPEnabledAt == nil {
+			return ErrTOTPNotEnrolled
+		}
+		if err := checkNotLocked(member, now); err != nil {
+			return err
+		}
+		if err := checkDisableTOTPPassword(member, password); err != nil {
+			return err
+		}
+
+		ok, err := verifySecondFactor(tx, member, code, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		if err := tx.Model(member).Updates(map[string]interface{}{
+			"totp_secret":            "",
+			"totp_enabled_at":        nil,
+			"totp_last_step":         0,
+			"failed_login_attempts":  0,
+			"locked_until":           nil,
+			"last_modifier_id":       memberID,
+			"last_modification_time": &now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("member_id = ?", memberID).Delete(&models.RecoveryCode{}).Error
+	})
+	if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrInvalidMFACode) {
+		return s.reauthFailure(ctx, memberID, policy, now, err)
+	}
+	return err
+}
+
+// IssueMFAChallenge 簽發兩步驟登入第二階段使用的短期 token
+func IssueMFAChallenge(memberID uint, email string) (string, error) {
+	return auth.GeneratePurposeToken(PurposeMFAChallenge, int64(memberID), email, MFAChallengeTTL)
+}
+
+// CompleteMFAChallenge 驗證第二階段 token 與驗證碼（或復原碼）。
+// 錯誤的驗證碼與密碼錯誤一樣計入 policy 的失敗次數
+func (s *MemberService) CompleteMFAChallenge(ctx context.Context, challenge, code string, policy auth.LockoutPolicy) (*models.Member, error) {
+	claims, err := auth.ValidatePurposeToken(challenge, PurposeMFAChallenge)
+	if err != nil {
+		return nil, ErrMFAChallengeInvalid
+	}
+	memberID := uint(claims.UserID)
+
+	now := time.Now()
+	var (
+		member *models.Member
+		ok     bool
+	)
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if member, err = lockMember(tx, memberID); err != nil {
+			return err
+		}
+		if member.Email != claims.Email || member.TOTPEnabledAt == nil {
+			return ErrMFAChallengeInvalid
+		}
+		if member.LockedUntil != nil && now.Before(*member.LockedUntil) {
+			return &AccountLockedError{Until: *member.LockedUntil}
+		}
+
+		ok, err = verifySecondFactor(tx, member, code, now)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrMemberNotFound) {
+			return nil, ErrMFAChallengeInvalid
+		}
+		return nil, err
+	}
+
+	if !ok {
+		until, err := s.recordLoginFailure(ctx, memberID, policy, now)
+		if err != nil {
+			return nil, err
+		}
+		if until != nil {
+			return nil, &AccountLockedError{Until: *until}
+		}
+		return nil, ErrInvalidMFACode
+	}
+
+	if member.FailedLoginAttempts > 0 || member.LockedUntil != nil {
+		if err := s.DB.WithContext(ctx).Model(member).Updates(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return member, nil
+}
+
+// lockMember 以 SELECT ... FOR UPDATE 讀取會員
+func lockMember(tx *gorm.DB, memberID uint) (*models.Member, error) {
+	var member models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("is_deleted = ?", false).
+		First(&member, memberID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// checkDisableTOTPPassword 驗證停用兩步驟驗證時的密碼，尚未設定密碼的會員不檢查
+func checkDisableTOTPPassword(member *models.Member, password string) error {
+	if member.PasswordHash == "" {
+		return nil
+	}
+	if !auth.CheckPassword(password, member.PasswordHash) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// verifySecondFactor 驗證 TOTP 驗證碼或復原碼；成功時記錄使用過的時間區間或標記復原碼已使用。
+// member 需已在 tx 中鎖定
+func verifySecondFactor(tx *gorm.DB, member *models.Member, code string, now time.Time) (bool, error) {
+	if step, ok := auth.ValidateTOTP(member.TOTPSecret, code, now); ok {
+		// 同一個時間區間的驗證碼只能使用一次
+		if step <= member.TOTPLastStep {
+			return false, nil
+		}
+		member.TOTPLastStep = step
+		return true, tx.Model(member).Update("totp_last_step", step).Error
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("member_id = ? AND code_hash = ? AND used_at IS NULL", member.ID, auth.HashToken(auth.NormalizeRecoveryCode(code))).
+		Updates(map[string]interface{}{
+			"used_at":                &now,
+			"last_modifier_id":       member.ID,
+			"last_modification_time": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// replaceRecoveryCodes 刪除舊的復原碼並儲存新復原碼的雜湊
+func replaceRecoveryCodes(tx *gorm.DB, memberID uint, codes []string, now time.Time) error {
+	if err := tx.Where("member_id = ?", memberID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	records := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, models.RecoveryCode{
+			Base: models.Base{
+				CreationTime: now,
+				CreatorId:    memberID,
+			},
+			MemberID: memberID,
+			CodeHash: auth.HashToken(code),
+		})
+	}
+	return tx.Create(&records).Error
+}
@@ -0,0 +1,241 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cborMap 測試用的 CBOR map，依 CTAP2 canonical 規則排序鍵值
+type cborMap map[interface{}]interface{}
+
+// encodeCBOR 測試用的最小 CBOR 編碼器
+func encodeCBOR(value interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		case n <= 0xffffffff:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		default:
+			return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+		}
+	}
+
+	switch v := value.(type) {
+	case int:
+		return encodeCBOR(int64(v))
+	case int64:
+		if v >= 0 {
+			return head(0, uint64(v))
+		}
+		return head(1, uint64(-1-v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case []interface{}:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborMap:
+		keys := make([][]byte, 0, len(v))
+		encoded := make(map[string][]byte, len(v))
+		for key, item := range v {
+			k := encodeCBOR(key)
+			keys = append(keys, k)
+			encoded[string(k)] = encodeCBOR(item)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) < len(keys[j])
+			}
+			return string(keys[i]) < string(keys[j])
+		})
+		out := head(5, uint64(len(v)))
+		for _, k := range keys {
+			out = append(out, k...)
+			out = append(out, encoded[string(k)]...)
+		}
+		return out
+	default:
+		panic("unsupported CBOR test value")
+	}
+}
+
+// softAuthenticator 以軟體實作的認證器，讓測試不需要硬體即可跑完整個 ceremony
+type softAuthenticator struct {
+	t            *testing.T
+	signer       crypto.Signer
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+	rpID         string
+	// flags 預設為使用者在場與使用者驗證
+	flags byte
+	// format 為 attestation 格式，預設 "none"
+	format string
+	// noCounter 模擬不支援簽章計數器的認證器，計數器永遠為 0
+	noCounter bool
+}
+
+func newSoftAuthenticator(t *testing.T, alg int, origin, rpID string) *softAuthenticator {
+	t.Helper()
+	var signer crypto.Signer
+	switch alg {
+	case AlgES256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		signer = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signer = key
+	default:
+		t.Fatalf("unsupported algorithm %d", alg)
+	}
+
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	require.NoError(t, err)
+
+	return &softAuthenticator{
+		t:            t,
+		signer:       signer,
+		credentialID: id,
+		origin:       origin,
+		rpID:         rpID,
+		flags:        flagUserPresent | flagUserVerified,
+		format:       "none",
+	}
+}
+
+// coseKey 以 COSE_Key 格式編碼公鑰
+func (a *softAuthenticator) coseKey() []byte {
+	switch pub := a.signer.Public().(type) {
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+		return encodeCBOR(cborMap{coseKeyType: coseKeyTypeEC2, coseAlgorithm: AlgES256, coseCurve: coseCurveP256, coseX: x, coseY: y})
+	case ed25519.PublicKey:
+		return encodeCBOR(cborMap{coseKeyType: coseKeyTypeOKP, coseAlgorithm: AlgEdDSA, coseCurve: coseCurveEd25519, coseX: []byte(pub)})
+	}
+	a.t.Fatal("unsupported key")
+	return nil
+}
+
+func (a *softAuthenticator) algorithm() int {
+	if _, ok := a.signer.(ed25519.PrivateKey); ok {
+		return AlgEdDSA
+	}
+	return AlgES256
+}
+
+func (a *softAuthenticator) sign(data []byte) []byte {
+	var (
+		sig []byte
+		err error
+	)
+	if key, ok := a.signer.(ed25519.PrivateKey); ok {
+		sig = ed25519.Sign(key, data)
+	} else {
+		digest := sha256.Sum256(data)
+		sig, err = a.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	require.NoError(a.t, err)
+	return sig
+}
+
+func (a *softAuthenticator) clientData(ceremonyType, challenge string) []byte {
+	data, err := json.Marshal(clientData{Type: ceremonyType, Challenge: challenge, Origin: a.origin})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpHash := sha256.Sum256([]byte(a.rpID))
+	out := append([]byte(nil), rpHash[:]...)
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.signCount)
+	return append(out, attested...)
+}
+
+// tick 每次使用時遞增簽章計數器
+func (a *softAuthenticator) tick() {
+	if !a.noCounter {
+		a.signCount++
+	}
+}
+
+// create 模擬 navigator.credentials.create()
+func (a *softAuthenticator) create(options *CreationOptions) *AttestationResponse {
+	a.userHandle = options.User.ID
+	a.tick()
+
+	attested := make([]byte, 16) // AAGUID 全為 0
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, a.coseKey()...)
+	authData := a.authData(a.flags|flagAttestedData, attested)
+	clientDataJSON := a.clientData("webauthn.create", options.Challenge)
+
+	statement := cborMap{}
+	if a.format == "packed" {
+		hash := sha256.Sum256(clientDataJSON)
+		statement = cborMap{"alg": a.algorithm(), "sig": a.sign(append(append([]byte(nil), authData...), hash[:]...))}
+	}
+
+	return &AttestationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: AuthenticatorAttestationResponse{
+			ClientDataJSON:    clientDataJSON,
+			AttestationObject: encodeCBOR(cborMap{"fmt": a.format, "attStmt": statement, "authData": authData}),
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+// get 模擬 navigator.credentials.get()
+func (a *softAuthenticator) get(options *RequestOptions) *AssertionResponse {
+	a.tick()
+	authData := a.authData(a.flags, nil)
+	clientDataJSON := a.clientData("webauthn.get", options.Challenge)
+	hash := sha256.Sum256(clientDataJSON)
+
+	return &AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(a.credentialID),
+		RawID: a.credentialID,
+		Type:  "public-key",
+		Response: AuthenticatorAssertionResponse{
+			ClientDataJSON:    clientDataJSON,
+			AuthenticatorData: authData,
+			Signature:         a.sign(append(append([]byte(nil), authData...), hash[:]...)),
+			UserHandle:        a.userHandle,
+		},
+	}
+}
@@ -0,0 +1,175 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth 巢狀陣列與 map 的最大深度，避免惡意輸入耗盡堆疊
+const maxCBORDepth = 16
+
+// errCBOR CBOR 格式錯誤
+var errCBOR = errors.New("webauthn: malformed CBOR")
+
+// decodeCBOR 解碼 data 開頭的一個 CBOR 項目，回傳解碼後的值與使用的位元組數。
+// 只支援 WebAuthn 使用的 CTAP2 子集（不支援不定長度）；整數解碼為 int64，
+// 位元組字串為 []byte，文字為 string，陣列為 []interface{}，map 為 map[interface{}]interface{}
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// head 讀取項目開頭的 major type 與參數
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errCBOR
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported additional information %d", errCBOR, info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, errCBOR
+	}
+	buf := d.data[d.pos : d.pos+size]
+	d.pos += size
+	switch size {
+	case 1:
+		return major, uint64(buf[0]), nil
+	case 2:
+		return major, uint64(binary.BigEndian.Uint16(buf)), nil
+	case 4:
+		return major, uint64(binary.BigEndian.Uint32(buf)), nil
+	default:
+		return major, binary.BigEndian.Uint64(buf), nil
+	}
+}
+
+// bytes 讀取 n 個位元組
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBOR
+	}
+	buf := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return buf, nil
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > maxCBORDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", errCBOR)
+	}
+	start := d.pos
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("%w: integer overflow", errCBOR)
+		}
+		return -1 - int64(arg), nil
+	case 2:
+		buf, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), buf...), nil
+	case 3:
+		buf, err := d.bytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(buf), nil
+	case 4:
+		// 每個元素至少一個位元組，長度超過剩餘資料必定錯誤
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos)/2 {
+			return nil, errCBOR
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, fmt.Errorf("%w: unsupported map key", errCBOR)
+			}
+			if _, exists := items[key]; exists {
+				return nil, fmt.Errorf("%w: duplicate map key", errCBOR)
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items[key] = value
+		}
+		return items, nil
+	case 6:
+		// 忽略 tag，只保留內容
+		return d.decode(depth + 1)
+	default:
+		// 浮點數（additional information 25–27）不會出現在 WebAuthn 資料中
+		if d.data[start]&0x1f >= 24 {
+			return nil, fmt.Errorf("%w: unsupported simple value", errCBOR)
+		}
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("%w: unsupported simple value %d", errCBOR, arg)
+		}
+	}
+}
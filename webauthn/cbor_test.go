@@ -0,0 +1,53 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	data := encodeCBOR(cborMap{
+		"fmt":  "none",
+		1:      2,
+		-1:     -300,
+		"list": []interface{}{[]byte{1, 2}, true, int64(70000)},
+	})
+	value, n, err := decodeCBOR(append(data, 0xff))
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n, "只解碼第一個項目")
+	assert.Equal(t, map[interface{}]interface{}{
+		"fmt":     "none",
+		int64(1):  int64(2),
+		int64(-1): int64(-300),
+		"list":    []interface{}{[]byte{1, 2}, true, int64(70000)},
+	}, value)
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	deep := make([]byte, 0, maxCBORDepth+2)
+	for i := 0; i < maxCBORDepth+2; i++ {
+		deep = append(deep, 0x81) // 長度 1 的陣列
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "空資料", data: nil},
+		{name: "長度超過剩餘資料", data: []byte{0x45, 1, 2}},
+		{name: "不定長度", data: []byte{0x9f, 0x01, 0xff}},
+		{name: "宣告極大的陣列", data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "重複的 map 鍵", data: []byte{0xa2, 0x01, 0x01, 0x01, 0x02}},
+		{name: "浮點數", data: []byte{0xf9, 0x00, 0x14}},
+		{name: "巢狀過深", data: append(deep, 0x01)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := decodeCBOR(tt.data)
+			assert.ErrorIs(t, err, errCBOR)
+		})
+	}
+}
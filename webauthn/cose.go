@@ -0,0 +1,124 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE 演算法識別碼（RFC 9053）
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// COSE key 參數
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1 // EC2/OKP 的曲線；RSA 的 n
+	coseX         = -2 // EC2/OKP 的 x；RSA 的 e
+	coseY         = -3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// supportedAlgorithms 註冊時接受的演算法，依偏好排序
+var supportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+var (
+	// ErrUnsupportedKey 不支援的公鑰類型或演算法
+	ErrUnsupportedKey = errors.New("webauthn: unsupported public key")
+	// ErrInvalidSignature 簽章驗證失敗
+	ErrInvalidSignature = errors.New("webauthn: invalid signature")
+)
+
+// publicKey 解析後的 COSE_Key
+type publicKey struct {
+	Algorithm int
+	Key       crypto.PublicKey
+}
+
+// parsePublicKey 解析 COSE_Key（CBOR 編碼），支援 ES256（P-256）、EdDSA（Ed25519）與 RS256
+func parsePublicKey(data []byte) (*publicKey, error) {
+	value, n, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("%w: trailing data after public key", errCBOR)
+	}
+	return publicKeyFromCOSE(value)
+}
+
+func publicKeyFromCOSE(value interface{}) (*publicKey, error) {
+	key, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	kty, _ := key[int64(coseKeyType)].(int64)
+	alg, _ := key[int64(coseAlgorithm)].(int64)
+	crv, _ := key[int64(coseCurve)].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256 && crv == coseCurveP256:
+		x, _ := key[int64(coseX)].([]byte)
+		y, _ := key[int64(coseY)].([]byte)
+		if len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{Algorithm: AlgES256, Key: pub}, nil
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA && crv == coseCurveEd25519:
+		x, _ := key[int64(coseX)].([]byte)
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{Algorithm: AlgEdDSA, Key: ed25519.PublicKey(x)}, nil
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := key[int64(coseCurve)].([]byte)
+		e, _ := key[int64(coseX)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{Algorithm: AlgRS256, Key: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// verify 驗證 data 的簽章；ES256 簽章為 ASN.1 DER 格式
+func (k *publicKey) verify(data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	ok := false
+	switch pub := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(pub, digest[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, data, sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
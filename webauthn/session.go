@@ -0,0 +1,80 @@
+package webauthn
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Ceremony 類型
+const (
+	CeremonyRegistration = "registration"
+	CeremonyLogin        = "login"
+)
+
+// ErrSessionNotFound challenge 不存在、已使用或已過期
+var ErrSessionNotFound = errors.New("webauthn: session not found or expired")
+
+// SessionData 開始 ceremony 時保存、完成時取回的資料，以 challenge 作為索引
+type SessionData struct {
+	Challenge string
+	Ceremony  string
+	// MemberID 註冊時為目前的會員；登入時若先輸入 email 則為該會員，否則為 0
+	MemberID uint
+	// AllowedCredentials 登入時允許使用的 credential ID，空白表示任何已註冊的 credential
+	AllowedCredentials [][]byte
+	ExpiresAt          time.Time
+}
+
+// SessionStore 保存進行中的 ceremony；Take 取回後即刪除，確保 challenge 只能使用一次
+type SessionStore interface {
+	Save(ctx context.Context, data SessionData) error
+	Take(ctx context.Context, challenge string) (*SessionData, error)
+}
+
+// MemorySessionStore 以記憶體保存 ceremony，只適用於單一實例或測試
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]SessionData
+	now      func() time.Time
+}
+
+// NewMemorySessionStore 建立記憶體 session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]SessionData), now: time.Now}
+}
+
+// Save 保存 ceremony，並順便清除已過期的紀錄
+func (s *MemorySessionStore) Save(_ context.Context, data SessionData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, existing := range s.sessions {
+		if now.After(existing.ExpiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	if data.ExpiresAt.IsZero() {
+		data.ExpiresAt = now.Add(DefaultTimeout)
+	}
+	s.sessions[data.Challenge] = data
+	return nil
+}
+
+// Take 取回並刪除 ceremony
+func (s *MemorySessionStore) Take(_ context.Context, challenge string) (*SessionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.sessions[challenge]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	delete(s.sessions, challenge)
+	if s.now().After(data.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+	return &data, nil
+}
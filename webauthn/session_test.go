@@ -0,0 +1,32 @@
+package webauthn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Save(ctx, SessionData{Challenge: "abc", Ceremony: CeremonyLogin, MemberID: 7}))
+
+	data, err := store.Take(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), data.MemberID)
+	assert.Equal(t, now.Add(DefaultTimeout), data.ExpiresAt)
+
+	_, err = store.Take(ctx, "abc")
+	assert.ErrorIs(t, err, ErrSessionNotFound, "challenge 只能使用一次")
+
+	require.NoError(t, store.Save(ctx, SessionData{Challenge: "expired", ExpiresAt: now.Add(time.Minute)}))
+	now = now.Add(2 * time.Minute)
+	_, err = store.Take(ctx, "expired")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
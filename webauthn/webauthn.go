@@ -0,0 +1,566 @@
+// Package webauthn 實作 WebAuthn（passkey）relying party 的註冊與登入驗證。
+// 只要求 "none" attestation，不驗證認證器的製造商憑證鏈；安全性來自 origin 與 RP ID 綁定、
+// 使用者驗證（UV）與簽章計數器。
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 使用者完成 ceremony 的期限
+const DefaultTimeout = 5 * time.Minute
+
+// authenticator data 旗標
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackupState    = 0x10
+	flagAttestedData   = 0x40
+	flagExtensionData  = 0x80
+)
+
+var (
+	// ErrInvalidResponse 認證器回應格式錯誤，或 type、challenge 與 ceremony 不符
+	ErrInvalidResponse = errors.New("webauthn: invalid response")
+	// ErrOriginMismatch 回應來自不允許的 origin
+	ErrOriginMismatch = errors.New("webauthn: origin not allowed")
+	// ErrRPIDMismatch authenticator data 的 RP ID hash 不符
+	ErrRPIDMismatch = errors.New("webauthn: rp id mismatch")
+	// ErrUserNotVerified 認證器沒有完成使用者驗證（PIN、生物辨識）
+	ErrUserNotVerified = errors.New("webauthn: user not verified")
+	// ErrUnsupportedAttestation 不支援的 attestation 格式
+	ErrUnsupportedAttestation = errors.New("webauthn: unsupported attestation format")
+	// ErrSignCount 簽章計數器沒有遞增，credential 可能遭到複製
+	ErrSignCount = errors.New("webauthn: signature counter did not increase")
+)
+
+// URLEncodedBytes 在 JSON 中以 base64url（無 padding）表示的位元組
+type URLEncodedBytes []byte
+
+// MarshalJSON 編碼為 base64url 字串
+func (b URLEncodedBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON 解碼 base64url 字串，容許結尾的 padding
+func (b *URLEncodedBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// Config relying party 設定
+type Config struct {
+	// RPID 通常是網站的網域（不含 scheme 與 port），passkey 只能在此網域及其子網域使用
+	RPID   string
+	RPName string
+	// Origins 允許發起 ceremony 的前端 origin，例如 https://app.example.com
+	Origins []string
+	Timeout time.Duration
+}
+
+// RelyingParty 產生 ceremony 選項並驗證認證器的回應
+type RelyingParty struct {
+	config Config
+	rpHash [32]byte
+}
+
+// New 建立 relying party
+func New(config Config) (*RelyingParty, error) {
+	if config.RPID == "" {
+		return nil, errors.New("webauthn: rp id is required")
+	}
+	if len(config.Origins) == 0 {
+		return nil, errors.New("webauthn: at least one origin is required")
+	}
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	for i, origin := range config.Origins {
+		config.Origins[i] = strings.TrimRight(origin, "/")
+	}
+	return &RelyingParty{config: config, rpHash: sha256.Sum256([]byte(config.RPID))}, nil
+}
+
+// User 註冊 credential 的會員；ID 為不含個人資料的 user handle
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CredentialDescriptor 指定一個已註冊的 credential
+type CredentialDescriptor struct {
+	Type       string          `json:"type" example:"public-key"`
+	ID         URLEncodedBytes `json:"id" swaggertype:"string" example:"3q2-7w"`
+	Transports []string        `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor 建立 public-key 類型的 credential descriptor
+func NewCredentialDescriptor(id []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: id, Transports: transports}
+}
+
+type rpEntity struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          URLEncodedBytes `json:"id" swaggertype:"string"`
+	Name        string          `json:"name"`
+	DisplayName string          `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions 傳給 navigator.credentials.create() 的 publicKey 選項
+type CreationOptions struct {
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	Challenge              string                 `json:"challenge"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions 傳給 navigator.credentials.get() 的 publicKey 選項
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// AuthenticatorAttestationResponse navigator.credentials.create() 的 response
+type AuthenticatorAttestationResponse struct {
+	ClientDataJSON    URLEncodedBytes `json:"clientDataJSON" swaggertype:"string"`
+	AttestationObject URLEncodedBytes `json:"attestationObject" swaggertype:"string"`
+	Transports        []string        `json:"transports,omitempty"`
+}
+
+// AttestationResponse navigator.credentials.create() 回傳的 PublicKeyCredential（JSON 格式）
+type AttestationResponse struct {
+	ID       string                           `json:"id"`
+	RawID    URLEncodedBytes                  `json:"rawId" swaggertype:"string"`
+	Type     string                           `json:"type" example:"public-key"`
+	Response AuthenticatorAttestationResponse `json:"response"`
+}
+
+// AuthenticatorAssertionResponse navigator.credentials.get() 的 response
+type AuthenticatorAssertionResponse struct {
+	ClientDataJSON    URLEncodedBytes `json:"clientDataJSON" swaggertype:"string"`
+	AuthenticatorData URLEncodedBytes `json:"authenticatorData" swaggertype:"string"`
+	Signature         URLEncodedBytes `json:"signature" swaggertype:"string"`
+	UserHandle        URLEncodedBytes `json:"userHandle,omitempty" swaggertype:"string"`
+}
+
+// AssertionResponse navigator.credentials.get() 回傳的 PublicKeyCredential（JSON 格式）
+type AssertionResponse struct {
+	ID       string                         `json:"id"`
+	RawID    URLEncodedBytes                `json:"rawId" swaggertype:"string"`
+	Type     string                         `json:"type" example:"public-key"`
+	Response AuthenticatorAssertionResponse `json:"response"`
+}
+
+// clientData 瀏覽器產生的 clientDataJSON
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge 從 clientDataJSON 取出 challenge，用於查詢進行中的 ceremony
+func Challenge(clientDataJSON []byte) (string, error) {
+	var data clientData
+	if err := json.Unmarshal(clientDataJSON, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidResponse
+	}
+	return data.Challenge, nil
+}
+
+// Credential 註冊成功的 credential
+type Credential struct {
+	ID []byte
+	// PublicKey CBOR 編碼的 COSE_Key，登入時用來驗證簽章
+	PublicKey      []byte
+	Algorithm      int
+	SignCount      uint32
+	AAGUID         string
+	Transports     []string
+	BackupEligible bool
+}
+
+// StoredCredential 登入時驗證簽章所需的已註冊 credential
+type StoredCredential struct {
+	PublicKey []byte
+	SignCount uint32
+}
+
+// AssertionResult 登入驗證結果
+type AssertionResult struct {
+	// SignCount 認證器回報的新計數器，應寫回已註冊的 credential
+	SignCount   uint32
+	BackupState bool
+}
+
+// authenticatorData 解析後的 authenticator data
+type authenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+	// 以下只在註冊時（AT 旗標）存在
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// newChallenge 產生 32 位元組的隨機 challenge
+func newChallenge() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// BeginRegistration 產生註冊選項；exclude 為會員已註冊的 credential，避免同一認證器重複註冊
+func (rp *RelyingParty) BeginRegistration(user User, exclude []CredentialDescriptor) (*CreationOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := make([]credentialParameter, 0, len(supportedAlgorithms))
+	for _, alg := range supportedAlgorithms {
+		params = append(params, credentialParameter{Type: "public-key", Alg: alg})
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+
+	options := &CreationOptions{
+		RP:                 rpEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User:               userEntity{ID: user.ID, Name: user.Name, DisplayName: user.DisplayName},
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            rp.config.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}
+	session := &SessionData{
+		Challenge: challenge,
+		Ceremony:  CeremonyRegistration,
+		ExpiresAt: time.Now().Add(rp.config.Timeout),
+	}
+	return options, session, nil
+}
+
+// FinishRegistration 驗證 navigator.credentials.create() 的回應，成功時回傳要保存的 credential
+func (rp *RelyingParty) FinishRegistration(session *SessionData, resp *AttestationResponse) (*Credential, error) {
+	if session.Ceremony != CeremonyRegistration || resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", session.Challenge); err != nil {
+		return nil, err
+	}
+
+	value, n, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil || n != len(resp.Response.AttestationObject) {
+		return nil, ErrInvalidResponse
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if statement == nil || rawAuthData == nil {
+		return nil, ErrInvalidResponse
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.Flags&flagAttestedData == 0 {
+		return nil, ErrInvalidResponse
+	}
+	if len(resp.RawID) > 0 && !bytes.Equal(resp.RawID, authData.CredentialID) {
+		return nil, ErrInvalidResponse
+	}
+
+	key, err := parsePublicKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	if err := verifyAttestationStatement(format, statement, key, append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             authData.CredentialID,
+		PublicKey:      authData.PublicKey,
+		Algorithm:      key.Algorithm,
+		SignCount:      authData.SignCount,
+		AAGUID:         formatAAGUID(authData.AAGUID),
+		Transports:     resp.Response.Transports,
+		BackupEligible: authData.Flags&flagBackupEligible != 0,
+	}, nil
+}
+
+// BeginLogin 產生登入選項；allow 為空時由認證器列出可用的 passkey（discoverable credential）
+func (rp *RelyingParty) BeginLogin(allow []CredentialDescriptor) (*RequestOptions, *SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, nil, err
+	}
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+
+	allowed := make([][]byte, 0, len(allow))
+	for _, descriptor := range allow {
+		allowed = append(allowed, descriptor.ID)
+	}
+
+	options := &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.config.Timeout.Milliseconds(),
+		RPID:             rp.config.RPID,
+		AllowCredentials: allow,
+		UserVerification: "required",
+	}
+	session := &SessionData{
+		Challenge:          challenge,
+		Ceremony:           CeremonyLogin,
+		AllowedCredentials: allowed,
+		ExpiresAt:          time.Now().Add(rp.config.Timeout),
+	}
+	return options, session, nil
+}
+
+// FinishLogin 以已註冊的 credential 驗證 navigator.credentials.get() 的回應
+func (rp *RelyingParty) FinishLogin(session *SessionData, credential StoredCredential, resp *AssertionResponse) (*AssertionResult, error) {
+	if session.Ceremony != CeremonyLogin || resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if len(session.AllowedCredentials) > 0 && !containsCredential(session.AllowedCredentials, resp.RawID) {
+		return nil, ErrInvalidResponse
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", session.Challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := key.verify(signed, resp.Response.Signature); err != nil {
+		return nil, err
+	}
+
+	// 計數器為 0 表示認證器不支援（多數同步的 passkey），否則必須遞增
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return nil, ErrSignCount
+	}
+
+	return &AssertionResult{
+		SignCount:   authData.SignCount,
+		BackupState: authData.Flags&flagBackupState != 0,
+	}, nil
+}
+
+// verifyClientData 驗證 clientDataJSON 的 type、challenge 與 origin
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremonyType, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ErrInvalidResponse
+	}
+	if data.Type != ceremonyType {
+		return ErrInvalidResponse
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return ErrInvalidResponse
+	}
+	if data.CrossOrigin {
+		return ErrOriginMismatch
+	}
+	for _, origin := range rp.config.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+	return ErrOriginMismatch
+}
+
+// verifyAuthenticatorData 驗證 RP ID hash 以及使用者在場與使用者驗證旗標
+func (rp *RelyingParty) verifyAuthenticatorData(data *authenticatorData) error {
+	if subtle.ConstantTimeCompare(data.RPIDHash, rp.rpHash[:]) != 1 {
+		return ErrRPIDMismatch
+	}
+	if data.Flags&flagUserPresent == 0 || data.Flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// parseAuthenticatorData 解析 authenticator data（WebAuthn §6.1）
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalidResponse
+	}
+	data := &authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if data.Flags&flagAttestedData != 0 {
+		if len(rest) < 18 {
+			return nil, ErrInvalidResponse
+		}
+		data.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, ErrInvalidResponse
+		}
+		data.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		data.PublicKey = rest[:n]
+		rest = rest[n:]
+	}
+
+	if data.Flags&flagExtensionData != 0 {
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalidResponse
+		}
+		rest = rest[n:]
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidResponse
+	}
+	return data, nil
+}
+
+// verifyAttestationStatement 驗證 attestation statement。
+// 支援 "none" 與 "packed"（自我 attestation 或附憑證，但不驗證憑證鏈的信任）
+func verifyAttestationStatement(format string, statement map[interface{}]interface{}, key *publicKey, signed []byte) error {
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return ErrInvalidResponse
+		}
+		return nil
+	case "packed":
+		alg, _ := statement["alg"].(int64)
+		sig, _ := statement["sig"].([]byte)
+		if sig == nil {
+			return ErrInvalidResponse
+		}
+		chain, hasChain := statement["x5c"].([]interface{})
+		if !hasChain {
+			if int(alg) != key.Algorithm {
+				return ErrInvalidResponse
+			}
+			return key.verify(signed, sig)
+		}
+		if len(chain) == 0 {
+			return ErrInvalidResponse
+		}
+		der, _ := chain[0].([]byte)
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return ErrInvalidResponse
+		}
+		if int(alg) != AlgES256 {
+			return ErrUnsupportedAttestation
+		}
+		attestationKey := &publicKey{Algorithm: AlgES256, Key: cert.PublicKey}
+		return attestationKey.verify(signed, sig)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAttestation, format)
+	}
+}
+
+// containsCredential 判斷 id 是否在允許的 credential 清單中
+func containsCredential(allowed [][]byte, id []byte) bool {
+	for _, candidate := range allowed {
+		if bytes.Equal(candidate, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAAGUID 將認證器型號識別碼格式化為 UUID 字串
+func formatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+	s := hex.EncodeToString(aaguid)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
@@ -0,0 +1,270 @@
+package webauthn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOrigin = "https://app.example.com"
+	testRPID   = "example.com"
+)
+
+func newTestRelyingParty(t *testing.T) *RelyingParty {
+	t.Helper()
+	rp, err := New(Config{RPID: testRPID, RPName: "Member API", Origins: []string{testOrigin + "/"}})
+	require.NoError(t, err)
+	return rp
+}
+
+// register 以軟體認證器完成註冊
+func register(t *testing.T, rp *RelyingParty, authenticator *softAuthenticator) *Credential {
+	t.Helper()
+	options, session, err := rp.BeginRegistration(User{ID: []byte{0, 0, 0, 0, 0, 0, 0, 7}, Name: "admin@example.com", DisplayName: "管理員"}, nil)
+	require.NoError(t, err)
+	credential, err := rp.FinishRegistration(session, authenticator.create(options))
+	require.NoError(t, err)
+	return credential
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Origins: []string{testOrigin}})
+	assert.Error(t, err, "缺少 RP ID")
+
+	_, err = New(Config{RPID: testRPID})
+	assert.Error(t, err, "缺少 origin")
+
+	rp, err := New(Config{RPID: testRPID, Origins: []string{testOrigin}})
+	require.NoError(t, err)
+	assert.Equal(t, testRPID, rp.config.RPName)
+	assert.Equal(t, DefaultTimeout, rp.config.Timeout)
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	tests := []struct {
+		name   string
+		alg    int
+		format string
+	}{
+		{name: "ES256", alg: AlgES256, format: "none"},
+		{name: "EdDSA", alg: AlgEdDSA, format: "none"},
+		{name: "packed 自我 attestation", alg: AlgES256, format: "packed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newTestRelyingParty(t)
+			authenticator := newSoftAuthenticator(t, tt.alg, testOrigin, testRPID)
+			authenticator.format = tt.format
+
+			credential := register(t, rp, authenticator)
+			assert.Equal(t, authenticator.credentialID, credential.ID)
+			assert.Equal(t, tt.alg, credential.Algorithm)
+			assert.Equal(t, uint32(1), credential.SignCount)
+			assert.Equal(t, "00000000-0000-0000-0000-000000000000", credential.AAGUID)
+			assert.Equal(t, []string{"internal"}, credential.Transports)
+
+			stored := StoredCredential{PublicKey: credential.PublicKey, SignCount: credential.SignCount}
+			for i := 0; i < 2; i++ {
+				options, session, err := rp.BeginLogin([]CredentialDescriptor{NewCredentialDescriptor(credential.ID, nil)})
+				require.NoError(t, err)
+				assert.Equal(t, testRPID, options.RPID)
+				assert.Equal(t, "required", options.UserVerification)
+
+				assertion := authenticator.get(options)
+				assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 7}, []byte(assertion.Response.UserHandle))
+
+				result, err := rp.FinishLogin(session, stored, assertion)
+				require.NoError(t, err)
+				assert.Greater(t, result.SignCount, stored.SignCount)
+				stored.SignCount = result.SignCount
+			}
+		})
+	}
+}
+
+func TestFinishRegistrationRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(a *softAuthenticator, options *CreationOptions, session *SessionData)
+		wantErr error
+	}{
+		{
+			name: "來自其他 origin（釣魚網站）",
+			mutate: func(a *softAuthenticator, _ *CreationOptions, _ *SessionData) {
+				a.origin = "https://app.example.com.evil.test"
+			},
+			wantErr: ErrOriginMismatch,
+		},
+		{
+			name:    "其他 RP ID",
+			mutate:  func(a *softAuthenticator, _ *CreationOptions, _ *SessionData) { a.rpID = "evil.test" },
+			wantErr: ErrRPIDMismatch,
+		},
+		{
+			name:    "challenge 不符",
+			mutate:  func(_ *softAuthenticator, options *CreationOptions, _ *SessionData) { options.Challenge = "other" },
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:    "未完成使用者驗證",
+			mutate:  func(a *softAuthenticator, _ *CreationOptions, _ *SessionData) { a.flags = flagUserPresent },
+			wantErr: ErrUserNotVerified,
+		},
+		{
+			name:    "不支援的 attestation 格式",
+			mutate:  func(a *softAuthenticator, _ *CreationOptions, _ *SessionData) { a.format = "fido-u2f" },
+			wantErr: ErrUnsupportedAttestation,
+		},
+		{
+			name:    "以登入的 ceremony 完成註冊",
+			mutate:  func(_ *softAuthenticator, _ *CreationOptions, session *SessionData) { session.Ceremony = CeremonyLogin },
+			wantErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newTestRelyingParty(t)
+			authenticator := newSoftAuthenticator(t, AlgES256, testOrigin, testRPID)
+
+			options, session, err := rp.BeginRegistration(User{ID: []byte{7}, Name: "admin@example.com"}, nil)
+			require.NoError(t, err)
+			tt.mutate(authenticator, options, session)
+
+			_, err = rp.FinishRegistration(session, authenticator.create(options))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFinishLoginRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(a *softAuthenticator, stored *StoredCredential, resp *AssertionResponse)
+		wantErr error
+	}{
+		{
+			name: "簽章遭竄改",
+			mutate: func(_ *softAuthenticator, _ *StoredCredential, resp *AssertionResponse) {
+				resp.Response.Signature[len(resp.Response.Signature)-1] ^= 0xff
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "authenticator data 遭竄改",
+			mutate: func(_ *softAuthenticator, _ *StoredCredential, resp *AssertionResponse) {
+				resp.Response.AuthenticatorData[36]++
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "計數器沒有遞增（credential 遭複製）",
+			mutate: func(_ *softAuthenticator, stored *StoredCredential, _ *AssertionResponse) {
+				stored.SignCount = 100
+			},
+			wantErr: ErrSignCount,
+		},
+		{
+			name: "使用其他認證器的公鑰驗證",
+			mutate: func(_ *softAuthenticator, stored *StoredCredential, _ *AssertionResponse) {
+				stored.PublicKey = newSoftAuthenticator(t, AlgES256, testOrigin, testRPID).coseKey()
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name: "不在允許清單中的 credential",
+			mutate: func(_ *softAuthenticator, _ *StoredCredential, resp *AssertionResponse) {
+				resp.RawID = []byte("other-credential")
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name: "以註冊的 clientData 登入",
+			mutate: func(a *softAuthenticator, _ *StoredCredential, resp *AssertionResponse) {
+				var data clientData
+				require.NoError(a.t, json.Unmarshal(resp.Response.ClientDataJSON, &data))
+				resp.Response.ClientDataJSON = a.clientData("webauthn.create", data.Challenge)
+			},
+			wantErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newTestRelyingParty(t)
+			authenticator := newSoftAuthenticator(t, AlgES256, testOrigin, testRPID)
+			credential := register(t, rp, authenticator)
+
+			options, session, err := rp.BeginLogin([]CredentialDescriptor{NewCredentialDescriptor(credential.ID, nil)})
+			require.NoError(t, err)
+			resp := authenticator.get(options)
+			stored := StoredCredential{PublicKey: credential.PublicKey, SignCount: credential.SignCount}
+			tt.mutate(authenticator, &stored, resp)
+
+			_, err = rp.FinishLogin(session, stored, resp)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFinishLoginOriginAndVerification(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	authenticator := newSoftAuthenticator(t, AlgEdDSA, testOrigin, testRPID)
+	credential := register(t, rp, authenticator)
+	stored := StoredCredential{PublicKey: credential.PublicKey, SignCount: credential.SignCount}
+
+	// 釣魚網站即使轉送 challenge，瀏覽器也會寫入真正的 origin
+	authenticator.origin = "https://evil.test"
+	options, session, err := rp.BeginLogin(nil)
+	require.NoError(t, err)
+	_, err = rp.FinishLogin(session, stored, authenticator.get(options))
+	assert.ErrorIs(t, err, ErrOriginMismatch)
+
+	authenticator.origin = testOrigin
+	authenticator.flags = flagUserPresent
+	options, session, err = rp.BeginLogin(nil)
+	require.NoError(t, err)
+	_, err = rp.FinishLogin(session, stored, authenticator.get(options))
+	assert.ErrorIs(t, err, ErrUserNotVerified)
+}
+
+func TestSignCountZero(t *testing.T) {
+	rp := newTestRelyingParty(t)
+	// 不支援計數器的認證器（例如同步的 passkey）每次都回報 0
+	authenticator := newSoftAuthenticator(t, AlgES256, testOrigin, testRPID)
+	authenticator.noCounter = true
+	credential := register(t, rp, authenticator)
+	assert.Zero(t, credential.SignCount)
+
+	stored := StoredCredential{PublicKey: credential.PublicKey}
+	for i := 0; i < 2; i++ {
+		options, session, err := rp.BeginLogin(nil)
+		require.NoError(t, err)
+		result, err := rp.FinishLogin(session, stored, authenticator.get(options))
+		require.NoError(t, err)
+		assert.Zero(t, result.SignCount)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	challenge, err := Challenge([]byte(`{"type":"webauthn.get","challenge":"abc","origin":"https://app.example.com"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "abc", challenge)
+
+	_, err = Challenge([]byte(`not json`))
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func TestURLEncodedBytes(t *testing.T) {
+	data, err := json.Marshal(URLEncodedBytes{0xfb, 0xff})
+	require.NoError(t, err)
+	assert.Equal(t, `"-_8"`, string(data))
+
+	var decoded URLEncodedBytes
+	require.NoError(t, json.Unmarshal([]byte(`"-_8="`), &decoded), "容許 padding")
+	assert.Equal(t, URLEncodedBytes{0xfb, 0xff}, decoded)
+}